@@ -26,6 +26,43 @@ type Cache[K comparable] interface {
 	Remove(ctx context.Context, key K)
 }
 
+// A Resizer is a [Cache] namespace whose capacity can be changed,
+// or its content discarded, at runtime. Backends unable to do it
+// return [ErrNotSupported].
+type Resizer interface {
+	// Resize changes the capacity of the namespace, evicting
+	// entries as needed, and returns how many were evicted
+	Resize(ctx context.Context, cacheBytes int64) (int, error)
+	// Purge removes all entries from the namespace
+	Purge(ctx context.Context) error
+}
+
+// An Inspector is a [Cache] namespace that allows looking at its
+// entries without affecting their recency nor the hit statistics,
+// and without loading missing entries.
+type Inspector[K comparable] interface {
+	// Peek reads an entry into a Sink, or returns [ErrNotFound]
+	Peek(ctx context.Context, key K, dest Sink) error
+	// Contains tells if there is an entry for the key
+	Contains(ctx context.Context, key K) bool
+	// TTL returns the remaining time-to-live of an entry and if it
+	// was found. A zero duration on a found entry means it never
+	// expires.
+	TTL(ctx context.Context, key K) (time.Duration, bool)
+}
+
+// A Tagger is a [Cache] namespace whose entries can be labelled
+// with tags when stored, to be removed together later.
+type Tagger[K comparable] interface {
+	// SetTagged adds an entry to the Cache labelled with the given
+	// tags, replacing those of a previous entry
+	SetTagged(ctx context.Context, key K, value []byte,
+		expire time.Time, cacheType Type, tags ...string) error
+	// InvalidateTag removes all entries labelled with the tag,
+	// and returns how many were removed
+	InvalidateTag(ctx context.Context, tag string) (int, error)
+}
+
 // A Getter loads data for a key.
 type Getter[K comparable] interface {
 	// Get returns the value identified by key, populating dest.
@@ -61,3 +98,16 @@ func (f SetterFunc[K]) Set(ctx context.Context, key K, value []byte,
 	//
 	return f(ctx, key, value, expire, cacheType)
 }
+
+// A Deleter removes the data of a key
+type Deleter[K comparable] interface {
+	Delete(ctx context.Context, key K) error
+}
+
+// A DeleterFunc implements Deleter with a function
+type DeleterFunc[K comparable] func(ctx context.Context, key K) error
+
+// Delete allows a DeleterFunc to implement the Deleter interface
+func (f DeleterFunc[K]) Delete(ctx context.Context, key K) error {
+	return f(ctx, key)
+}
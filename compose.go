@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// KeySeparator separates the components of a composite key
+const KeySeparator = ':'
+
+// Type tags prefixing each component of a composite key
+const (
+	keyTagString   = 's'
+	keyTagBool     = 'b'
+	keyTagInt      = 'i'
+	keyTagUnsigned = 'u'
+)
+
+// JoinKey builds a composite string key from strings, byte slices,
+// [fmt.Stringer]s, booleans and integers. Each component is prefixed
+// by a tag of its kind and separated by [KeySeparator], escaping it
+// and '%' within them, so different tuples never produce the same key.
+// Textual components are interchangeable, as are integers of the same
+// signedness, but JoinKey(7) and JoinKey("7") differ. Use [SplitKey]
+// to get the components back.
+func JoinKey(parts ...any) (string, error) {
+	var sb strings.Builder
+
+	for i, p := range parts {
+		tag, s, err := keyPart(p)
+		if err != nil {
+			return "", core.Wrapf(err, "component %v", i)
+		}
+
+		if i > 0 {
+			_ = sb.WriteByte(KeySeparator)
+		}
+		_ = sb.WriteByte(tag)
+		escapeKeyPart(&sb, s)
+	}
+	return sb.String(), nil
+}
+
+// MustJoinKey is like [JoinKey] but panics if a component
+// has an unsupported type
+func MustJoinKey(parts ...any) string {
+	s, err := JoinKey(parts...)
+	if err != nil {
+		core.Panic(err)
+	}
+	return s
+}
+
+// SplitKey returns the unescaped components of a key built by [JoinKey],
+// with integers and booleans in their textual representation. The key
+// of an empty tuple has no components.
+func SplitKey(key string) ([]string, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(key, string(KeySeparator))
+	for i, s := range parts {
+		if s == "" || !validKeyTag(s[0]) {
+			return nil, core.Wrapf(core.ErrInvalid, "component %v: bad type tag", i)
+		}
+
+		v, err := unescapeKeyPart(s[1:])
+		if err != nil {
+			return nil, core.Wrapf(err, "component %v", i)
+		}
+		parts[i] = v
+	}
+	return parts, nil
+}
+
+func validKeyTag(c byte) bool {
+	switch c {
+	case keyTagString, keyTagBool, keyTagInt, keyTagUnsigned:
+		return true
+	default:
+		return false
+	}
+}
+
+// HashKey returns the SHA-256 of the components of a composite key,
+// for backends using [32]byte keys. See [JoinKey] for the supported
+// types.
+func HashKey(parts ...any) ([32]byte, error) {
+	var out [32]byte
+
+	h := sha256.New()
+	if err := hashKeyParts(h, parts); err != nil {
+		return out, err
+	}
+	h.Sum(out[:0])
+	return out, nil
+}
+
+// HashKey64 returns the 64-bit FNV-1a hash of the components of a
+// composite key. It's faster than [HashKey] but collisions are more
+// likely. See [JoinKey] for the supported types.
+func HashKey64(parts ...any) (uint64, error) {
+	h := fnv.New64a()
+	if err := hashKeyParts(h, parts); err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+// hashKeyParts writes each component prefixed by its type tag and
+// its length, so the boundaries are unambiguous
+func hashKeyParts(h hash.Hash, parts []any) error {
+	var buf [1 + binary.MaxVarintLen64]byte
+
+	for i, p := range parts {
+		tag, s, err := keyPart(p)
+		if err != nil {
+			return core.Wrapf(err, "component %v", i)
+		}
+
+		buf[0] = tag
+		n := binary.PutUvarint(buf[1:], uint64(len(s)))
+		_, _ = h.Write(buf[:1+n])
+		_, _ = h.Write([]byte(s))
+	}
+	return nil
+}
+
+// revive:disable:cyclomatic
+
+// keyPart converts a component into its type tag and its textual
+// representation
+func keyPart(p any) (byte, string, error) {
+	// revive:enable:cyclomatic
+	switch v := p.(type) {
+	case string:
+		return keyTagString, v, nil
+	case []byte:
+		return keyTagString, string(v), nil
+	case bool:
+		return keyTagBool, strconv.FormatBool(v), nil
+	case int:
+		return keyTagInt, strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return keyTagInt, strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return keyTagInt, strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return keyTagInt, strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return keyTagInt, strconv.FormatInt(v, 10), nil
+	case uint:
+		return keyTagUnsigned, strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return keyTagUnsigned, strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return keyTagUnsigned, strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return keyTagUnsigned, strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return keyTagUnsigned, strconv.FormatUint(v, 10), nil
+	case fmt.Stringer:
+		return keyTagString, v.String(), nil
+	default:
+		return 0, "", core.Wrapf(core.ErrInvalid, "unsupported key type %T", p)
+	}
+}
+
+func escapeKeyPart(sb *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '%', KeySeparator:
+			_, _ = fmt.Fprintf(sb, "%%%02X", c)
+		default:
+			_ = sb.WriteByte(c)
+		}
+	}
+}
+
+func unescapeKeyPart(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			_ = sb.WriteByte(s[i])
+			continue
+		}
+
+		if i+3 > len(s) {
+			return "", core.Wrap(core.ErrInvalid, "bad escape sequence")
+		}
+
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", core.Wrap(core.ErrInvalid, "bad escape sequence")
+		}
+		_ = sb.WriteByte(byte(c))
+		i += 2
+	}
+	return sb.String(), nil
+}
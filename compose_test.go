@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func TestJoinKey(t *testing.T) {
+	t.Run("round trip", runTestJoinKeyRoundTrip)
+	t.Run("empty", runTestJoinKeyEmpty)
+	t.Run("types", runTestJoinKeyTypes)
+	t.Run("bad keys", runTestJoinKeyBad)
+}
+
+func runTestJoinKeyRoundTrip(t *testing.T) {
+	key, err := JoinKey("a:b", []byte("50%"), true, -7, uint64(42), int8(-1), uint16(9))
+	core.AssertMustNoError(t, err, "JoinKey")
+
+	parts, err := SplitKey(key)
+	core.AssertNoError(t, err, "SplitKey")
+	core.AssertSliceEqual(t, []string{"a:b", "50%", "true", "-7", "42", "-1", "9"}, parts, "SplitKey")
+}
+
+func runTestJoinKeyEmpty(t *testing.T) {
+	none := MustJoinKey()
+	empty := MustJoinKey("")
+	core.AssertNotEqual(t, none, empty, "no components vs one empty")
+
+	parts, err := SplitKey(none)
+	core.AssertNoError(t, err, "SplitKey none")
+	core.AssertEqual(t, 0, len(parts), "SplitKey none")
+
+	parts, err = SplitKey(empty)
+	core.AssertNoError(t, err, "SplitKey empty")
+	core.AssertSliceEqual(t, []string{""}, parts, "SplitKey empty")
+
+	parts, err = SplitKey(MustJoinKey("", ""))
+	core.AssertNoError(t, err, "SplitKey two empty")
+	core.AssertSliceEqual(t, []string{"", ""}, parts, "SplitKey two empty")
+}
+
+func runTestJoinKeyTypes(t *testing.T) {
+	core.AssertNotEqual(t, MustJoinKey(7), MustJoinKey("7"), "int vs string")
+	core.AssertNotEqual(t, MustJoinKey(7), MustJoinKey(uint(7)), "int vs uint")
+	core.AssertNotEqual(t, MustJoinKey(true), MustJoinKey("true"), "bool vs string")
+	core.AssertEqual(t, MustJoinKey(7), MustJoinKey(int8(7)), "same signedness")
+	core.AssertEqual(t, MustJoinKey("x"), MustJoinKey([]byte("x")), "textual")
+
+	h1, err := HashKey(7)
+	core.AssertMustNoError(t, err, "HashKey")
+	h2, err := HashKey("7")
+	core.AssertMustNoError(t, err, "HashKey")
+	core.AssertNotEqual(t, h1, h2, "HashKey int vs string")
+
+	n1, err := HashKey64("a", "b")
+	core.AssertMustNoError(t, err, "HashKey64")
+	n2, err := HashKey64("ab")
+	core.AssertMustNoError(t, err, "HashKey64")
+	core.AssertNotEqual(t, n1, n2, "HashKey64 boundaries")
+
+	_, err = JoinKey(1.5)
+	core.AssertErrorIs(t, err, core.ErrInvalid, "unsupported type")
+}
+
+func runTestJoinKeyBad(t *testing.T) {
+	for _, key := range []string{":", "x1", "s1:", "s%4", "s%zz"} {
+		_, err := SplitKey(key)
+		core.AssertErrorIs(t, err, core.ErrInvalid, "SplitKey %q", key)
+	}
+}
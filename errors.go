@@ -10,4 +10,11 @@ var (
 
 	// ErrNoData indicates to data was provided.
 	ErrNoData = core.Wrap(core.ErrInvalid, "no data")
+
+	// ErrNotFound indicates the entry isn't in the cache.
+	ErrNotFound = core.Wrap(core.ErrNotExists, "not found")
+
+	// ErrNotSupported indicates the backend doesn't support
+	// an optional operation.
+	ErrNotSupported = core.Wrap(core.ErrNotImplemented, "not supported")
 )
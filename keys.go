@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ KeyCodec[string]   = StringKeys{}
+	_ KeyCodec[uint32]   = Uint32Keys{}
+	_ KeyCodec[uint64]   = Uint64Keys{}
+	_ KeyCodec[[32]byte] = Bytes32Keys{}
+)
+
+// A KeyCodec converts keys into a stable string representation
+// and back, for backends and tools that need to store or transmit
+// them.
+type KeyCodec[K comparable] interface {
+	// EncodeKey converts a key into its string representation
+	EncodeKey(key K) (string, error)
+	// DecodeKey converts a string representation back into a key
+	DecodeKey(s string) (K, error)
+}
+
+// StringKeys is a [KeyCodec] for string keys, used as-is.
+type StringKeys struct{}
+
+// EncodeKey returns the key unchanged
+func (StringKeys) EncodeKey(key string) (string, error) { return key, nil }
+
+// DecodeKey returns the string unchanged
+func (StringKeys) DecodeKey(s string) (string, error) { return s, nil }
+
+// Uint32Keys is a [KeyCodec] for uint32 keys, encoded in decimal.
+type Uint32Keys struct{}
+
+// EncodeKey returns the decimal representation of the key
+func (Uint32Keys) EncodeKey(key uint32) (string, error) {
+	return strconv.FormatUint(uint64(key), 10), nil
+}
+
+// DecodeKey parses a decimal representation of a key
+func (Uint32Keys) DecodeKey(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, core.Wrap(core.ErrInvalid, "bad key")
+	}
+	return uint32(v), nil
+}
+
+// Uint64Keys is a [KeyCodec] for uint64 keys, encoded in decimal.
+type Uint64Keys struct{}
+
+// EncodeKey returns the decimal representation of the key
+func (Uint64Keys) EncodeKey(key uint64) (string, error) {
+	return strconv.FormatUint(key, 10), nil
+}
+
+// DecodeKey parses a decimal representation of a key
+func (Uint64Keys) DecodeKey(s string) (uint64, error) {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, core.Wrap(core.ErrInvalid, "bad key")
+	}
+	return v, nil
+}
+
+// Bytes32Keys is a [KeyCodec] for [32]byte keys, encoded in hexadecimal.
+type Bytes32Keys struct{}
+
+// EncodeKey returns the hexadecimal representation of the key
+func (Bytes32Keys) EncodeKey(key [32]byte) (string, error) {
+	return hex.EncodeToString(key[:]), nil
+}
+
+// DecodeKey parses a hexadecimal representation of a key
+func (Bytes32Keys) DecodeKey(s string) ([32]byte, error) {
+	var key [32]byte
+
+	if hex.DecodedLen(len(s)) != len(key) {
+		return key, core.Wrap(core.ErrInvalid, "bad key length")
+	}
+
+	if _, err := hex.Decode(key[:], []byte(s)); err != nil {
+		return key, core.Wrap(core.ErrInvalid, "bad key")
+	}
+	return key, nil
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+
+	"darvaza.org/core"
+)
+
+var ctxGetObserver = core.NewContextKey[GetObserver]("cache.GetObserver")
+
+// A GetObserver is notified of how a Get call is served, allowing
+// instrumentation to look inside the Cache implementations. It's
+// attached to the context of the call using [WithGetObserver], and
+// it must be safe for concurrent use.
+type GetObserver interface {
+	// OnHit is called when the value is found in a local cache
+	OnHit(ctx context.Context)
+	// OnWait is called when the call waits for a load in
+	// progress instead of starting its own
+	OnWait(ctx context.Context)
+	// OnLoad is called before calling the [Getter], and returns
+	// the context to use for it and a function to call with
+	// the result
+	OnLoad(ctx context.Context) (context.Context, func(error))
+	// OnPeer is called before asking another peer for the value,
+	// and returns the context to use for it and a function to call
+	// with the result
+	OnPeer(ctx context.Context, peer string) (context.Context, func(error))
+}
+
+// WithGetObserver attaches a [GetObserver] to a context
+func WithGetObserver(ctx context.Context, obs GetObserver) context.Context {
+	return ctxGetObserver.WithValue(ctx, obs)
+}
+
+// GetObserverFromContext returns the [GetObserver] attached to a context,
+// if any
+func GetObserverFromContext(ctx context.Context) (GetObserver, bool) {
+	obs, ok := ctxGetObserver.Get(ctx)
+	return obs, ok && obs != nil
+}
+
+// ObserveHit notifies the [GetObserver] of the context, if any, that
+// the value was found in a local cache
+func ObserveHit(ctx context.Context) {
+	if obs, ok := GetObserverFromContext(ctx); ok {
+		obs.OnHit(ctx)
+	}
+}
+
+// ObserveWait notifies the [GetObserver] of the context, if any, that
+// the call is waiting for a load in progress
+func ObserveWait(ctx context.Context) {
+	if obs, ok := GetObserverFromContext(ctx); ok {
+		obs.OnWait(ctx)
+	}
+}
+
+// ObserveLoad notifies the [GetObserver] of the context, if any, that
+// the [Getter] is about to be called. It returns the context to use
+// for the call and a function to call with its result.
+func ObserveLoad(ctx context.Context) (context.Context, func(error)) {
+	if obs, ok := GetObserverFromContext(ctx); ok {
+		return obs.OnLoad(ctx)
+	}
+	return ctx, observeNothing
+}
+
+// ObservePeer notifies the [GetObserver] of the context, if any, that
+// another peer is about to be asked for the value. It returns the
+// context to use for the request and a function to call with its result.
+func ObservePeer(ctx context.Context, peer string) (context.Context, func(error)) {
+	if obs, ok := GetObserverFromContext(ctx); ok {
+		return obs.OnPeer(ctx, peer)
+	}
+	return ctx, observeNothing
+}
+
+func observeNothing(error) {}
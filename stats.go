@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ExtendedStats complements [Stats] with details on how the misses
+// of a Cache namespace were served. The load counters are shared by
+// all [Type]s of the namespace.
+type ExtendedStats struct {
+	Stats
+
+	// Loads counts the calls to the [Getter]
+	Loads int64
+	// LoadErrors counts the calls to the [Getter] that failed
+	LoadErrors int64
+	// DedupWaits counts the callers that waited for a load in
+	// progress instead of starting their own
+	DedupWaits int64
+	// LocalHits counts the requests served by the local caches
+	LocalHits int64
+	// PeerHits counts the values obtained from other peers
+	PeerHits int64
+	// PeerErrors counts the failed requests to other peers
+	PeerErrors int64
+
+	// LoadLatency is the distribution of the duration of the loads
+	LoadLatency Histogram
+}
+
+// An ExtendedStatser is a [Cache] namespace able to provide [ExtendedStats]
+type ExtendedStatser interface {
+	// ExtendedStats returns extended stats about the Cache namespace
+	ExtendedStats(Type) ExtendedStats
+}
+
+// Histogram is a snapshot of a distribution of durations
+type Histogram struct {
+	// Bounds are the inclusive upper bounds of the buckets, sorted
+	Bounds []time.Duration
+	// Counts are the number of observations on each bucket, with
+	// an extra one at the end for those above the last bound
+	Counts []int64
+	// Count is the total number of observations
+	Count int64
+	// Sum is the total of the observed durations
+	Sum time.Duration
+}
+
+// Mean returns the average of the observed durations
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// latencyBounds are the upper bounds of the buckets of the LoadLatency
+// [Histogram] of [LoadStats]
+var latencyBounds = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// LatencyBounds returns the upper bounds of the buckets used by
+// [LoadStats] to track the duration of the loads
+func LatencyBounds() []time.Duration {
+	return append([]time.Duration(nil), latencyBounds[:]...)
+}
+
+// LoadStats accumulates the load counters of [ExtendedStats]. It's safe
+// for concurrent use, and the zero value is ready to use.
+type LoadStats struct {
+	loads      atomic.Int64
+	loadErrors atomic.Int64
+	dedupWaits atomic.Int64
+	localHits  atomic.Int64
+	peerHits   atomic.Int64
+	peerErrors atomic.Int64
+
+	mu     sync.Mutex
+	counts [len(latencyBounds) + 1]int64
+	sum    time.Duration
+}
+
+// RecordLoad accounts for a call to the [Getter] that took the given
+// duration, and failed if err isn't nil
+func (ls *LoadStats) RecordLoad(d time.Duration, err error) {
+	ls.loads.Add(1)
+	if err != nil {
+		ls.loadErrors.Add(1)
+	}
+
+	i := sort.Search(len(latencyBounds), func(i int) bool {
+		return d <= latencyBounds[i]
+	})
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.counts[i]++
+	ls.sum += d
+}
+
+// RecordDedupWait accounts for a caller that waited for a load in progress
+func (ls *LoadStats) RecordDedupWait() { ls.dedupWaits.Add(1) }
+
+// RecordLocalHit accounts for a request served by the local caches
+func (ls *LoadStats) RecordLocalHit() { ls.localHits.Add(1) }
+
+// RecordPeerHit accounts for a value obtained from another peer
+func (ls *LoadStats) RecordPeerHit() { ls.peerHits.Add(1) }
+
+// RecordPeerError accounts for a failed request to another peer
+func (ls *LoadStats) RecordPeerError() { ls.peerErrors.Add(1) }
+
+// Extend returns [ExtendedStats] combining the given [Stats] with the
+// accumulated load counters
+func (ls *LoadStats) Extend(s Stats) ExtendedStats {
+	out := ExtendedStats{
+		Stats:      s,
+		Loads:      ls.loads.Load(),
+		LoadErrors: ls.loadErrors.Load(),
+		DedupWaits: ls.dedupWaits.Load(),
+		LocalHits:  ls.localHits.Load(),
+		PeerHits:   ls.peerHits.Load(),
+		PeerErrors: ls.peerErrors.Load(),
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	out.LoadLatency = Histogram{
+		Bounds: LatencyBounds(),
+		Counts: append([]int64(nil), ls.counts[:]...),
+		Sum:    ls.sum,
+	}
+	for _, n := range ls.counts {
+		out.LoadLatency.Count += n
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestLoadStats(t *testing.T) {
+	t.Run("buckets", runTestLoadStatsBuckets)
+	t.Run("counters", runTestLoadStatsCounters)
+	t.Run("extend copies", runTestLoadStatsExtendCopies)
+}
+
+func runTestLoadStatsBuckets(t *testing.T) {
+	var ls LoadStats
+
+	ls.RecordLoad(0, nil)                                  // first bucket
+	ls.RecordLoad(time.Millisecond, nil)                   // first bound, inclusive
+	ls.RecordLoad(time.Millisecond+1, nil)                 // second bucket
+	ls.RecordLoad(10*time.Second, nil)                     // last bound
+	ls.RecordLoad(10*time.Second+1, core.ErrInvalid)       // overflow
+	ls.RecordLoad(time.Minute, nil)                        // overflow
+	ls.RecordLoad(300*time.Millisecond, core.ErrNotExists) // 500ms bucket
+
+	h := ls.Extend(Stats{}).LoadLatency
+	core.AssertSliceEqual(t, LatencyBounds(), h.Bounds, "Bounds")
+
+	want := make([]int64, len(latencyBounds)+1)
+	want[0] = 2
+	want[1] = 1
+	want[7] = 1
+	want[10] = 1
+	want[11] = 2
+	core.AssertSliceEqual(t, want, h.Counts, "Counts")
+	core.AssertEqual(t, int64(7), h.Count, "Count")
+
+	sum := 2*time.Millisecond + 1 + 20*time.Second + 1 + time.Minute + 300*time.Millisecond
+	core.AssertEqual(t, sum, h.Sum, "Sum")
+	core.AssertEqual(t, sum/7, h.Mean(), "Mean")
+	core.AssertEqual(t, time.Duration(0), Histogram{}.Mean(), "empty Mean")
+}
+
+func runTestLoadStatsCounters(t *testing.T) {
+	var ls LoadStats
+
+	ls.RecordLoad(time.Millisecond, nil)
+	ls.RecordLoad(time.Millisecond, core.ErrInvalid)
+	ls.RecordDedupWait()
+	ls.RecordLocalHit()
+	ls.RecordLocalHit()
+	ls.RecordPeerHit()
+	ls.RecordPeerError()
+
+	s := Stats{Gets: 5, Hits: 2, Items: 1}
+	es := ls.Extend(s)
+	core.AssertEqual(t, s, es.Stats, "Stats")
+	core.AssertEqual(t, int64(2), es.Loads, "Loads")
+	core.AssertEqual(t, int64(1), es.LoadErrors, "LoadErrors")
+	core.AssertEqual(t, int64(1), es.DedupWaits, "DedupWaits")
+	core.AssertEqual(t, int64(2), es.LocalHits, "LocalHits")
+	core.AssertEqual(t, int64(1), es.PeerHits, "PeerHits")
+	core.AssertEqual(t, int64(1), es.PeerErrors, "PeerErrors")
+}
+
+func runTestLoadStatsExtendCopies(t *testing.T) {
+	var ls LoadStats
+
+	ls.RecordLoad(time.Millisecond, nil)
+	es := ls.Extend(Stats{})
+
+	// later loads don't change the snapshot
+	ls.RecordLoad(time.Millisecond, nil)
+	core.AssertEqual(t, int64(1), es.LoadLatency.Counts[0], "snapshot Counts")
+	core.AssertEqual(t, int64(1), es.LoadLatency.Count, "snapshot Count")
+
+	// nor changing the snapshot the counters
+	es.LoadLatency.Counts[0] = 100
+	es.LoadLatency.Bounds[0] = time.Hour
+	h := ls.Extend(Stats{}).LoadLatency
+	core.AssertEqual(t, int64(2), h.Counts[0], "Counts")
+	core.AssertEqual(t, time.Millisecond, h.Bounds[0], "Bounds")
+	core.AssertEqual(t, time.Millisecond, LatencyBounds()[0], "LatencyBounds")
+}
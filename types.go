@@ -16,6 +16,12 @@ type Store[K comparable] interface {
 	SetLogger(log slog.Logger)
 }
 
+// A Lister is a [Store] able to enumerate its Cache namespaces
+type Lister interface {
+	// CacheNames returns the names of the Cache namespaces
+	CacheNames() []string
+}
+
 // Stats provides a snapshot on the state of a Cache namespace
 type Stats struct {
 	Bytes     int64
@@ -35,3 +41,16 @@ const (
 	// not owned by the application.
 	HotCache
 )
+
+// String returns the name of the [Type], "main" or "hot", or an empty
+// string if it isn't valid
+func (t Type) String() string {
+	switch t {
+	case MainCache:
+		return "main"
+	case HotCache:
+		return "hot"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,3 @@
+// Package boltcache provides a persistent cache.Cache backed
+// by an embedded bbolt database
+package boltcache
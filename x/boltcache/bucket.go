@@ -0,0 +1,312 @@
+package boltcache
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/slog"
+)
+
+var (
+	_ memcache.AdderGetter[string] = (*Bucket[string])(nil)
+)
+
+// Bucket is a namespace of entries with TTL and maximum total size
+// stored in a bbolt database. When space is needed, expired entries
+// are evicted first, and then the oldest inserted.
+type Bucket[K comparable] struct {
+	mu       sync.Mutex
+	db       *database
+	name     []byte
+	codec    cache.KeyCodec[K]
+	maxBytes int64
+	log      slog.Logger
+	stats    cache.Stats
+}
+
+func newBucket[K comparable](db *database, name string, cacheBytes int64,
+	codec cache.KeyCodec[K]) (*Bucket[K], error) {
+	//
+	b := &Bucket[K]{
+		db:       db,
+		name:     []byte(name),
+		codec:    codec,
+		maxBytes: cacheBytes,
+	}
+
+	// create buckets and apply the size
+	var n int
+	err := db.Update(func(tx *bolt.Tx) error {
+		bs, _, err := openBuckets(tx, b.name)
+		if err == nil {
+			n, err = bs.prune(cacheBytes)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.stats.Evictions = int64(n)
+	return b, nil
+}
+
+func (b *Bucket[K]) encodeKey(key K) ([]byte, bool) {
+	s, err := b.codec.EncodeKey(key)
+	if err != nil {
+		b.logError(err, "encode key")
+		return nil, false
+	}
+	return []byte(s), true
+}
+
+// Get attempts to find an entry, and returns its value, expiration date
+// if any, and if it was found or not
+func (b *Bucket[K]) Get(key K) ([]byte, *time.Time, bool) {
+	v, ex, ok := b.Peek(key)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.Gets++
+	if ok {
+		b.stats.Hits++
+	}
+	return v, ex, ok
+}
+
+// Peek is like Get but it doesn't update the statistics
+func (b *Bucket[K]) Peek(key K) ([]byte, *time.Time, bool) {
+	r, ok := b.read(key, true)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if r.expire.IsZero() {
+		return r.value, nil, true
+	}
+	return r.value, &r.expire, true
+}
+
+func (b *Bucket[K]) read(key K, withValue bool) (*record, bool) {
+	k, ok := b.encodeKey(key)
+	if !ok {
+		return nil, false
+	}
+
+	var r *record
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bs, ok, err := openBuckets(tx, b.name)
+		if ok {
+			r, _ = bs.get(k, withValue)
+		}
+		return err
+	})
+
+	switch {
+	case err != nil:
+		b.logError(err, "read")
+		return nil, false
+	case r == nil || r.Expired(time.Now()):
+		return nil, false
+	default:
+		return r, true
+	}
+}
+
+// Contains tells if a non-expired entry exists for the key
+func (b *Bucket[K]) Contains(key K) bool {
+	_, ok := b.read(key, false)
+	return ok
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was
+// found. A zero duration on a found entry means it never expires.
+func (b *Bucket[K]) TTL(key K) (time.Duration, bool) {
+	r, ok := b.read(key, false)
+	switch {
+	case !ok:
+		return 0, false
+	case r.expire.IsZero():
+		return 0, true
+	default:
+		ttl := time.Until(r.expire)
+		return max(ttl, 0), ttl > 0
+	}
+}
+
+// Add stores an entry and its expiration date, and returns true if entries
+// were removed to free capacity. If expire is zero, it never expires.
+func (b *Bucket[K]) Add(key K, value []byte, expire time.Time) bool {
+	k, ok := b.encodeKey(key)
+	if !ok {
+		return false
+	}
+
+	maxBytes := b.MaxBytes()
+	n, err := b.update(func(bs *buckets) (int, error) {
+		if err := bs.put(k, value, expire); err != nil {
+			return 0, err
+		}
+		return bs.prune(maxBytes)
+	})
+
+	b.evicted(n, err, "add")
+	return n > 0
+}
+
+// Evict removes an entry if present
+func (b *Bucket[K]) Evict(key K) {
+	k, ok := b.encodeKey(key)
+	if !ok {
+		return
+	}
+
+	n, err := b.update(func(bs *buckets) (int, error) {
+		items := bs.counter(metaItems)
+		err := bs.remove(k)
+		return int(items - bs.counter(metaItems)), err
+	})
+
+	b.evicted(n, err, "evict")
+}
+
+// EvictExpired removes all expired entries using the expiry index,
+// and returns how many were removed
+func (b *Bucket[K]) EvictExpired() int {
+	n, err := b.update(func(bs *buckets) (int, error) {
+		return bs.evictExpired(time.Now())
+	})
+
+	b.evicted(n, err, "evict expired")
+	return n
+}
+
+// Resize changes the maximum total size of the [Bucket], evicting expired
+// entries first and then the oldest if needed, and returns the number of
+// evicted entries.
+func (b *Bucket[K]) Resize(cacheBytes int64) int {
+	b.mu.Lock()
+	b.maxBytes = cacheBytes
+	b.mu.Unlock()
+
+	n, err := b.update(func(bs *buckets) (int, error) {
+		return bs.prune(cacheBytes)
+	})
+
+	b.evicted(n, err, "resize")
+	return n
+}
+
+// Purge removes all entries from the [Bucket]
+func (b *Bucket[K]) Purge() error {
+	var n int
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bs, ok, err := openBuckets(tx, b.name)
+		if !ok {
+			return err
+		}
+
+		n = int(bs.counter(metaItems))
+		err = tx.DeleteBucket(b.name)
+		if err == nil {
+			_, _, err = openBuckets(tx, b.name)
+		}
+		return err
+	})
+
+	b.evicted(n, err, "purge")
+	return err
+}
+
+// update runs a function on the namespace's buckets within
+// a read-write transaction
+func (b *Bucket[K]) update(fn func(*buckets) (int, error)) (int, error) {
+	var n int
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bs, _, err := openBuckets(tx, b.name)
+		if err == nil {
+			n, err = fn(bs)
+		}
+		return err
+	})
+	if err != nil {
+		// rolled back
+		return 0, err
+	}
+	return n, nil
+}
+
+func (b *Bucket[K]) evicted(n int, err error, op string) {
+	b.mu.Lock()
+	b.stats.Evictions += int64(n)
+	b.mu.Unlock()
+
+	if err != nil {
+		b.logError(err, op)
+	}
+}
+
+// MaxBytes returns the maximum total size of the [Bucket]
+func (b *Bucket[K]) MaxBytes() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.maxBytes
+}
+
+// Stats returns statistics about the [Bucket]. Bytes and Items are
+// stored in the database, and account for keys and records.
+func (b *Bucket[K]) Stats() cache.Stats {
+	var bytes, items int64
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bs, ok, err := openBuckets(tx, b.name)
+		if ok {
+			bytes, items = bs.counter(metaBytes), bs.counter(metaItems)
+		}
+		return err
+	})
+	if err != nil {
+		b.logError(err, "stats")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.Bytes, stats.Items = bytes, items
+	return stats
+}
+
+// SetLogger attaches a [slog.Logger] to the [Bucket]
+func (b *Bucket[K]) SetLogger(log slog.Logger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.log = log
+}
+
+func (b *Bucket[K]) logError(err error, op string) {
+	if errors.Is(err, ErrClosed) {
+		return
+	}
+
+	b.mu.Lock()
+	l := b.log
+	b.mu.Unlock()
+
+	if l != nil {
+		if log, ok := l.Error().WithEnabled(); ok {
+			log.WithField(slog.ErrorFieldName, err).
+				Printf("%s: %s", op, "failed")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package boltcache
+
+import (
+	"context"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Cache[string]     = (*Cache[string])(nil)
+	_ cache.Resizer           = (*Cache[string])(nil)
+	_ cache.Inspector[string] = (*Cache[string])(nil)
+)
+
+// Cache is a persistent [cache.Cache] stored in a [Bucket], using
+// [memcache.SingleFlight] to prevent stampedes. The [Bucket] is accessed
+// without holding the lock of the [memcache.SingleFlight], so bbolt
+// transactions of different keys don't wait for each other.
+type Cache[K comparable] struct {
+	*memcache.SingleFlight[K]
+
+	b      *Bucket[K]
+	getter cache.Getter[K]
+}
+
+// Get reads an entry from the [Bucket], otherwise reaches out to the
+// [cache.Getter], but only once per key at a time
+func (g *Cache[K]) Get(ctx context.Context, key K, dest cache.Sink) error {
+	if v, ex, ok := g.b.Get(key); ok {
+		cache.ObserveHit(ctx)
+		return dest.SetBytes(v, expireTime(ex))
+	}
+
+	return g.SingleFlight.Get(ctx, key, dest)
+}
+
+// load calls the [cache.Getter] on behalf of the [memcache.SingleFlight],
+// and stores the value on the [Bucket]
+func (g *Cache[K]) load(ctx context.Context, key K, dest cache.Sink) error {
+	// check again, someone may have stored it while we waited
+	if v, ex, ok := g.b.Peek(key); ok {
+		return dest.SetBytes(v, expireTime(ex))
+	}
+
+	if err := g.getter.Get(ctx, key, dest); err != nil {
+		return err
+	}
+
+	g.b.Add(key, dest.Bytes(), dest.Expire())
+	return nil
+}
+
+// Set writes an entry to the [Bucket], and shares it with anyone waiting for it
+func (g *Cache[K]) Set(ctx context.Context, key K, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	g.b.Add(key, value, expire)
+	return g.SingleFlight.Set(ctx, key, value, expire, cacheType)
+}
+
+// noStore is the inward store of the [memcache.SingleFlight] of a
+// [Cache], which only handles the [Bucket] outside its lock
+type noStore[K comparable] struct{}
+
+func (noStore[K]) Get(K) ([]byte, *time.Time, bool)        { return nil, nil, false }
+func (noStore[K]) Add(K, []byte, time.Time) (evicted bool) { return false }
+
+func expireTime(ex *time.Time) time.Time {
+	if ex != nil {
+		return *ex
+	}
+	return time.Time{}
+}
+
+// Bucket returns the [Bucket] storing the entries of the [Cache]
+func (g *Cache[K]) Bucket() *Bucket[K] {
+	return g.b
+}
+
+// Stats returns statistics about the Cache. This implementation doesn't
+// distinguish among types
+func (g *Cache[K]) Stats(_ cache.Type) cache.Stats {
+	return g.b.Stats()
+}
+
+// Remove evicts an entry from the [Cache]
+func (g *Cache[K]) Remove(_ context.Context, key K) {
+	g.b.Evict(key)
+}
+
+// Resize changes the maximum total size of the [Cache], evicting entries
+// as needed, and returns how many were evicted
+func (g *Cache[K]) Resize(_ context.Context, cacheBytes int64) (int, error) {
+	return g.b.Resize(cacheBytes), nil
+}
+
+// Purge removes all entries from the [Cache]
+func (g *Cache[K]) Purge(_ context.Context) error {
+	return g.b.Purge()
+}
+
+// Peek reads an entry into a [cache.Sink] without updating the statistics,
+// and without reaching out to the [cache.Getter] if missing.
+func (g *Cache[K]) Peek(_ context.Context, key K, dest cache.Sink) error {
+	v, ex, ok := g.b.Peek(key)
+	if !ok {
+		return cache.ErrNotFound
+	}
+	return dest.SetBytes(v, expireTime(ex))
+}
+
+// Contains tells if the [Cache] has an entry for the key
+func (g *Cache[K]) Contains(_ context.Context, key K) bool {
+	return g.b.Contains(key)
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was found.
+// A zero duration on a found entry means it never expires.
+func (g *Cache[K]) TTL(_ context.Context, key K) (time.Duration, bool) {
+	return g.b.TTL(key)
+}
+
+// SetLogger attaches a [slog.Logger] to the [Cache] and its [Bucket]
+func (g *Cache[K]) SetLogger(log slog.Logger) {
+	g.SingleFlight.SetLogger(log)
+	if log != nil {
+		log = log.WithField("cache", g.Name())
+	}
+	g.b.SetLogger(log)
+}
@@ -0,0 +1,119 @@
+package boltcache
+
+import (
+	"os"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+
+	"darvaza.org/core"
+)
+
+const compactTxMaxSize = 64 << 20
+
+var (
+	// ErrClosed indicates the database has been closed
+	ErrClosed = core.Wrap(core.ErrInvalid, "database closed")
+)
+
+// database wraps a bolt.DB so it can be replaced when compacted
+type database struct {
+	mu   sync.RWMutex
+	db   *bolt.DB
+	path string
+	opts *bolt.Options
+}
+
+func openDatabase(filename string, opts *bolt.Options) (*database, error) {
+	db, err := bolt.Open(filename, 0o600, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{
+		db:   db,
+		path: filename,
+		opts: opts,
+	}, nil
+}
+
+// View runs a read-only transaction
+func (d *database) View(fn func(*bolt.Tx) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return ErrClosed
+	}
+	return d.db.View(fn)
+}
+
+// Update runs a read-write transaction
+func (d *database) Update(fn func(*bolt.Tx) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return ErrClosed
+	}
+	return d.db.Update(fn)
+}
+
+// Close closes the database
+func (d *database) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.db == nil {
+		return ErrClosed
+	}
+
+	err := d.db.Close()
+	d.db = nil
+	return err
+}
+
+// Compact copies the content of the database into a new file, releasing
+// the space left by removed entries, and replaces the original with it.
+func (d *database) Compact() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.db == nil {
+		return ErrClosed
+	}
+
+	tmp := d.path + ".compact"
+	if err := d.compactTo(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := d.db.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	err := os.Rename(tmp, d.path)
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+
+	// and reopen, compacted or not
+	db, err2 := bolt.Open(d.path, 0o600, d.opts)
+	d.db = db
+	return core.CoalesceError(err, err2)
+}
+
+func (d *database) compactTo(filename string) error {
+	dst, err := bolt.Open(filename, 0o600, d.opts)
+	if err != nil {
+		return err
+	}
+
+	err = bolt.Compact(dst, d.db, compactTxMaxSize)
+	if err2 := dst.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,287 @@
+package boltcache
+
+import (
+	"encoding/binary"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"darvaza.org/core"
+)
+
+// Each namespace is a top-level bucket containing:
+//
+//	data   key -> expire(8) seq(8) value
+//	order  seq(8) -> key
+//	expiry expire(8) key -> nil
+//	meta   "bytes", "items" -> int64
+//
+// Integers are big-endian, expiration dates are Unix nanoseconds
+// or zero if they don't expire, and seq is the insertion order.
+var (
+	bucketData   = []byte("data")
+	bucketOrder  = []byte("order")
+	bucketExpiry = []byte("expiry")
+	bucketMeta   = []byte("meta")
+
+	metaBytes = []byte("bytes")
+	metaItems = []byte("items")
+)
+
+const recordHeaderSize = 16
+
+var (
+	// ErrBadRecord indicates a stored record is corrupted
+	ErrBadRecord = core.Wrap(core.ErrInvalid, "bad record")
+)
+
+type record struct {
+	expire time.Time
+	seq    uint64
+	value  []byte
+}
+
+func (r *record) encode() []byte {
+	b := make([]byte, recordHeaderSize+len(r.value))
+	binary.BigEndian.PutUint64(b, uint64(unixNano(r.expire)))
+	binary.BigEndian.PutUint64(b[8:], r.seq)
+	copy(b[recordHeaderSize:], r.value)
+	return b
+}
+
+// decode parses a stored record. The value is copied only if asked
+// as bolt's memory is only valid during the transaction.
+func (r *record) decode(b []byte, withValue bool) error {
+	if len(b) < recordHeaderSize {
+		return ErrBadRecord
+	}
+
+	r.expire = fromUnixNano(int64(binary.BigEndian.Uint64(b)))
+	r.seq = binary.BigEndian.Uint64(b[8:])
+	r.value = nil
+	if withValue {
+		r.value = append([]byte{}, b[recordHeaderSize:]...)
+	}
+	return nil
+}
+
+// Expired tells if the record has expired at the given time
+func (r *record) Expired(now time.Time) bool {
+	return !r.expire.IsZero() && !now.Before(r.expire)
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func seqKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
+
+func expiryKey(expire time.Time, key []byte) []byte {
+	b := binary.BigEndian.AppendUint64(nil, uint64(unixNano(expire)))
+	return append(b, key...)
+}
+
+// buckets are the sub-buckets of a namespace within a transaction
+type buckets struct {
+	data   *bolt.Bucket
+	order  *bolt.Bucket
+	expiry *bolt.Bucket
+	meta   *bolt.Bucket
+}
+
+// openBuckets returns the sub-buckets of a namespace, creating them
+// if needed on writable transactions, or false if they don't exist
+func openBuckets(tx *bolt.Tx, name []byte) (*buckets, bool, error) {
+	ns := tx.Bucket(name)
+	if ns == nil {
+		if !tx.Writable() {
+			return nil, false, nil
+		}
+
+		var err error
+		if ns, err = tx.CreateBucket(name); err != nil {
+			return nil, false, err
+		}
+	}
+
+	bs := &buckets{}
+	for _, sub := range []struct {
+		name []byte
+		p    **bolt.Bucket
+	}{
+		{bucketData, &bs.data},
+		{bucketOrder, &bs.order},
+		{bucketExpiry, &bs.expiry},
+		{bucketMeta, &bs.meta},
+	} {
+		b, err := subBucket(ns, sub.name)
+		if err != nil || b == nil {
+			return nil, false, err
+		}
+		*sub.p = b
+	}
+	return bs, true, nil
+}
+
+func subBucket(ns *bolt.Bucket, name []byte) (*bolt.Bucket, error) {
+	if b := ns.Bucket(name); b != nil || !ns.Writable() {
+		return b, nil
+	}
+	return ns.CreateBucket(name)
+}
+
+func (bs *buckets) counter(name []byte) int64 {
+	if v := bs.meta.Get(name); len(v) == 8 {
+		return int64(binary.BigEndian.Uint64(v))
+	}
+	return 0
+}
+
+func (bs *buckets) addCounters(bytes, items int64) error {
+	for _, c := range []struct {
+		name  []byte
+		delta int64
+	}{
+		{metaBytes, bytes},
+		{metaItems, items},
+	} {
+		v := bs.counter(c.name) + c.delta
+		if err := bs.meta.Put(c.name, binary.BigEndian.AppendUint64(nil, uint64(v))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get reads and decodes a record
+func (bs *buckets) get(key []byte, withValue bool) (*record, bool) {
+	b := bs.data.Get(key)
+	if b == nil {
+		return nil, false
+	}
+
+	r := new(record)
+	if err := r.decode(b, withValue); err != nil {
+		return nil, false
+	}
+	return r, true
+}
+
+// put stores a record, replacing any previous one
+func (bs *buckets) put(key, value []byte, expire time.Time) error {
+	if err := bs.remove(key); err != nil {
+		return err
+	}
+
+	seq, err := bs.data.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	r := record{expire: expire, seq: seq, value: value}
+	b := r.encode()
+
+	if err := bs.data.Put(key, b); err != nil {
+		return err
+	}
+	if err := bs.order.Put(seqKey(seq), key); err != nil {
+		return err
+	}
+	if !expire.IsZero() {
+		if err := bs.expiry.Put(expiryKey(expire, key), nil); err != nil {
+			return err
+		}
+	}
+	return bs.addCounters(int64(len(key)+len(b)), 1)
+}
+
+// remove deletes a record and its index entries, if present
+func (bs *buckets) remove(key []byte) error {
+	b := bs.data.Get(key)
+	if b == nil {
+		return nil
+	}
+
+	size := int64(len(key) + len(b))
+
+	var r record
+	if err := r.decode(b, false); err == nil {
+		_ = bs.order.Delete(seqKey(r.seq))
+		if !r.expire.IsZero() {
+			_ = bs.expiry.Delete(expiryKey(r.expire, key))
+		}
+	}
+
+	if err := bs.data.Delete(key); err != nil {
+		return err
+	}
+	return bs.addCounters(-size, -1)
+}
+
+// evictExpired removes all records expired at the given time,
+// using the expiry index, and returns how many were removed
+func (bs *buckets) evictExpired(now time.Time) (int, error) {
+	var keys [][]byte
+
+	limit := uint64(unixNano(now))
+	c := bs.expiry.Cursor()
+	for k, _ := c.First(); len(k) >= 8 && binary.BigEndian.Uint64(k) <= limit; k, _ = c.Next() {
+		keys = append(keys, append([]byte{}, k[8:]...))
+	}
+
+	return bs.removeAll(keys)
+}
+
+// evictOldest removes the oldest records until the namespace fits in
+// maxBytes, and returns how many were removed
+func (bs *buckets) evictOldest(maxBytes int64) (int, error) {
+	var keys [][]byte
+
+	excess := bs.counter(metaBytes) - maxBytes
+	c := bs.order.Cursor()
+	for k, v := c.First(); k != nil && excess > 0; k, v = c.Next() {
+		if b := bs.data.Get(v); b != nil {
+			excess -= int64(len(v) + len(b))
+		}
+		keys = append(keys, append([]byte{}, v...))
+	}
+
+	return bs.removeAll(keys)
+}
+
+func (bs *buckets) removeAll(keys [][]byte) (int, error) {
+	for i, key := range keys {
+		if err := bs.remove(key); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// prune evicts expired records first, and then the oldest,
+// until the namespace fits in maxBytes
+func (bs *buckets) prune(maxBytes int64) (int, error) {
+	if bs.counter(metaBytes) <= maxBytes {
+		return 0, nil
+	}
+
+	n, err := bs.evictExpired(time.Now())
+	if err != nil || bs.counter(metaBytes) <= maxBytes {
+		return n, err
+	}
+
+	m, err := bs.evictOldest(maxBytes)
+	return n + m, err
+}
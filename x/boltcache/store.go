@@ -0,0 +1,170 @@
+package boltcache
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Store[string]   = (*Store[string])(nil)
+	_ cache.Store[uint32]   = (*Store[uint32])(nil)
+	_ cache.Store[[32]byte] = (*Store[[32]byte])(nil)
+)
+
+// Store manages persistent [cache.Cache]s on a bbolt database file,
+// each on its own bucket
+type Store[K comparable] struct {
+	mu    sync.Mutex
+	db    *database
+	codec cache.KeyCodec[K]
+	log   slog.Logger
+	m     map[string]*Cache[K]
+}
+
+// Open opens or creates a bbolt database file for a [Store], using the
+// given [cache.KeyCodec] to encode the keys.
+func Open[K comparable](filename string, codec cache.KeyCodec[K], opts *Options) (*Store[K], error) {
+	if codec == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing key codec")
+	}
+
+	db, err := openDatabase(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store[K]{
+		db:    db,
+		codec: codec,
+		m:     make(map[string]*Cache[K]),
+	}
+	return s, nil
+}
+
+// Close closes the database. The [Cache]s of the [Store] will
+// miss and fail to store from then on.
+func (s *Store[K]) Close() error {
+	return s.db.Close()
+}
+
+// Compact rewrites the database file releasing the space left by
+// removed entries. The [Store] is blocked while compacting.
+func (s *Store[K]) Compact() error {
+	err := s.db.Compact()
+	if log, ok := s.withLogger(slog.Info); ok {
+		if err != nil {
+			log = log.WithField(slog.ErrorFieldName, err)
+		}
+		log.Print("compacted")
+	}
+	return err
+}
+
+// EvictExpired periodically removes expired entries from all [Cache]s
+// of the [Store]. It runs until the provided context is cancelled.
+func (s *Store[K]) EvictExpired(ctx context.Context, period time.Duration) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(period):
+			for _, g := range s.caches() {
+				g.b.EvictExpired()
+			}
+		}
+	}
+}
+
+// caches returns the [Cache]s of the [Store] sorted by name
+func (s *Store[K]) caches() []*Cache[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*Cache[K], 0, len(s.m))
+	for _, name := range slices.Sorted(maps.Keys(s.m)) {
+		out = append(out, s.m[name])
+	}
+	return out
+}
+
+// DeregisterCache disconnects a [Cache] from the [Store]. Its entries
+// are kept for the next time it's created.
+func (s *Store[K]) DeregisterCache(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.m, name)
+}
+
+// GetCache finds a [Cache] by name in the [Store]
+func (s *Store[K]) GetCache(name string) cache.Cache[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.m[name]
+	if ok {
+		return g
+	}
+	return nil
+}
+
+// NewCache creates a new persistent [Cache] attached to the [Store],
+// keeping the entries stored by a previous run
+func (s *Store[K]) NewCache(name string, cacheBytes int64, getter cache.Getter[K]) cache.Cache[K] {
+	if name == "" || getter == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.m[name]; ok {
+		core.Panicf("%s: %s", name, "cache already registered")
+	}
+
+	b, err := newBucket(s.db, name, cacheBytes, s.codec)
+	if err != nil {
+		if log, ok := s.withLogger(slog.Error); ok {
+			log.WithField(slog.ErrorFieldName, err).
+				Printf("cache:%q failed", name)
+		}
+		return nil
+	}
+
+	g := &Cache[K]{
+		b:      b,
+		getter: getter,
+	}
+	g.SingleFlight = memcache.NewSingleFlight[K](name, noStore[K]{},
+		cache.GetterFunc[K](g.load))
+	g.SetLogger(s.log)
+	s.m[name] = g
+
+	if log, ok := s.withLogger(slog.Debug); ok {
+		log.Printf("cache:%q created", name)
+	}
+	return g
+}
+
+// SetLogger attaches a [slog.Logger] to the store and any new Cache created through it
+func (s *Store[K]) SetLogger(log slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = log
+}
+
+func (s *Store[K]) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	if s.log != nil {
+		return s.log.WithLevel(level).WithEnabled()
+	}
+	return nil, false
+}
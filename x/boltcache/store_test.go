@@ -0,0 +1,232 @@
+package boltcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+// entrySize is the size accounted for an entry with a two bytes key
+// and a ten bytes value
+const entrySize = 2 + recordHeaderSize + 10
+
+func TestStore(t *testing.T) {
+	t.Run("get and set", runTestStoreGetSet)
+	t.Run("expiry", runTestStoreExpiry)
+	t.Run("eviction order", runTestStoreEvictionOrder)
+	t.Run("expired evicted first", runTestStoreEvictExpiredFirst)
+	t.Run("reopen", runTestStoreReopen)
+	t.Run("compact", runTestStoreCompact)
+}
+
+// countingGetter returns "value-" followed by the key, and
+// counts how many times it was called
+type countingGetter struct {
+	calls int
+}
+
+func (g *countingGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.calls++
+	return dest.SetBytes([]byte("value-"+key), time.Time{})
+}
+
+func openTestStore(t *testing.T, filename string) *Store[string] {
+	t.Helper()
+
+	s, err := Open[string](filename, cache.StringKeys{}, nil)
+	core.AssertMustNoError(t, err, "Open")
+	return s
+}
+
+func newTestCache(t *testing.T, s *Store[string], cacheBytes int64, getter cache.Getter[string]) *Cache[string] {
+	t.Helper()
+
+	g := s.NewCache("test", cacheBytes, getter)
+	core.AssertMustNotNil(t, g, "NewCache")
+	return core.AssertMustTypeIs[*Cache[string]](t, g, "NewCache")
+}
+
+func getString(t *testing.T, g cache.Getter[string], key string) (string, error) {
+	t.Helper()
+
+	var sink cache.ByteSink
+	err := g.Get(context.Background(), key, &sink)
+	return string(sink.Bytes()), err
+}
+
+func runTestStoreGetSet(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer s.Close()
+
+	getter := new(countingGetter)
+	g := newTestCache(t, s, 1024, getter)
+
+	v, err := getString(t, g, "k1")
+	core.AssertNoError(t, err, "first Get")
+	core.AssertEqual(t, "value-k1", v, "first Get")
+
+	v, err = getString(t, g, "k1")
+	core.AssertNoError(t, err, "second Get")
+	core.AssertEqual(t, "value-k1", v, "second Get")
+	core.AssertEqual(t, 1, getter.calls, "getter calls")
+
+	err = g.Set(context.Background(), "k2", []byte("stored"), time.Time{}, cache.MainCache)
+	core.AssertNoError(t, err, "Set")
+
+	v, err = getString(t, g, "k2")
+	core.AssertNoError(t, err, "Get after Set")
+	core.AssertEqual(t, "stored", v, "Get after Set")
+	core.AssertEqual(t, 1, getter.calls, "getter calls")
+
+	g.Remove(context.Background(), "k2")
+	core.AssertFalse(t, g.Contains(context.Background(), "k2"), "Contains after Remove")
+}
+
+func runTestStoreExpiry(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer s.Close()
+
+	g := newTestCache(t, s, 1024, new(countingGetter))
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, g.Set(ctx, "k1", []byte("short"),
+		time.Now().Add(50*time.Millisecond), cache.MainCache), "Set k1")
+	core.AssertMustNoError(t, g.Set(ctx, "k2", []byte("forever"),
+		time.Time{}, cache.MainCache), "Set k2")
+
+	ttl, ok := g.TTL(ctx, "k1")
+	core.AssertTrue(t, ok, "TTL k1 found")
+	core.AssertTrue(t, ttl > 0 && ttl <= 50*time.Millisecond, "TTL k1 %v", ttl)
+
+	ttl, ok = g.TTL(ctx, "k2")
+	core.AssertTrue(t, ok, "TTL k2 found")
+	core.AssertEqual(t, time.Duration(0), ttl, "TTL k2")
+
+	time.Sleep(100 * time.Millisecond)
+
+	var sink cache.ByteSink
+	core.AssertErrorIs(t, g.Peek(ctx, "k1", &sink), cache.ErrNotFound, "Peek expired")
+	core.AssertTrue(t, g.Contains(ctx, "k2"), "Contains k2")
+
+	core.AssertEqual(t, 1, g.Bucket().EvictExpired(), "EvictExpired")
+	core.AssertEqual(t, int64(1), g.Stats(cache.MainCache).Items, "items")
+}
+
+func runTestStoreEvictionOrder(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer s.Close()
+
+	g := newTestCache(t, s, 2*entrySize, new(countingGetter))
+	ctx := context.Background()
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		core.AssertMustNoError(t, g.Set(ctx, key, []byte("0123456789"),
+			time.Time{}, cache.MainCache), "Set %s", key)
+	}
+
+	core.AssertFalse(t, g.Contains(ctx, "k1"), "oldest evicted")
+	core.AssertTrue(t, g.Contains(ctx, "k2"), "k2 kept")
+	core.AssertTrue(t, g.Contains(ctx, "k3"), "k3 kept")
+
+	stats := g.Stats(cache.MainCache)
+	core.AssertEqual(t, int64(2*entrySize), stats.Bytes, "bytes")
+	core.AssertEqual(t, int64(1), stats.Evictions, "evictions")
+
+	n, err := g.Resize(ctx, entrySize)
+	core.AssertNoError(t, err, "Resize")
+	core.AssertEqual(t, 1, n, "Resize evictions")
+	core.AssertTrue(t, g.Contains(ctx, "k3"), "newest kept")
+}
+
+func runTestStoreEvictExpiredFirst(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer s.Close()
+
+	g := newTestCache(t, s, 2*entrySize, new(countingGetter))
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, g.Set(ctx, "k1", []byte("0123456789"),
+		time.Time{}, cache.MainCache), "Set k1")
+	core.AssertMustNoError(t, g.Set(ctx, "k2", []byte("0123456789"),
+		time.Now().Add(10*time.Millisecond), cache.MainCache), "Set k2")
+
+	time.Sleep(20 * time.Millisecond)
+	core.AssertMustNoError(t, g.Set(ctx, "k3", []byte("0123456789"),
+		time.Time{}, cache.MainCache), "Set k3")
+
+	core.AssertTrue(t, g.Contains(ctx, "k1"), "oldest kept")
+	core.AssertTrue(t, g.Contains(ctx, "k3"), "newest kept")
+	core.AssertEqual(t, int64(2), g.Stats(cache.MainCache).Items, "items")
+}
+
+func runTestStoreReopen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.db")
+	ctx := context.Background()
+
+	s := openTestStore(t, filename)
+	g := newTestCache(t, s, 1024, new(countingGetter))
+	core.AssertMustNoError(t, g.Set(ctx, "k1", []byte("persisted"),
+		time.Time{}, cache.MainCache), "Set")
+	core.AssertMustNoError(t, s.Close(), "Close")
+
+	// closed stores miss
+	core.AssertFalse(t, g.Contains(ctx, "k1"), "Contains after Close")
+
+	s = openTestStore(t, filename)
+	defer s.Close()
+
+	getter := new(countingGetter)
+	g = newTestCache(t, s, 1024, getter)
+
+	v, err := getString(t, g, "k1")
+	core.AssertNoError(t, err, "Get after reopen")
+	core.AssertEqual(t, "persisted", v, "Get after reopen")
+	core.AssertEqual(t, 0, getter.calls, "getter calls")
+	core.AssertEqual(t, int64(1), g.Stats(cache.MainCache).Items, "items")
+}
+
+func runTestStoreCompact(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.db")
+	ctx := context.Background()
+
+	s := openTestStore(t, filename)
+	defer s.Close()
+
+	g := newTestCache(t, s, 1<<24, new(countingGetter))
+	value := []byte(strings.Repeat("x", 4096))
+	for i := range 256 {
+		key := cache.MustJoinKey("k", i)
+		core.AssertMustNoError(t, g.Set(ctx, key, value,
+			time.Time{}, cache.MainCache), "Set %s", key)
+	}
+	core.AssertMustNoError(t, g.Set(ctx, "kept", []byte("kept"),
+		time.Time{}, cache.MainCache), "Set kept")
+
+	for i := range 256 {
+		g.Remove(ctx, cache.MustJoinKey("k", i))
+	}
+
+	before := fileSize(t, filename)
+	core.AssertMustNoError(t, s.Compact(), "Compact")
+	after := fileSize(t, filename)
+	core.AssertTrue(t, after < before, "file shrunk from %d to %d", before, after)
+
+	v, err := getString(t, g, "kept")
+	core.AssertNoError(t, err, "Get after Compact")
+	core.AssertEqual(t, "kept", v, "Get after Compact")
+	core.AssertEqual(t, int64(1), g.Stats(cache.MainCache).Items, "items")
+}
+
+func fileSize(t *testing.T, filename string) int64 {
+	t.Helper()
+
+	fi, err := os.Stat(filename)
+	core.AssertMustNoError(t, err, "Stat")
+	return fi.Size()
+}
@@ -0,0 +1,10 @@
+package boltcache
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+type (
+	// Options alias for the Open wrapper
+	Options = bolt.Options
+)
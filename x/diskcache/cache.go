@@ -0,0 +1,157 @@
+package diskcache
+
+import (
+	"context"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Cache[string]     = (*Cache)(nil)
+	_ cache.Resizer           = (*Cache)(nil)
+	_ cache.Inspector[string] = (*Cache)(nil)
+)
+
+// Cache is a persistent [cache.Cache] stored in a [Dir], using
+// [memcache.SingleFlight] to prevent stampedes. The [Dir] is accessed
+// without holding the lock of the [memcache.SingleFlight], so reads and
+// writes of different keys don't wait for each other.
+type Cache struct {
+	*memcache.SingleFlight[string]
+
+	dir    *Dir
+	getter cache.Getter[string]
+}
+
+// NewCache opens a [Cache] on a directory with a maximum total size
+// and a [cache.Getter]
+func NewCache(name, path string, cacheBytes int64, getter cache.Getter[string]) (*Cache, error) {
+	if getter == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing getter")
+	}
+
+	dir, err := OpenDir(path, cacheBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Cache{
+		dir:    dir,
+		getter: getter,
+	}
+	g.SingleFlight = memcache.NewSingleFlight[string](name, noStore{},
+		cache.GetterFunc[string](g.load))
+	return g, nil
+}
+
+// Get reads an entry from the [Dir], otherwise reaches out to the
+// [cache.Getter], but only once per key at a time
+func (g *Cache) Get(ctx context.Context, key string, dest cache.Sink) error {
+	if v, ex, ok := g.dir.Get(key); ok {
+		cache.ObserveHit(ctx)
+		return dest.SetBytes(v, expireTime(ex))
+	}
+
+	return g.SingleFlight.Get(ctx, key, dest)
+}
+
+// load calls the [cache.Getter] on behalf of the [memcache.SingleFlight],
+// and stores the value on the [Dir]
+func (g *Cache) load(ctx context.Context, key string, dest cache.Sink) error {
+	// check again, someone may have stored it while we waited
+	if v, ex, ok := g.dir.Peek(key); ok {
+		return dest.SetBytes(v, expireTime(ex))
+	}
+
+	if err := g.getter.Get(ctx, key, dest); err != nil {
+		return err
+	}
+
+	g.dir.Add(key, dest.Bytes(), dest.Expire())
+	return nil
+}
+
+// Set writes an entry to the [Dir], and shares it with anyone waiting for it
+func (g *Cache) Set(ctx context.Context, key string, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	g.dir.Add(key, value, expire)
+	return g.SingleFlight.Set(ctx, key, value, expire, cacheType)
+}
+
+// noStore is the inward store of the [memcache.SingleFlight] of a
+// [Cache], which only handles the [Dir] outside its lock
+type noStore struct{}
+
+func (noStore) Get(string) ([]byte, *time.Time, bool)        { return nil, nil, false }
+func (noStore) Add(string, []byte, time.Time) (evicted bool) { return false }
+
+func expireTime(ex *time.Time) time.Time {
+	if ex != nil {
+		return *ex
+	}
+	return time.Time{}
+}
+
+// Dir returns the [Dir] storing the entries of the [Cache]
+func (g *Cache) Dir() *Dir {
+	return g.dir
+}
+
+// Stats returns statistics about the Cache. This implementation doesn't
+// distinguish among types
+func (g *Cache) Stats(_ cache.Type) cache.Stats {
+	return g.dir.Stats()
+}
+
+// Remove evicts an entry from the [Cache]
+func (g *Cache) Remove(_ context.Context, key string) {
+	g.dir.Evict(key)
+}
+
+// Resize changes the maximum total size of the [Cache], evicting entries
+// as needed, and returns how many were evicted
+func (g *Cache) Resize(_ context.Context, cacheBytes int64) (int, error) {
+	return g.dir.Resize(cacheBytes), nil
+}
+
+// Purge removes all entries from the [Cache]
+func (g *Cache) Purge(_ context.Context) error {
+	g.dir.Purge()
+	return nil
+}
+
+// Peek reads an entry into a [cache.Sink] without updating its recency
+// nor the statistics, and without reaching out to the [cache.Getter]
+// if missing.
+func (g *Cache) Peek(_ context.Context, key string, dest cache.Sink) error {
+	v, ex, ok := g.dir.Peek(key)
+	if !ok {
+		return cache.ErrNotFound
+	}
+	return dest.SetBytes(v, expireTime(ex))
+}
+
+// Contains tells if the [Cache] has an entry for the key
+func (g *Cache) Contains(_ context.Context, key string) bool {
+	return g.dir.Contains(key)
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was found.
+// A zero duration on a found entry means it never expires.
+func (g *Cache) TTL(_ context.Context, key string) (time.Duration, bool) {
+	return g.dir.TTL(key)
+}
+
+// SetLogger attaches a [slog.Logger] to the [Cache] and its [Dir]
+func (g *Cache) SetLogger(log slog.Logger) {
+	g.SingleFlight.SetLogger(log)
+	if log != nil {
+		log = log.WithField("cache", g.Name())
+	}
+	g.dir.SetLogger(log)
+}
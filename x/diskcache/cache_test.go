@@ -0,0 +1,86 @@
+package diskcache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("get and set", runTestCacheGetSet)
+	t.Run("concurrent get", runTestCacheConcurrentGet)
+}
+
+// countingGetter returns "value-" followed by the key after an
+// optional delay, and counts how many times it was called
+type countingGetter struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (g *countingGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.calls.Add(1)
+	time.Sleep(g.delay)
+	return dest.SetBytes([]byte("value-"+key), time.Time{})
+}
+
+func getString(t *testing.T, g cache.Getter[string], key string) (string, error) {
+	t.Helper()
+
+	var sink cache.ByteSink
+	err := g.Get(context.Background(), key, &sink)
+	return string(sink.Bytes()), err
+}
+
+func runTestCacheGetSet(t *testing.T) {
+	getter := new(countingGetter)
+	g, err := NewCache("test", t.TempDir(), 1<<20, getter)
+	core.AssertMustNoError(t, err, "NewCache")
+
+	for range 2 {
+		v, err := getString(t, g, "k1")
+		core.AssertNoError(t, err, "Get k1")
+		core.AssertEqual(t, "value-k1", v, "Get k1")
+	}
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+
+	err = g.Set(context.Background(), "k2", []byte("stored"), time.Time{}, cache.MainCache)
+	core.AssertNoError(t, err, "Set")
+
+	v, err := getString(t, g, "k2")
+	core.AssertNoError(t, err, "Get k2")
+	core.AssertEqual(t, "stored", v, "Get k2")
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+
+	// persisted
+	v2, ex, ok := g.Dir().Peek("k1")
+	core.AssertTrue(t, ok, "Peek k1")
+	core.AssertEqual(t, "value-k1", string(v2), "Peek k1")
+	core.AssertNil(t, ex, "Peek k1 expire")
+}
+
+func runTestCacheConcurrentGet(t *testing.T) {
+	getter := &countingGetter{delay: 50 * time.Millisecond}
+	g, err := NewCache("test", t.TempDir(), 1<<20, getter)
+	core.AssertMustNoError(t, err, "NewCache")
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err := getString(t, g, "k1")
+			core.AssertNoError(t, err, "Get")
+			core.AssertEqual(t, "value-k1", v, "Get")
+		}()
+	}
+	wg.Wait()
+
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+}
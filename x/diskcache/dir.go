@@ -0,0 +1,345 @@
+package diskcache
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/cache/x/simplelru"
+	"darvaza.org/slog"
+)
+
+var (
+	_ memcache.AdderGetter[string] = (*Dir)(nil)
+)
+
+// Dir is a least-recently-used cache of bytes with TTL and a maximum
+// total size, storing each entry as a file named after the SHA-256
+// of its key. Writes are atomic, and the entries left by a previous
+// run are recovered when opened.
+type Dir struct {
+	mu    sync.Mutex
+	path  string
+	lru   *simplelru.LRU[string, *fileEntry]
+	log   slog.Logger
+	stats cache.Stats
+}
+
+// fileEntry is the value kept on the LRU for each entry. A new one
+// is created on every write, so they tell apart writes of the same key.
+type fileEntry struct {
+	name string
+}
+
+// OpenDir opens or creates a [Dir] with a maximum total size in bytes,
+// recovering the entries left by a previous run from the least to the
+// most recently accessed.
+func OpenDir(path string, cacheBytes int64) (*Dir, error) {
+	if err := os.MkdirAll(path, 0o750); err != nil {
+		return nil, err
+	}
+
+	d := &Dir{
+		path: path,
+		lru:  simplelru.NewLRU[string, *fileEntry](int(cacheBytes), nil, nil),
+	}
+	d.lru.SetEvictReasonCallback(d.evictionCallback)
+
+	if err := d.recover(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Dir) evictionCallback(key string, fe *fileEntry, size int, reason simplelru.EvictReason) {
+	d.stats.Evictions++
+
+	err := os.Remove(filepath.Join(d.path, fe.name))
+	if err != nil && !os.IsNotExist(err) {
+		if log, ok := d.withLogger(slog.Warn); ok {
+			log.WithField("key", key).
+				WithField(slog.ErrorFieldName, err).
+				Print("failed to remove")
+		}
+	} else if log, ok := d.withLogger(slog.Debug); ok {
+		log.WithField("key", key).
+			WithField("size", size).
+			Print(reason)
+	}
+}
+
+type recoveredEntry struct {
+	key    string
+	name   string
+	size   int64
+	expire time.Time
+	atime  time.Time
+}
+
+// recover scans the directory for entry files left by a previous run,
+// removing temporary, corrupted and expired ones.
+func (d *Dir) recover() error {
+	var found []recoveredEntry
+
+	err := filepath.WalkDir(d.path, func(filename string, de fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case de.IsDir():
+			return nil
+		case isTempFile(filename):
+			// interrupted write
+			_ = os.Remove(filename)
+		case isEntryFile(filename):
+			if e, ok := d.recoverFile(filename, de); ok {
+				found = append(found, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(found, func(a, b recoveredEntry) int {
+		return a.atime.Compare(b.atime)
+	})
+
+	for _, e := range found {
+		d.lru.Add(e.key, &fileEntry{name: e.name}, int(e.size), e.expire)
+	}
+
+	// recovery doesn't count
+	d.stats = cache.Stats{}
+	return nil
+}
+
+func (*Dir) recoverFile(filename string, de fs.DirEntry) (recoveredEntry, bool) {
+	fi, err := de.Info()
+	if err != nil {
+		return recoveredEntry{}, false
+	}
+
+	key, h, err := readFileHeader(filename)
+	if err != nil || (!h.expire.IsZero() && time.Now().After(h.expire)) {
+		// corrupted or expired
+		_ = os.Remove(filename)
+		return recoveredEntry{}, false
+	}
+
+	return recoveredEntry{
+		key:    key,
+		name:   relName(filename),
+		size:   h.Size(),
+		expire: h.expire,
+		atime:  fi.ModTime(),
+	}, true
+}
+
+// Get attempts to find an entry in the [Dir], and returns its value,
+// expiration date if any, and if it was found or not
+func (d *Dir) Get(key string) ([]byte, *time.Time, bool) {
+	fe, ex, ok := d.lookup(key, true)
+	if !ok {
+		d.count(false)
+		return nil, nil, false
+	}
+
+	filename := filepath.Join(d.path, fe.name)
+	value, err := readFile(filename, key)
+	if err != nil {
+		d.drop(key, fe, err)
+		d.count(false)
+		return nil, nil, false
+	}
+	d.count(true)
+
+	// remember the access across restarts
+	now := time.Now()
+	_ = os.Chtimes(filename, now, now)
+
+	if ex.IsZero() {
+		return value, nil, true
+	}
+	return value, &ex, true
+}
+
+// Peek is like Get but it doesn't update the recency of the entry
+// nor the statistics
+func (d *Dir) Peek(key string) ([]byte, *time.Time, bool) {
+	fe, ex, ok := d.lookup(key, false)
+	if !ok {
+		return nil, nil, false
+	}
+
+	value, err := readFile(filepath.Join(d.path, fe.name), key)
+	if err != nil {
+		d.drop(key, fe, err)
+		return nil, nil, false
+	}
+
+	if ex.IsZero() {
+		return value, nil, true
+	}
+	return value, &ex, true
+}
+
+// revive:disable:flag-parameter
+
+func (d *Dir) lookup(key string, promote bool) (*fileEntry, time.Time, bool) {
+	// revive:enable:flag-parameter
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !promote {
+		return d.lru.Peek(key)
+	}
+	return d.lru.Get(key)
+}
+
+// count updates the statistics after a Get
+func (d *Dir) count(hit bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stats.Gets++
+	if hit {
+		d.stats.Hits++
+	}
+}
+
+// drop forgets an entry which file was lost or corrupted, unless
+// it was written again meanwhile
+func (d *Dir) drop(key string, fe *fileEntry, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if cur, _, ok := d.lru.Peek(key); !ok || cur != fe {
+		return
+	}
+	d.lru.Evict(key)
+
+	if log, ok := d.withLogger(slog.Warn); ok {
+		log.WithField("key", key).
+			WithField(slog.ErrorFieldName, err).
+			Print("failed to read")
+	}
+}
+
+// Add writes an entry and its expiration date, and returns true if entries
+// were removed to free capacity. If expire is zero, it never expires.
+func (d *Dir) Add(key string, value []byte, expire time.Time) bool {
+	name := fileName(key)
+	filename := filepath.Join(d.path, name)
+
+	tmp, size, err := writeTempFile(filepath.Dir(filename), key, value, expire)
+	if err == nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		err = os.Rename(tmp, filename)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+		if log, ok := d.withLogger(slog.Error); ok {
+			log.WithField("key", key).
+				WithField(slog.ErrorFieldName, err).
+				Print("failed to write")
+		}
+		return false
+	}
+
+	return d.lru.Add(key, &fileEntry{name: name}, int(size), expire)
+}
+
+// Evict removes an entry if present
+func (d *Dir) Evict(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lru.Evict(key)
+}
+
+// Purge removes all entries from the [Dir]
+func (d *Dir) Purge() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lru.Purge()
+}
+
+// Resize changes the maximum total size of the [Dir], evicting expired
+// entries first and then the least recently used if needed, and returns
+// the number of evicted entries.
+func (d *Dir) Resize(cacheBytes int64) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.lru.Resize(int(cacheBytes))
+}
+
+// Contains tells if a non-expired entry exists for the key, without
+// updating its recency nor the statistics
+func (d *Dir) Contains(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.lru.Contains(key)
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was
+// found. A zero duration on a found entry means it never expires.
+func (d *Dir) TTL(key string) (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.lru.TTL(key)
+}
+
+// Stats returns statistics about the [Dir]
+func (d *Dir) Stats() cache.Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.stats
+	stats.Bytes = int64(d.lru.Size())
+	stats.Items = int64(d.lru.Len())
+	return stats
+}
+
+// EvictExpired periodically scans for expired entries and evicts them.
+// It runs until the provided context is cancelled.
+func (d *Dir) EvictExpired(ctx context.Context, period time.Duration) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(period):
+			d.mu.Lock()
+			d.lru.EvictExpired()
+			d.mu.Unlock()
+		}
+	}
+}
+
+// SetLogger attaches a [slog.Logger] to the [Dir]
+func (d *Dir) SetLogger(log slog.Logger) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.log = log
+}
+
+func (d *Dir) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	if d.log != nil {
+		return d.log.WithLevel(level).WithEnabled()
+	}
+	return nil, false
+}
@@ -0,0 +1,176 @@
+package diskcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// entrySize is the size accounted for an entry with a two bytes key
+// and a ten bytes value
+const entrySize = headerSize + 2 + 10
+
+func TestDir(t *testing.T) {
+	t.Run("round trip", runTestDirRoundTrip)
+	t.Run("reopen", runTestDirReopen)
+	t.Run("recover cleanup", runTestDirRecoverCleanup)
+	t.Run("eviction", runTestDirEviction)
+	t.Run("bad file", runTestDirBadFile)
+	t.Run("drop rewritten", runTestDirDropRewritten)
+}
+
+func openTestDir(t *testing.T, path string, cacheBytes int64) *Dir {
+	t.Helper()
+
+	d, err := OpenDir(path, cacheBytes)
+	core.AssertMustNoError(t, err, "OpenDir")
+	return d
+}
+
+func entryPath(d *Dir, key string) string {
+	return filepath.Join(d.path, fileName(key))
+}
+
+func runTestDirRoundTrip(t *testing.T) {
+	d := openTestDir(t, t.TempDir(), 10*entrySize)
+	expire := time.Now().Add(time.Hour).Round(0)
+
+	core.AssertFalse(t, d.Add("k1", []byte("0123456789"), time.Time{}), "Add k1")
+	core.AssertFalse(t, d.Add("k2", []byte("abcdefghij"), expire), "Add k2")
+
+	v, ex, ok := d.Get("k1")
+	core.AssertMustTrue(t, ok, "Get k1")
+	core.AssertEqual(t, "0123456789", string(v), "Get k1")
+	core.AssertNil(t, ex, "Get k1 expire")
+
+	v, ex, ok = d.Get("k2")
+	core.AssertMustTrue(t, ok, "Get k2")
+	core.AssertEqual(t, "abcdefghij", string(v), "Get k2")
+	core.AssertMustNotNil(t, ex, "Get k2 expire")
+	core.AssertTrue(t, expire.Equal(*ex), "Get k2 expire")
+
+	_, _, ok = d.Get("k3")
+	core.AssertFalse(t, ok, "Get k3")
+
+	stats := d.Stats()
+	core.AssertEqual(t, int64(3), stats.Gets, "Gets")
+	core.AssertEqual(t, int64(2), stats.Hits, "Hits")
+	core.AssertEqual(t, int64(2), stats.Items, "Items")
+	core.AssertEqual(t, int64(2*entrySize), stats.Bytes, "Bytes")
+}
+
+func runTestDirReopen(t *testing.T) {
+	path := t.TempDir()
+	d := openTestDir(t, path, 3*entrySize)
+	for _, k := range []string{"k1", "k2", "k3"} {
+		d.Add(k, []byte("0123456789"), time.Time{})
+	}
+
+	// k2 is the least recently accessed, then k3 and k1
+	now := time.Now()
+	for i, k := range []string{"k2", "k3", "k1"} {
+		mtime := now.Add(time.Duration(i-3) * time.Minute)
+		core.AssertMustNoError(t, os.Chtimes(entryPath(d, k), mtime, mtime), "Chtimes %q", k)
+	}
+
+	d = openTestDir(t, path, 3*entrySize)
+	stats := d.Stats()
+	core.AssertEqual(t, int64(3), stats.Items, "recovered Items")
+	core.AssertEqual(t, int64(0), stats.Gets, "recovered Gets")
+	core.AssertEqual(t, int64(0), stats.Evictions, "recovered Evictions")
+
+	v, _, ok := d.Peek("k1")
+	core.AssertTrue(t, ok, "Peek k1")
+	core.AssertEqual(t, "0123456789", string(v), "Peek k1")
+
+	// k2 goes first, then k3
+	core.AssertTrue(t, d.Add("k4", []byte("0123456789"), time.Time{}), "Add k4")
+	core.AssertFalse(t, d.Contains("k2"), "k2 evicted")
+	core.AssertTrue(t, d.Contains("k3"), "k3 kept")
+
+	core.AssertTrue(t, d.Add("k5", []byte("0123456789"), time.Time{}), "Add k5")
+	core.AssertFalse(t, d.Contains("k3"), "k3 evicted")
+	core.AssertTrue(t, d.Contains("k1"), "k1 kept")
+}
+
+func runTestDirRecoverCleanup(t *testing.T) {
+	path := t.TempDir()
+	d := openTestDir(t, path, 10*entrySize)
+	d.Add("ok", []byte("0123456789"), time.Time{})
+	d.Add("expired", []byte("0123456789"), time.Now().Add(-time.Second))
+	d.Add("corrupt", []byte("0123456789"), time.Time{})
+	d.Add("truncated", []byte("0123456789"), time.Time{})
+
+	corrupt := entryPath(d, "corrupt")
+	core.AssertMustNoError(t, os.WriteFile(corrupt, []byte("garbage"), 0o600), "corrupt")
+	truncated := entryPath(d, "truncated")
+	core.AssertMustNoError(t, os.Truncate(truncated, headerSize+2), "truncate")
+	temp := filepath.Join(filepath.Dir(entryPath(d, "ok")), tempPrefix+"123")
+	core.AssertMustNoError(t, os.WriteFile(temp, []byte("partial"), 0o600), "temp")
+	expired := entryPath(d, "expired")
+
+	d = openTestDir(t, path, 10*entrySize)
+	core.AssertEqual(t, int64(1), d.Stats().Items, "recovered Items")
+	core.AssertTrue(t, d.Contains("ok"), "ok recovered")
+
+	for _, filename := range []string{expired, corrupt, truncated, temp} {
+		_, err := os.Stat(filename)
+		core.AssertTrue(t, os.IsNotExist(err), "%s removed", filepath.Base(filename))
+	}
+}
+
+func runTestDirEviction(t *testing.T) {
+	d := openTestDir(t, t.TempDir(), 2*entrySize)
+
+	core.AssertFalse(t, d.Add("k1", []byte("0123456789"), time.Time{}), "Add k1")
+	core.AssertFalse(t, d.Add("k2", []byte("0123456789"), time.Time{}), "Add k2")
+	_, _, ok := d.Get("k1")
+	core.AssertTrue(t, ok, "Get k1")
+
+	core.AssertTrue(t, d.Add("k3", []byte("0123456789"), time.Time{}), "Add k3")
+	core.AssertFalse(t, d.Contains("k2"), "k2 evicted")
+	core.AssertTrue(t, d.Contains("k1"), "k1 kept")
+
+	_, err := os.Stat(entryPath(d, "k2"))
+	core.AssertTrue(t, os.IsNotExist(err), "k2 file removed")
+
+	stats := d.Stats()
+	core.AssertEqual(t, int64(1), stats.Evictions, "Evictions")
+	core.AssertEqual(t, int64(2*entrySize), stats.Bytes, "Bytes")
+}
+
+func runTestDirBadFile(t *testing.T) {
+	d := openTestDir(t, t.TempDir(), 10*entrySize)
+	d.Add("k1", []byte("0123456789"), time.Time{})
+
+	b, err := os.ReadFile(entryPath(d, "k1"))
+	core.AssertMustNoError(t, err, "ReadFile")
+	b[len(b)-1] ^= 0xff
+	core.AssertMustNoError(t, os.WriteFile(entryPath(d, "k1"), b, 0o600), "WriteFile")
+
+	_, _, ok := d.Get("k1")
+	core.AssertFalse(t, ok, "Get corrupted")
+	core.AssertFalse(t, d.Contains("k1"), "corrupted dropped")
+
+	stats := d.Stats()
+	core.AssertEqual(t, int64(1), stats.Gets, "Gets")
+	core.AssertEqual(t, int64(0), stats.Hits, "Hits")
+}
+
+func runTestDirDropRewritten(t *testing.T) {
+	d := openTestDir(t, t.TempDir(), 10*entrySize)
+	d.Add("k1", []byte("0123456789"), time.Time{})
+
+	// a read of the old entry failed while another write took place
+	fe, _, ok := d.lookup("k1", false)
+	core.AssertMustTrue(t, ok, "lookup")
+	d.Add("k1", []byte("abcdefghij"), time.Time{})
+	d.drop("k1", fe, ErrBadEntry)
+
+	v, _, ok := d.Get("k1")
+	core.AssertTrue(t, ok, "rewritten kept")
+	core.AssertEqual(t, "abcdefghij", string(v), "rewritten kept")
+}
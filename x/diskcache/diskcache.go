@@ -0,0 +1,3 @@
+// Package diskcache provides a persistent cache.Cache backed
+// by files in a directory
+package diskcache
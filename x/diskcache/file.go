@@ -0,0 +1,232 @@
+package diskcache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// Entry file format, version 1:
+//
+//	magic    "DVZD"
+//	version  byte
+//	expire   int64, Unix nanoseconds or zero if it doesn't expire
+//	keyLen   uint32
+//	valueLen uint64
+//	checksum CRC-32C of key and value
+//	key
+//	value
+//
+// All integers are big-endian.
+const (
+	fileMagic   = "DVZD"
+	fileVersion = 1
+
+	headerSize = 4 + 1 + 8 + 4 + 8 + 4
+
+	tempPrefix = ".tmp-"
+)
+
+var (
+	// ErrBadEntry indicates an entry file is corrupted or uses
+	// an unsupported format
+	ErrBadEntry = core.Wrap(core.ErrInvalid, "bad entry file")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type fileHeader struct {
+	expire   time.Time
+	keyLen   uint32
+	valueLen uint64
+	checksum uint32
+}
+
+// Size returns the total size of the entry file
+func (h *fileHeader) Size() int64 {
+	return headerSize + int64(h.keyLen) + int64(h.valueLen)
+}
+
+func (h *fileHeader) encode(key string, value []byte) []byte {
+	var ex int64
+
+	if !h.expire.IsZero() {
+		ex = h.expire.UnixNano()
+	}
+
+	b := make([]byte, headerSize, headerSize+len(key))
+	copy(b, fileMagic)
+	b[4] = fileVersion
+	binary.BigEndian.PutUint64(b[5:], uint64(ex))
+	binary.BigEndian.PutUint32(b[13:], h.keyLen)
+	binary.BigEndian.PutUint64(b[17:], h.valueLen)
+	binary.BigEndian.PutUint32(b[25:], h.checksum)
+
+	return append(b, key...)
+}
+
+func (h *fileHeader) decode(b []byte) error {
+	switch {
+	case len(b) < headerSize:
+		return core.Wrap(ErrBadEntry, "truncated")
+	case string(b[:4]) != fileMagic:
+		return core.Wrap(ErrBadEntry, "bad magic")
+	case b[4] != fileVersion:
+		return core.Wrap(ErrBadEntry, "unsupported version")
+	}
+
+	h.expire = time.Time{}
+	if ex := int64(binary.BigEndian.Uint64(b[5:])); ex != 0 {
+		h.expire = time.Unix(0, ex)
+	}
+	h.keyLen = binary.BigEndian.Uint32(b[13:])
+	h.valueLen = binary.BigEndian.Uint64(b[17:])
+	h.checksum = binary.BigEndian.Uint32(b[25:])
+	return nil
+}
+
+func checksum(key string, value []byte) uint32 {
+	crc := crc32.Update(0, crcTable, []byte(key))
+	return crc32.Update(crc, crcTable, value)
+}
+
+// fileName returns the content-addressed name of the file for a key,
+// relative to the namespace directory
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	s := hex.EncodeToString(sum[:])
+	return filepath.Join(s[:2], s)
+}
+
+// writeTempFile writes an entry file under a temporary name in the given
+// directory, to be renamed into place afterwards.
+func writeTempFile(dir, key string, value []byte, expire time.Time) (string, int64, error) {
+	h := fileHeader{
+		expire:   expire,
+		keyLen:   uint32(len(key)),
+		valueLen: uint64(len(value)),
+		checksum: checksum(key, value),
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", 0, err
+	}
+
+	f, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return "", 0, err
+	}
+
+	_, err = f.Write(h.encode(key, value))
+	if err == nil {
+		_, err = f.Write(value)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", 0, err
+	}
+	return f.Name(), h.Size(), nil
+}
+
+// readFile reads and verifies an entry file
+func readFile(filename, key string) ([]byte, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var h fileHeader
+	if err := h.decode(b); err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) != h.Size() {
+		return nil, core.Wrap(ErrBadEntry, "size mismatch")
+	}
+
+	n := headerSize + int(h.keyLen)
+	k, value := b[headerSize:n], b[n:]
+	switch {
+	case string(k) != key:
+		return nil, core.Wrap(ErrBadEntry, "key mismatch")
+	case checksum(key, value) != h.checksum:
+		return nil, core.Wrap(ErrBadEntry, "checksum mismatch")
+	default:
+		return value, nil
+	}
+}
+
+// readFileHeader reads the header and key of an entry file,
+// and verifies its size
+func readFileHeader(filename string) (string, *fileHeader, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	var h fileHeader
+	b := make([]byte, headerSize)
+	if _, err := io.ReadFull(f, b); err != nil {
+		return "", nil, core.Wrap(ErrBadEntry, "truncated")
+	}
+
+	if err := h.decode(b); err != nil {
+		return "", nil, err
+	}
+
+	fi, err := f.Stat()
+	switch {
+	case err != nil:
+		return "", nil, err
+	case fi.Size() != h.Size():
+		return "", nil, core.Wrap(ErrBadEntry, "size mismatch")
+	}
+
+	key := make([]byte, h.keyLen)
+	if _, err := io.ReadFull(f, key); err != nil {
+		return "", nil, core.Wrap(ErrBadEntry, "truncated")
+	}
+
+	if fileName(string(key)) != relName(filename) {
+		return "", nil, core.Wrap(ErrBadEntry, "misplaced")
+	}
+	return string(key), &h, nil
+}
+
+// relName returns the last two elements of a path
+func relName(filename string) string {
+	dir, base := filepath.Split(filename)
+	return filepath.Join(filepath.Base(dir), base)
+}
+
+func isTempFile(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), tempPrefix)
+}
+
+// isEntryFile tells if the last two elements of a path
+// look like those given by fileName
+func isEntryFile(name string) bool {
+	dir, base := filepath.Split(relName(name))
+	if len(base) != 2*sha256.Size || filepath.Clean(dir) != base[:2] {
+		return false
+	}
+
+	_, err := hex.DecodeString(base)
+	return err == nil
+}
@@ -0,0 +1,111 @@
+package diskcache
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Store[string] = (*Store)(nil)
+)
+
+// Store manages persistent [cache.Cache]s, each on its own
+// subdirectory
+type Store struct {
+	mu   sync.Mutex
+	path string
+	log  slog.Logger
+	m    map[string]*Cache
+}
+
+// New creates a new [Store] on the given directory
+func New(path string) (*Store, error) {
+	if err := os.MkdirAll(path, 0o750); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		path: path,
+		m:    make(map[string]*Cache),
+	}
+	return s, nil
+}
+
+// DeregisterCache disconnects a [Cache] from the [Store]. Its files
+// are kept for the next time it's created.
+func (s *Store) DeregisterCache(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.m, name)
+}
+
+// GetCache finds a [Cache] by name in the [Store]
+func (s *Store) GetCache(name string) cache.Cache[string] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.m[name]
+	if ok {
+		return g
+	}
+	return nil
+}
+
+// NewCache creates a new persistent [Cache] attached to the [Store],
+// recovering the entries stored by a previous run
+func (s *Store) NewCache(name string, cacheBytes int64, getter cache.Getter[string]) cache.Cache[string] {
+	if name == "" || getter == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.m[name]; ok {
+		core.Panicf("%s: %s", name, "cache already registered")
+	}
+
+	g, err := NewCache(name, s.namespacePath(name), cacheBytes, getter)
+	if err != nil {
+		if log, ok := s.withLogger(slog.Error); ok {
+			log.WithField(slog.ErrorFieldName, err).
+				Printf("cache:%q failed", name)
+		}
+		return nil
+	}
+
+	g.SetLogger(s.log)
+	s.m[name] = g
+
+	if log, ok := s.withLogger(slog.Debug); ok {
+		log.Printf("cache:%q created", name)
+	}
+	return g
+}
+
+// namespacePath returns a safe subdirectory name for a namespace
+func (s *Store) namespacePath(name string) string {
+	return filepath.Join(s.path, "ns-"+url.PathEscape(name))
+}
+
+// SetLogger attaches a [slog.Logger] to the store and any new Cache created through it
+func (s *Store) SetLogger(log slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = log
+}
+
+func (s *Store) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	if s.log != nil {
+		return s.log.WithLevel(level).WithEnabled()
+	}
+	return nil, false
+}
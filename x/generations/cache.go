@@ -0,0 +1,73 @@
+package generations
+
+import (
+	"context"
+	"time"
+
+	"darvaza.org/cache"
+)
+
+var (
+	_ cache.Cache[string] = (*Cache[string])(nil)
+	_ cache.Resizer       = (*Cache[string])(nil)
+)
+
+// Cache is a namespace of a [Store]
+type Cache[K comparable] struct {
+	s *Store[K]
+	c cache.Cache[string]
+}
+
+// Name returns the name of the [Cache]
+func (c *Cache[K]) Name() string {
+	return c.c.Name()
+}
+
+// Get reads an entry of the current generation into a Sink
+func (c *Cache[K]) Get(ctx context.Context, key K, dest cache.Sink) error {
+	k, err := c.s.encodeKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.c.Get(ctx, k, dest)
+}
+
+// Set adds an entry to the current generation
+func (c *Cache[K]) Set(ctx context.Context, key K, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	k, err := c.s.encodeKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.c.Set(ctx, k, value, expire, cacheType)
+}
+
+// Remove removes an entry of the current generation
+func (c *Cache[K]) Remove(ctx context.Context, key K) {
+	if k, err := c.s.encodeKey(ctx, key); err == nil {
+		c.c.Remove(ctx, k)
+	}
+}
+
+// Stats returns stats about the underlying cache, including
+// entries of previous generations
+func (c *Cache[K]) Stats(cacheType cache.Type) cache.Stats {
+	return c.c.Stats(cacheType)
+}
+
+// Resize changes the capacity of the underlying cache, if supported
+func (c *Cache[K]) Resize(ctx context.Context, cacheBytes int64) (int, error) {
+	if r, ok := c.c.(cache.Resizer); ok {
+		return r.Resize(ctx, cacheBytes)
+	}
+	return 0, cache.ErrNotSupported
+}
+
+// Purge removes all entries of the underlying cache, if supported
+func (c *Cache[K]) Purge(ctx context.Context) error {
+	if r, ok := c.c.(cache.Resizer); ok {
+		return r.Purge(ctx)
+	}
+	return cache.ErrNotSupported
+}
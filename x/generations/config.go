@@ -0,0 +1,64 @@
+package generations
+
+import (
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+const (
+	// DefaultControlName is the name of the control cache
+	// when none is specified
+	DefaultControlName = "generations"
+	// DefaultControlBytes is the capacity of the control cache
+	// when none is specified
+	DefaultControlBytes = 1 << 20
+	// DefaultControlTTL is how long a generation is used when
+	// none is specified
+	DefaultControlTTL = 5 * time.Minute
+)
+
+// Config describes a [Store]
+type Config[K comparable] struct {
+	// Codec converts keys to strings. Required.
+	Codec cache.KeyCodec[K]
+	// Namespace returns the namespace of a key. Required.
+	Namespace func(K) string
+
+	// ControlName is the name of the control cache on the
+	// underlying store, [DefaultControlName] if empty.
+	ControlName string
+	// ControlBytes is the capacity of the control cache,
+	// [DefaultControlBytes] if zero.
+	ControlBytes int64
+	// ControlTTL is how long a generation is used before a new one
+	// is started, [DefaultControlTTL] if zero, or never if negative.
+	// It bounds how long a peer that missed a Bump keeps using the
+	// old generation, and also the lifetime of the entries of each
+	// generation.
+	ControlTTL time.Duration
+}
+
+// SetDefaults fills the gaps in the [Config] and validates it
+func (cfg *Config[K]) SetDefaults() error {
+	switch {
+	case cfg == nil:
+		return core.ErrNilReceiver
+	case cfg.Codec == nil:
+		return core.Wrap(core.ErrInvalid, "missing key codec")
+	case cfg.Namespace == nil:
+		return core.Wrap(core.ErrInvalid, "missing namespace function")
+	}
+
+	if cfg.ControlName == "" {
+		cfg.ControlName = DefaultControlName
+	}
+	if cfg.ControlBytes <= 0 {
+		cfg.ControlBytes = DefaultControlBytes
+	}
+	if cfg.ControlTTL == 0 {
+		cfg.ControlTTL = DefaultControlTTL
+	}
+	return nil
+}
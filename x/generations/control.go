@@ -0,0 +1,83 @@
+package generations
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+// Control keeps the current generation of each namespace in a
+// cache.Cache. Missing namespaces get a new generation, derived
+// from the clock, when first used, and generations expire after
+// the configured TTL, if positive.
+type Control struct {
+	c   cache.Cache[string]
+	ttl time.Duration
+
+	mu   sync.Mutex
+	last uint64
+}
+
+func newControl(store cache.Store[string], name string, cacheBytes int64,
+	ttl time.Duration) (*Control, error) {
+	//
+	ctl := &Control{ttl: ttl}
+
+	c := store.NewCache(name, cacheBytes, cache.GetterFunc[string](ctl.newGeneration))
+	if c == nil {
+		return nil, core.Wrap(core.ErrInvalid, "failed to create control cache")
+	}
+
+	ctl.c = c
+	return ctl, nil
+}
+
+// newGeneration is the [cache.Getter] of the control cache, returning
+// a value greater than any previous one of this process, valid for
+// the TTL of the [Control], or forever if not positive
+func (ctl *Control) newGeneration(_ context.Context, _ string, dest cache.Sink) error {
+	var expire time.Time
+
+	now := time.Now()
+	if ctl.ttl > 0 {
+		expire = now.Add(ctl.ttl)
+	}
+
+	ctl.mu.Lock()
+	gen := max(uint64(now.UnixNano()), ctl.last+1)
+	ctl.last = gen
+	ctl.mu.Unlock()
+
+	return dest.SetBytes([]byte(strconv.FormatUint(gen, 10)), expire)
+}
+
+// Generation returns the current generation of a namespace
+func (ctl *Control) Generation(ctx context.Context, ns string) (string, error) {
+	var dest cache.ByteSink
+
+	if err := ctl.c.Get(ctx, ns, &dest); err != nil {
+		return "", err
+	}
+	return string(dest.Bytes()), nil
+}
+
+// Bump invalidates all entries of a namespace by discarding its
+// generation, so a new one is created on the next access. Entries
+// of previous generations are left to age out.
+//
+// Peers holding a copy of the generation that miss the removal keep
+// using it until it expires, so a Bump takes effect everywhere within
+// the ControlTTL of the [Config], or never reaches them if generations
+// don't expire.
+func (ctl *Control) Bump(ctx context.Context, ns string) {
+	ctl.c.Remove(ctx, ns)
+}
+
+// Cache returns the underlying control cache
+func (ctl *Control) Cache() cache.Cache[string] {
+	return ctl.c
+}
@@ -0,0 +1,3 @@
+// Package generations provides a cache.Store decorator invalidating
+// whole namespaces at once by prefixing keys with a generation number
+package generations
@@ -0,0 +1,134 @@
+package generations
+
+import (
+	"context"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Store[string] = (*Store[string])(nil)
+)
+
+// Store is a cache.Store whose keys belong to namespaces that can be
+// invalidated at once. Entries are kept on an underlying store of
+// strings, like memcache.Store or those of x/groupcache, under keys
+// made of their namespace, its current generation and the encoded key.
+type Store[K comparable] struct {
+	store cache.Store[string]
+	ctl   *Control
+	codec cache.KeyCodec[K]
+	ns    func(K) string
+}
+
+// New creates a [Store] on top of another, where it registers the
+// control cache.
+func New[K comparable](store cache.Store[string], cfg *Config[K]) (*Store[K], error) {
+	if store == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing store")
+	} else if cfg == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing config")
+	}
+
+	c := *cfg
+	if err := c.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	ctl, err := newControl(store, c.ControlName, c.ControlBytes, c.ControlTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store[K]{
+		store: store,
+		ctl:   ctl,
+		codec: c.Codec,
+		ns:    c.Namespace,
+	}, nil
+}
+
+// Control returns the [Control] keeping the generations
+func (s *Store[K]) Control() *Control {
+	return s.ctl
+}
+
+// Invalidate discards all entries of a namespace, on all caches
+// of the [Store]
+func (s *Store[K]) Invalidate(ctx context.Context, ns string) {
+	s.ctl.Bump(ctx, ns)
+}
+
+// NewCache creates a new [Cache] namespace on the underlying store.
+// The getter receives the original keys.
+func (s *Store[K]) NewCache(name string, cacheBytes int64, getter cache.Getter[K]) cache.Cache[K] {
+	if getter == nil {
+		return nil
+	}
+
+	fn := func(ctx context.Context, key string, dest cache.Sink) error {
+		k, err := s.decodeKey(key)
+		if err != nil {
+			return err
+		}
+		return getter.Get(ctx, k, dest)
+	}
+
+	return s.wrap(s.store.NewCache(name, cacheBytes, cache.GetterFunc[string](fn)))
+}
+
+// GetCache returns a named [Cache] previously created
+func (s *Store[K]) GetCache(name string) cache.Cache[K] {
+	return s.wrap(s.store.GetCache(name))
+}
+
+func (s *Store[K]) wrap(c cache.Cache[string]) cache.Cache[K] {
+	if c == nil {
+		return nil
+	}
+	return &Cache[K]{s: s, c: c}
+}
+
+// DeregisterCache removes a [Cache] from the underlying store
+func (s *Store[K]) DeregisterCache(name string) {
+	s.store.DeregisterCache(name)
+}
+
+// SetLogger attaches a slog.Logger to the underlying store
+func (s *Store[K]) SetLogger(log slog.Logger) {
+	s.store.SetLogger(log)
+}
+
+// encodeKey returns the key used on the underlying store
+func (s *Store[K]) encodeKey(ctx context.Context, key K) (string, error) {
+	k, err := s.codec.EncodeKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	ns := s.ns(key)
+	gen, err := s.ctl.Generation(ctx, ns)
+	if err != nil {
+		return "", err
+	}
+
+	return cache.JoinKey(ns, gen, k)
+}
+
+// decodeKey returns the original key from one used on the
+// underlying store
+func (s *Store[K]) decodeKey(key string) (K, error) {
+	var zero K
+
+	parts, err := cache.SplitKey(key)
+	switch {
+	case err != nil:
+		return zero, err
+	case len(parts) != 3:
+		return zero, core.Wrap(core.ErrInvalid, "bad key")
+	default:
+		return s.codec.DecodeKey(parts[2])
+	}
+}
@@ -0,0 +1,143 @@
+package generations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/core"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("set and get", runTestStoreSetGet)
+	t.Run("invalidate", runTestStoreInvalidate)
+	t.Run("never expire", runTestStoreNeverExpire)
+}
+
+// countingGetter returns the key and the number of loads so far
+// of that key as value
+type countingGetter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (g *countingGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]int)
+	}
+	g.calls[key]++
+	n := g.calls[key]
+	g.mu.Unlock()
+
+	return dest.SetBytes([]byte(fmt.Sprintf("%s#%v", key, n)), time.Time{})
+}
+
+func (g *countingGetter) Calls(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.calls[key]
+}
+
+// namespaceOf returns the part of a key before the first '/'
+func namespaceOf(key string) string {
+	ns, _, _ := strings.Cut(key, "/")
+	return ns
+}
+
+func newTestStore(t *testing.T, ttl time.Duration) (*Store[string], cache.Cache[string], *countingGetter) {
+	t.Helper()
+
+	s, err := New(memcache.New[string](), &Config[string]{
+		Codec:      cache.StringKeys{},
+		Namespace:  namespaceOf,
+		ControlTTL: ttl,
+	})
+	core.AssertMustNoError(t, err, "New")
+
+	g := new(countingGetter)
+	c := s.NewCache("test", 1<<20, g)
+	core.AssertMustNotNil(t, c, "NewCache")
+	return s, c, g
+}
+
+func getString(t *testing.T, c cache.Cache[string], key string) string {
+	t.Helper()
+
+	var dest cache.ByteSink
+	err := c.Get(context.Background(), key, &dest)
+	core.AssertMustNoError(t, err, "Get %q", key)
+	return string(dest.Bytes())
+}
+
+func runTestStoreSetGet(t *testing.T) {
+	ctx := context.Background()
+	_, c, g := newTestStore(t, 0)
+
+	err := c.Set(ctx, "a/k1", []byte("v1"), time.Time{}, cache.MainCache)
+	core.AssertMustNoError(t, err, "Set")
+
+	core.AssertEqual(t, "v1", getString(t, c, "a/k1"), "Get set entry")
+	core.AssertEqual(t, 0, g.Calls("a/k1"), "getter calls")
+
+	core.AssertEqual(t, "a/k2#1", getString(t, c, "a/k2"), "Get loaded entry")
+	core.AssertEqual(t, "a/k2#1", getString(t, c, "a/k2"), "Get cached entry")
+	core.AssertEqual(t, 1, g.Calls("a/k2"), "getter calls")
+}
+
+func runTestStoreInvalidate(t *testing.T) {
+	ctx := context.Background()
+	s, c, g := newTestStore(t, 0)
+
+	err := c.Set(ctx, "a/k1", []byte("v1"), time.Time{}, cache.MainCache)
+	core.AssertMustNoError(t, err, "Set")
+	core.AssertEqual(t, "a/k2#1", getString(t, c, "a/k2"), "Get a/k2")
+	core.AssertEqual(t, "b/k1#1", getString(t, c, "b/k1"), "Get b/k1")
+
+	s.Invalidate(ctx, "a")
+
+	// entries of the old generation are unreachable
+	core.AssertEqual(t, "a/k1#1", getString(t, c, "a/k1"), "Get a/k1 after Invalidate")
+	core.AssertEqual(t, "a/k2#2", getString(t, c, "a/k2"), "Get a/k2 after Invalidate")
+	core.AssertEqual(t, 2, g.Calls("a/k2"), "a/k2 getter calls")
+
+	// other namespaces are unaffected
+	core.AssertEqual(t, "b/k1#1", getString(t, c, "b/k1"), "Get b/k1 after Invalidate")
+	core.AssertEqual(t, 1, g.Calls("b/k1"), "b/k1 getter calls")
+}
+
+func runTestStoreNeverExpire(t *testing.T) {
+	ctx := context.Background()
+	s, c, _ := newTestStore(t, -1)
+
+	core.AssertEqual(t, "a/k1#1", getString(t, c, "a/k1"), "Get a/k1")
+
+	var dest cache.ByteSink
+	err := s.Control().Cache().Get(ctx, "a", &dest)
+	core.AssertMustNoError(t, err, "Get generation")
+	core.AssertTrue(t, dest.Expire().IsZero(), "generation expiry")
+}
+
+func TestDecodeKey(t *testing.T) {
+	s := &Store[string]{codec: cache.StringKeys{}}
+
+	for _, k := range []string{"k1", "", "a:b", "50%"} {
+		key, err := cache.JoinKey("ns", uint64(3), k)
+		core.AssertMustNoError(t, err, "JoinKey %q", k)
+
+		got, err := s.decodeKey(key)
+		core.AssertNoError(t, err, "decodeKey %q", k)
+		core.AssertEqual(t, k, got, "decodeKey %q", k)
+	}
+
+	for _, key := range []string{"", cache.MustJoinKey("ns", 3), "ns:3:k1"} {
+		_, err := s.decodeKey(key)
+		core.AssertErrorIs(t, err, core.ErrInvalid, "decodeKey %q", key)
+	}
+}
@@ -0,0 +1,6 @@
+// Package cluster provides an instance-scoped peer-to-peer
+// implementation of cache.Store, wire compatible with mailgun's
+// groupcache, so multiple independent pools can coexist in the
+// same process. Peers can alternatively talk gRPC using a
+// GRPCPool.
+package cluster
@@ -0,0 +1,5 @@
+// Package clusterpb contains the protobuf messages and gRPC service
+// used by the peers of a cluster.GRPCPool
+package clusterpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative cluster.proto
@@ -0,0 +1,10 @@
+package cluster
+
+import (
+	"darvaza.org/core"
+)
+
+var (
+	// ErrRemoteCall indicates the owner of a key failed to load it
+	ErrRemoteCall = core.Wrap(core.ErrUnknown, "remote call failed")
+)
@@ -0,0 +1,48 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// flight makes concurrent loads of the same key share one call
+type flight struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	wg     sync.WaitGroup
+	value  []byte
+	expire time.Time
+	err    error
+}
+
+// Do calls fn unless another call for the key is in progress, in
+// which case it waits for its result
+func (f *flight) Do(key string, fn func() ([]byte, time.Time, error)) ([]byte, time.Time, error) {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]*call)
+	}
+
+	if c, ok := f.calls[key]; ok {
+		f.mu.Unlock()
+		c.wg.Wait()
+		return c.value, c.expire, c.err
+	}
+
+	c := new(call)
+	c.wg.Add(1)
+	f.calls[key] = c
+	f.mu.Unlock()
+
+	c.value, c.expire, c.err = fn()
+	c.wg.Done()
+
+	f.mu.Lock()
+	delete(f.calls, key)
+	f.mu.Unlock()
+
+	return c.value, c.expire, c.err
+}
@@ -0,0 +1,261 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/core"
+)
+
+var (
+	_ cache.Cache[string] = (*Group)(nil)
+	_ cache.Resizer       = (*Group)(nil)
+
+	_ cache.ExtendedStatser = (*Group)(nil)
+)
+
+// hotRatio is the fraction of the capacity of a [Group] used to keep
+// copies of popular keys owned by other peers
+const hotRatio = 8
+
+// Group is a [cache.Cache] namespace distributed among the peers of a
+// [Pool]. Each key is loaded and kept by its owner, and other peers
+// occasionally keep a copy in their hot cache.
+type Group struct {
+	name   string
+	pool   *Pool
+	getter cache.Getter[string]
+	loads  flight
+	stats  cache.LoadStats
+
+	mu   sync.Mutex
+	main *memcache.LRU[string]
+	hot  *memcache.LRU[string]
+}
+
+func newGroup(pool *Pool, name string, cacheBytes int64, getter cache.Getter[string]) *Group {
+	mainBytes, hotBytes := splitSize(cacheBytes)
+	return &Group{
+		name:   name,
+		pool:   pool,
+		getter: getter,
+		main:   memcache.NewLRU[string](mainBytes, nil, nil),
+		hot:    memcache.NewLRU[string](hotBytes, nil, nil),
+	}
+}
+
+func splitSize(cacheBytes int64) (mainBytes, hotBytes int64) {
+	hotBytes = cacheBytes / hotRatio
+	return cacheBytes - hotBytes, hotBytes
+}
+
+// Name returns the name of the [Group]
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Get reads an entry into a [cache.Sink], from the local caches, the
+// owner of the key, or the [cache.Getter] if we own it. Concurrent loads
+// of the same key share a single call.
+func (g *Group) Get(ctx context.Context, key string, dest cache.Sink) error {
+	if dest == nil {
+		return core.ErrInvalid
+	}
+
+	if v, ex, ok := g.lookup(key); ok {
+		g.stats.RecordLocalHit()
+		cache.ObserveHit(ctx)
+		return dest.SetBytes(v, ex)
+	}
+
+	var started bool
+	v, ex, err := g.loads.Do(key, func() ([]byte, time.Time, error) {
+		started = true
+		return g.load(ctx, key)
+	})
+	if !started {
+		g.stats.RecordDedupWait()
+		cache.ObserveWait(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	return dest.SetBytes(v, ex)
+}
+
+func (g *Group) lookup(key string) ([]byte, time.Time, bool) {
+	for _, lru := range []*memcache.LRU[string]{g.main, g.hot} {
+		if v, ex, ok := lru.Get(key); ok {
+			var expire time.Time
+			if ex != nil {
+				expire = *ex
+			}
+			return v, expire, true
+		}
+	}
+	return nil, time.Time{}, false
+}
+
+// load gets a value from its owner, or from the [cache.Getter] if we
+// own it or the owner can't be reached
+func (g *Group) load(ctx context.Context, key string) ([]byte, time.Time, error) {
+	// check again, someone may have loaded it while we waited
+	if v, ex, ok := g.lookup(key); ok {
+		return v, ex, nil
+	}
+
+	if peer, ok := g.pool.pickPeer(key); ok {
+		pctx, done := cache.ObservePeer(ctx, peer.Name())
+		v, ex, err := peer.Get(pctx, g.name, key)
+		done(err)
+		switch {
+		case err == nil:
+			g.stats.RecordPeerHit()
+			g.maybeHot(key, v, ex)
+			return v, ex, nil
+		case !isTransportError(ctx, err):
+			return nil, time.Time{}, err
+		}
+
+		g.stats.RecordPeerError()
+		g.pool.logPeerError(peer, g.name, key, err)
+	}
+
+	return g.loadLocally(ctx, key)
+}
+
+func (g *Group) loadLocally(ctx context.Context, key string) ([]byte, time.Time, error) {
+	var dest cache.ByteSink
+
+	lctx, done := cache.ObserveLoad(ctx)
+	start := time.Now()
+	err := g.getter.Get(lctx, key, &dest)
+	g.stats.RecordLoad(time.Since(start), err)
+	done(err)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	v, ex := dest.Bytes(), dest.Expire()
+	g.main.Add(key, v, ex)
+	return v, ex, nil
+}
+
+// maybeHot keeps a copy of one in ten values received from peers
+func (g *Group) maybeHot(key string, v []byte, ex time.Time) {
+	if rand.IntN(10) == 0 {
+		g.hot.Add(key, v, ex)
+	}
+}
+
+// isTransportError tells if a peer failed to answer, instead of
+// answering with an error
+func isTransportError(ctx context.Context, err error) bool {
+	switch {
+	case ctx.Err() != nil:
+		return false
+	case errors.Is(err, cache.ErrNotFound), errors.Is(err, ErrRemoteCall):
+		return false
+	default:
+		return true
+	}
+}
+
+// Set stores an entry on the owner of the key. If it's remote, HotCache
+// also keeps a local copy.
+func (g *Group) Set(ctx context.Context, key string, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	if peer, ok := g.pool.pickPeer(key); ok {
+		if err := peer.Set(ctx, g.name, key, value, expire); err != nil {
+			return err
+		}
+
+		if cacheType == cache.HotCache {
+			g.hot.Add(key, value, expire)
+		}
+		return nil
+	}
+
+	g.localSet(key, value, expire)
+	return nil
+}
+
+func (g *Group) localSet(key string, value []byte, expire time.Time) {
+	g.main.Add(key, value, expire)
+}
+
+// Remove evicts an entry from its owner, ourselves, and then
+// from all other peers. Failures are logged, but don't stop the
+// eviction elsewhere.
+func (g *Group) Remove(ctx context.Context, key string) {
+	owner, ok := g.pool.pickPeer(key)
+	if ok {
+		if err := owner.Remove(ctx, g.name, key); err != nil {
+			// evict the copies elsewhere anyway
+			g.pool.logPeerError(owner, g.name, key, err)
+		}
+	}
+
+	g.localRemove(key)
+
+	var wg sync.WaitGroup
+	for _, peer := range g.pool.peers() {
+		if peer != owner {
+			wg.Add(1)
+			go func(peer Peer) {
+				defer wg.Done()
+
+				if err := peer.Remove(ctx, g.name, key); err != nil {
+					g.pool.logPeerError(peer, g.name, key, err)
+				}
+			}(peer)
+		}
+	}
+	wg.Wait()
+}
+
+func (g *Group) localRemove(key string) {
+	g.main.Evict(key)
+	g.hot.Evict(key)
+}
+
+// Stats returns statistics about the main or hot cache of the [Group]
+func (g *Group) Stats(cacheType cache.Type) cache.Stats {
+	switch cacheType {
+	case cache.MainCache:
+		return g.main.Stats()
+	case cache.HotCache:
+		return g.hot.Stats()
+	default:
+		return cache.Stats{}
+	}
+}
+
+// ExtendedStats returns statistics about the main or hot cache of the
+// [Group], and about its loads
+func (g *Group) ExtendedStats(cacheType cache.Type) cache.ExtendedStats {
+	return g.stats.Extend(g.Stats(cacheType))
+}
+
+// Resize changes the capacity of the [Group] on this peer, evicting
+// entries as needed, and returns how many were evicted
+func (g *Group) Resize(_ context.Context, cacheBytes int64) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	mainBytes, hotBytes := splitSize(cacheBytes)
+	return g.main.Resize(mainBytes) + g.hot.Resize(hotBytes), nil
+}
+
+// Purge removes all entries of the [Group] on this peer
+func (g *Group) Purge(context.Context) error {
+	g.main.Purge()
+	g.hot.Purge()
+	return nil
+}
@@ -0,0 +1,215 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mailgun/groupcache/v2/consistenthash"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/groupcache/cluster/clusterpb"
+	"darvaza.org/core"
+)
+
+var (
+	_ cache.Store[string]        = (*GRPCPool)(nil)
+	_ PeerPicker                 = (*GRPCPool)(nil)
+	_ clusterpb.GroupCacheServer = (*grpcServer)(nil)
+)
+
+// GRPCPoolOptions are the configurations of a [GRPCPool]
+type GRPCPoolOptions struct {
+	// Replicas is the number of virtual nodes of each peer on the
+	// hash ring, [DefaultReplicas] if zero
+	Replicas int
+	// HashFn is the hash function of the ring, CRC-32 if nil
+	HashFn consistenthash.Hash
+	// DialOptions are used to connect to the peers. Unless given,
+	// connections are made without transport security.
+	DialOptions []grpc.DialOption
+}
+
+// GRPCPool is a [Pool] whose peers talk gRPC, using the GroupCache
+// service defined in the clusterpb package.
+type GRPCPool struct {
+	*Pool
+	*ring
+
+	opts GRPCPoolOptions
+}
+
+// NewGRPCPool creates a [GRPCPool] with default options. self is the
+// address of this peer as known by the others, e.g. "10.0.0.1:8001".
+func NewGRPCPool(self string) *GRPCPool {
+	return NewGRPCPoolOpts(self, nil)
+}
+
+// NewGRPCPoolOpts creates a [GRPCPool] with the given options
+func NewGRPCPoolOpts(self string, opts *GRPCPoolOptions) *GRPCPool {
+	p := &GRPCPool{}
+
+	if opts != nil {
+		p.opts = *opts
+	}
+	if len(p.opts.DialOptions) == 0 {
+		p.opts.DialOptions = []grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		}
+	}
+
+	p.ring = newRing(self, p.opts.Replicas, p.opts.HashFn)
+	p.Pool = NewPool(p)
+	return p
+}
+
+// Set replaces the list of peers, including ourselves. Each peer is
+// given by its address. Connections to removed peers are closed.
+func (p *GRPCPool) Set(peers ...string) {
+	removed := p.ring.set(peers, func(name string) Peer {
+		return newGRPCPeer(name, p.opts.DialOptions)
+	})
+
+	for _, peer := range removed {
+		p.closePeer(peer)
+	}
+}
+
+// Close closes the connections to all peers
+func (p *GRPCPool) Close() error {
+	for _, peer := range p.ring.set(nil, nil) {
+		p.closePeer(peer)
+	}
+	return nil
+}
+
+func (p *GRPCPool) closePeer(peer Peer) {
+	gp, ok := peer.(*grpcPeer)
+	if !ok {
+		return
+	}
+
+	if err := gp.Close(); err != nil {
+		p.logPeerError(peer, "", "", err)
+	}
+}
+
+// PeerStats asks a peer for the statistics of one of its groups
+func (p *GRPCPool) PeerStats(ctx context.Context, name, group string) (main, hot cache.Stats, err error) {
+	peer, ok := p.ring.get(name)
+	if !ok {
+		return main, hot, core.Wrap(core.ErrInvalid, "unknown peer")
+	}
+
+	gp, ok := peer.(*grpcPeer)
+	if !ok {
+		return main, hot, core.Wrap(core.ErrInvalid, "not a gRPC peer")
+	}
+	return gp.Stats(ctx, group)
+}
+
+// Register adds the GroupCache service of the [GRPCPool] to a
+// [grpc.Server], to handle the requests of other peers
+func (p *GRPCPool) Register(s grpc.ServiceRegistrar) {
+	clusterpb.RegisterGroupCacheServer(s, &grpcServer{pool: p.Pool})
+}
+
+// grpcServer handles the GroupCache requests of other peers
+type grpcServer struct {
+	clusterpb.UnimplementedGroupCacheServer
+
+	pool *Pool
+}
+
+func (s *grpcServer) group(name string) (*Group, error) {
+	if g := s.pool.getGroup(name); g != nil {
+		return g, nil
+	}
+	return nil, status.Error(codes.NotFound, "no such group: "+name)
+}
+
+func (s *grpcServer) Get(ctx context.Context, in *clusterpb.GetRequest) (*clusterpb.GetResponse, error) {
+	g, err := s.group(in.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+
+	var dest cache.ByteSink
+	if err := g.Get(ctx, in.GetKey(), &dest); err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	return &clusterpb.GetResponse{
+		Value:  dest.Bytes(),
+		Expire: unixNano(dest.Expire()),
+	}, nil
+}
+
+func (s *grpcServer) Set(_ context.Context, in *clusterpb.SetRequest) (*clusterpb.SetResponse, error) {
+	g, err := s.group(in.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+
+	g.localSet(in.GetKey(), in.GetValue(), fromUnixNano(in.GetExpire()))
+	return &clusterpb.SetResponse{}, nil
+}
+
+func (s *grpcServer) Remove(_ context.Context, in *clusterpb.RemoveRequest) (*clusterpb.RemoveResponse, error) {
+	g, err := s.group(in.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+
+	g.localRemove(in.GetKey())
+	return &clusterpb.RemoveResponse{}, nil
+}
+
+func (s *grpcServer) Stats(_ context.Context, in *clusterpb.StatsRequest) (*clusterpb.StatsResponse, error) {
+	g, err := s.group(in.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+
+	return &clusterpb.StatsResponse{
+		Main: encodeStats(g.Stats(cache.MainCache)),
+		Hot:  encodeStats(g.Stats(cache.HotCache)),
+	}, nil
+}
+
+// statusError converts the error of a load into a gRPC status.
+// Failed loads use Aborted so they aren't mistaken for transport
+// errors.
+func statusError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, cache.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	default:
+		return status.Error(codes.Aborted, err.Error())
+	}
+}
+
+func encodeStats(s cache.Stats) *clusterpb.Stats {
+	return &clusterpb.Stats{
+		Bytes:     s.Bytes,
+		Items:     s.Items,
+		Gets:      s.Gets,
+		Hits:      s.Hits,
+		Evictions: s.Evictions,
+	}
+}
+
+func decodeStats(s *clusterpb.Stats) cache.Stats {
+	return cache.Stats{
+		Bytes:     s.GetBytes(),
+		Items:     s.GetItems(),
+		Gets:      s.GetGets(),
+		Hits:      s.GetHits(),
+		Evictions: s.GetEvictions(),
+	}
+}
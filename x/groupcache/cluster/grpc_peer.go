@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/groupcache/cluster/clusterpb"
+	"darvaza.org/core"
+)
+
+var (
+	_ Peer = (*grpcPeer)(nil)
+)
+
+// grpcPeer talks to another member of a [GRPCPool]. The connection
+// is established on first use.
+type grpcPeer struct {
+	name   string
+	conn   *grpc.ClientConn
+	client clusterpb.GroupCacheClient
+	err    error
+}
+
+func newGRPCPeer(name string, opts []grpc.DialOption) *grpcPeer {
+	conn, err := grpc.NewClient(name, opts...)
+	if err != nil {
+		return &grpcPeer{name: name, err: err}
+	}
+
+	return &grpcPeer{
+		name:   name,
+		conn:   conn,
+		client: clusterpb.NewGroupCacheClient(conn),
+	}
+}
+
+func (g *grpcPeer) Name() string { return g.name }
+
+// Get asks the peer for the value of a key
+func (g *grpcPeer) Get(ctx context.Context, group, key string) ([]byte, time.Time, error) {
+	if g.err != nil {
+		return nil, time.Time{}, g.err
+	}
+
+	out, err := g.client.Get(ctx, &clusterpb.GetRequest{
+		Group: group,
+		Key:   key,
+	})
+	if err != nil {
+		return nil, time.Time{}, grpcError(err)
+	}
+	return out.GetValue(), fromUnixNano(out.GetExpire()), nil
+}
+
+// Set stores a value on the peer
+func (g *grpcPeer) Set(ctx context.Context, group, key string, value []byte, expire time.Time) error {
+	if g.err != nil {
+		return g.err
+	}
+
+	_, err := g.client.Set(ctx, &clusterpb.SetRequest{
+		Group:  group,
+		Key:    key,
+		Value:  value,
+		Expire: unixNano(expire),
+	})
+	return grpcError(err)
+}
+
+// Remove evicts a key from the peer
+func (g *grpcPeer) Remove(ctx context.Context, group, key string) error {
+	if g.err != nil {
+		return g.err
+	}
+
+	_, err := g.client.Remove(ctx, &clusterpb.RemoveRequest{
+		Group: group,
+		Key:   key,
+	})
+	return grpcError(err)
+}
+
+// Stats asks the peer for the statistics of a group
+func (g *grpcPeer) Stats(ctx context.Context, group string) (main, hot cache.Stats, err error) {
+	if g.err != nil {
+		return main, hot, g.err
+	}
+
+	out, err := g.client.Stats(ctx, &clusterpb.StatsRequest{Group: group})
+	if err != nil {
+		return main, hot, grpcError(err)
+	}
+	return decodeStats(out.GetMain()), decodeStats(out.GetHot()), nil
+}
+
+// Close closes the connection to the peer
+func (g *grpcPeer) Close() error {
+	if g.conn == nil {
+		return nil
+	}
+	return g.conn.Close()
+}
+
+// grpcError converts an error status into [cache.ErrNotFound] or
+// [ErrRemoteCall] like responseError does for HTTP peers. Other
+// errors are considered transport errors.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch s.Code() {
+	case codes.NotFound:
+		return core.Wrap(cache.ErrNotFound, s.Message())
+	case codes.Aborted:
+		return core.Wrap(ErrRemoteCall, s.Message())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,276 @@
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestGRPCPool(t *testing.T) {
+	t.Run("get", runTestGRPCPoolGet)
+	t.Run("set", runTestGRPCPoolSet)
+	t.Run("remove", runTestGRPCPoolRemove)
+	t.Run("stats", runTestGRPCPoolStats)
+	t.Run("errors", runTestGRPCPoolErrors)
+	t.Run("unreachable", runTestGRPCPoolUnreachable)
+}
+
+// ownerGetter returns the name of the peer followed by the key, fails
+// keys containing "missing" or "broken", and counts its calls
+type ownerGetter struct {
+	name  string
+	calls atomic.Int32
+}
+
+func (g *ownerGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.calls.Add(1)
+
+	switch {
+	case strings.Contains(key, "missing"):
+		return cache.ErrNotFound
+	case strings.Contains(key, "broken"):
+		return core.ErrInvalid
+	default:
+		return dest.SetBytes([]byte(g.name+"-"+key), time.Time{})
+	}
+}
+
+// testPeer is a member of a test cluster
+type testPeer struct {
+	name   string
+	pool   *GRPCPool
+	srv    *grpc.Server
+	getter *ownerGetter
+	group  *Group
+}
+
+// testCluster is a set of [GRPCPool]s talking over in-memory listeners
+type testCluster struct {
+	listeners map[string]*bufconn.Listener
+	peers     []*testPeer
+}
+
+func (tc *testCluster) dial(ctx context.Context, addr string) (net.Conn, error) {
+	ln, ok := tc.listeners[addr]
+	if !ok {
+		return nil, core.Wrap(core.ErrNotExists, addr)
+	}
+	return ln.DialContext(ctx)
+}
+
+// newTestCluster creates the given number of peers, with a "test"
+// group each
+func newTestCluster(t *testing.T, n int) *testCluster {
+	t.Helper()
+
+	tc := &testCluster{
+		listeners: make(map[string]*bufconn.Listener),
+	}
+
+	var names []string
+	for i := range n {
+		addr := cache.MustJoinKey("peer", i)
+		tc.listeners[addr] = bufconn.Listen(1 << 20)
+		names = append(names, "passthrough:///"+addr)
+	}
+
+	opts := &GRPCPoolOptions{
+		// spread the similar names and keys of the test evenly
+		HashFn: testHash,
+		DialOptions: []grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithContextDialer(tc.dial),
+		},
+	}
+
+	for _, name := range names {
+		pool := NewGRPCPoolOpts(name, opts)
+		pool.Set(names...)
+		t.Cleanup(func() { _ = pool.Close() })
+
+		srv := grpc.NewServer()
+		pool.Register(srv)
+		go func(ln net.Listener) { _ = srv.Serve(ln) }(tc.listeners[strings.TrimPrefix(name, "passthrough:///")])
+		t.Cleanup(srv.Stop)
+
+		getter := &ownerGetter{name: name}
+		g := pool.NewCache("test", 1<<20, getter)
+		core.AssertMustNotNil(t, g, "NewCache")
+
+		tc.peers = append(tc.peers, &testPeer{
+			name:   name,
+			pool:   pool,
+			srv:    srv,
+			getter: getter,
+			group:  core.AssertMustTypeIs[*Group](t, g, "NewCache"),
+		})
+	}
+	return tc
+}
+
+func testHash(data []byte) uint64 {
+	sum := sha256.Sum256(data)
+	return binary.BigEndian.Uint64(sum[:])
+}
+
+// ownedKey returns a key with the given prefix owned by the second
+// peer, as seen by the first
+func (tc *testCluster) ownedKey(t *testing.T, prefix string) string {
+	t.Helper()
+
+	for i := range 1000 {
+		key := cache.MustJoinKey(prefix, i)
+		if peer, ok := tc.peers[0].pool.PickPeer(key); ok && peer.Name() == tc.peers[1].name {
+			return key
+		}
+	}
+
+	t.Fatalf("no key owned by %s", tc.peers[1].name)
+	return ""
+}
+
+func getString(ctx context.Context, g cache.Getter[string], key string) (string, error) {
+	var sink cache.ByteSink
+	err := g.Get(ctx, key, &sink)
+	return string(sink.Bytes()), err
+}
+
+func runTestGRPCPoolGet(t *testing.T) {
+	tc := newTestCluster(t, 2)
+	local, remote := tc.peers[0], tc.peers[1]
+	ctx := context.Background()
+	key := tc.ownedKey(t, "k")
+
+	v, err := getString(ctx, local.group, key)
+	core.AssertNoError(t, err, "Get")
+	core.AssertEqual(t, remote.name+"-"+key, v, "loaded by the owner")
+	core.AssertEqual(t, int32(0), local.getter.calls.Load(), "local getter calls")
+	core.AssertEqual(t, int32(1), remote.getter.calls.Load(), "remote getter calls")
+
+	// served by the owner's cache
+	v, err = getString(ctx, tc.peers[0].group, key)
+	core.AssertNoError(t, err, "second Get")
+	core.AssertEqual(t, remote.name+"-"+key, v, "second Get")
+	core.AssertEqual(t, int32(1), remote.getter.calls.Load(), "remote getter calls")
+
+	es := local.group.ExtendedStats(cache.MainCache)
+	core.AssertTrue(t, es.PeerHits >= 1, "peer hits %d", es.PeerHits)
+	core.AssertEqual(t, int64(0), es.PeerErrors, "peer errors")
+}
+
+func runTestGRPCPoolSet(t *testing.T) {
+	tc := newTestCluster(t, 2)
+	local, remote := tc.peers[0], tc.peers[1]
+	ctx := context.Background()
+	key := tc.ownedKey(t, "k")
+
+	err := local.group.Set(ctx, key, []byte("stored"), time.Time{}, cache.MainCache)
+	core.AssertNoError(t, err, "Set")
+
+	v, err := getString(ctx, remote.group, key)
+	core.AssertNoError(t, err, "Get on the owner")
+	core.AssertEqual(t, "stored", v, "Get on the owner")
+	core.AssertEqual(t, int32(0), remote.getter.calls.Load(), "remote getter calls")
+	core.AssertEqual(t, int64(0), local.group.Stats(cache.HotCache).Items, "no hot copy")
+
+	err = local.group.Set(ctx, key, []byte("hot"), time.Time{}, cache.HotCache)
+	core.AssertNoError(t, err, "Set hot")
+	core.AssertEqual(t, int64(1), local.group.Stats(cache.HotCache).Items, "hot copy")
+}
+
+func runTestGRPCPoolRemove(t *testing.T) {
+	tc := newTestCluster(t, 2)
+	local, remote := tc.peers[0], tc.peers[1]
+	ctx := context.Background()
+	key := tc.ownedKey(t, "k")
+
+	err := local.group.Set(ctx, key, []byte("stored"), time.Time{}, cache.HotCache)
+	core.AssertMustNoError(t, err, "Set")
+
+	local.group.Remove(ctx, key)
+	core.AssertEqual(t, int64(0), remote.group.Stats(cache.MainCache).Items, "removed from the owner")
+	core.AssertEqual(t, int64(0), local.group.Stats(cache.HotCache).Items, "removed locally")
+
+	v, err := getString(ctx, local.group, key)
+	core.AssertNoError(t, err, "Get after Remove")
+	core.AssertEqual(t, remote.name+"-"+key, v, "reloaded")
+}
+
+func runTestGRPCPoolStats(t *testing.T) {
+	tc := newTestCluster(t, 2)
+	local, remote := tc.peers[0], tc.peers[1]
+	ctx := context.Background()
+
+	_, err := getString(ctx, local.group, tc.ownedKey(t, "k"))
+	core.AssertMustNoError(t, err, "Get")
+
+	main, hot, err := local.pool.PeerStats(ctx, remote.name, "test")
+	core.AssertNoError(t, err, "PeerStats")
+	core.AssertEqual(t, remote.group.Stats(cache.MainCache), main, "main stats")
+	core.AssertEqual(t, remote.group.Stats(cache.HotCache), hot, "hot stats")
+	core.AssertEqual(t, int64(1), main.Items, "main items")
+
+	_, _, err = local.pool.PeerStats(ctx, remote.name, "other")
+	core.AssertErrorIs(t, err, cache.ErrNotFound, "unknown group")
+
+	_, _, err = local.pool.PeerStats(ctx, "unknown", "test")
+	core.AssertErrorIs(t, err, core.ErrInvalid, "unknown peer")
+}
+
+func runTestGRPCPoolErrors(t *testing.T) {
+	tc := newTestCluster(t, 2)
+	local, remote := tc.peers[0], tc.peers[1]
+	ctx := context.Background()
+
+	_, err := getString(ctx, local.group, tc.ownedKey(t, "missing"))
+	core.AssertErrorIs(t, err, cache.ErrNotFound, "not found")
+
+	_, err = getString(ctx, local.group, tc.ownedKey(t, "broken"))
+	core.AssertErrorIs(t, err, ErrRemoteCall, "failed load")
+
+	// remote failures aren't retried locally
+	core.AssertEqual(t, int32(0), local.getter.calls.Load(), "local getter calls")
+	core.AssertEqual(t, int32(2), remote.getter.calls.Load(), "remote getter calls")
+	core.AssertEqual(t, int64(0), local.group.ExtendedStats(cache.MainCache).PeerErrors, "peer errors")
+}
+
+func runTestGRPCPoolUnreachable(t *testing.T) {
+	tc := newTestCluster(t, 2)
+	local, remote := tc.peers[0], tc.peers[1]
+	key := tc.ownedKey(t, "k")
+
+	// stopping the server closes its listener
+	remote.srv.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := getString(ctx, local.group, key)
+	core.AssertNoError(t, err, "Get")
+	core.AssertEqual(t, local.name+"-"+key, v, "loaded locally")
+	core.AssertEqual(t, int64(1), local.group.ExtendedStats(cache.MainCache).PeerErrors, "peer errors")
+}
+
+func TestGRPCError(t *testing.T) {
+	core.AssertNil(t, grpcError(nil), "nil")
+	core.AssertErrorIs(t, grpcError(status.Error(codes.NotFound, "x")), cache.ErrNotFound, "NotFound")
+	core.AssertErrorIs(t, grpcError(status.Error(codes.Aborted, "x")), ErrRemoteCall, "Aborted")
+
+	err := grpcError(status.Error(codes.Unavailable, "x"))
+	core.AssertEqual(t, codes.Unavailable, status.Code(err), "Unavailable")
+	core.AssertTrue(t, isTransportError(context.Background(), err), "transport error")
+}
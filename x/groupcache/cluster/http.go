@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mailgun/groupcache/v2"
+	pb "github.com/mailgun/groupcache/v2/groupcachepb"
+
+	"darvaza.org/cache"
+)
+
+const (
+	// DefaultBasePath is the HTTP path served by default,
+	// as mailgun's groupcache
+	DefaultBasePath = "/_groupcache/"
+	// DefaultReplicas is the default number of virtual nodes of
+	// each peer on the hash ring, as mailgun's groupcache
+	DefaultReplicas = 50
+)
+
+// maxSetBody limits the size of the Set requests of other peers
+const maxSetBody = 64 << 20
+
+var (
+	_ cache.Store[string] = (*HTTPPool)(nil)
+	_ PeerPicker          = (*HTTPPool)(nil)
+	_ http.Handler        = (*HTTPPool)(nil)
+)
+
+// HTTPPoolOptions are the configurations of a [HTTPPool], shared with
+// mailgun's groupcache
+type HTTPPoolOptions = groupcache.HTTPPoolOptions
+
+// HTTPPool is a [Pool] whose peers talk HTTP, using the same requests,
+// paths, protobuf messages and key distribution as mailgun's groupcache.
+type HTTPPool struct {
+	*Pool
+	*ring
+
+	opts HTTPPoolOptions
+}
+
+// NewHTTPPool creates an [HTTPPool] with default options. self is the
+// base URL of this peer, e.g. "http://10.0.0.1:8000".
+// Unlike mailgun's it isn't registered on [http.DefaultServeMux].
+func NewHTTPPool(self string) *HTTPPool {
+	return NewHTTPPoolOpts(self, nil)
+}
+
+// NewHTTPPoolOpts creates an [HTTPPool] with the given options
+func NewHTTPPoolOpts(self string, opts *HTTPPoolOptions) *HTTPPool {
+	p := &HTTPPool{}
+
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.BasePath == "" {
+		p.opts.BasePath = DefaultBasePath
+	}
+	if p.opts.Replicas == 0 {
+		p.opts.Replicas = DefaultReplicas
+	}
+
+	p.ring = newRing(self, p.opts.Replicas, p.opts.HashFn)
+	p.Pool = NewPool(p)
+	return p
+}
+
+// Set replaces the list of peers, including ourselves. Each peer is
+// given by its base URL.
+func (p *HTTPPool) Set(peers ...string) {
+	p.ring.set(peers, func(name string) Peer {
+		return &httpPeer{
+			name:      name,
+			baseURL:   name + p.opts.BasePath,
+			transport: p.opts.Transport,
+		}
+	})
+}
+
+// ServeHTTP handles the requests of other peers on the BasePath
+func (p *HTTPPool) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	group, key, ok := p.parsePath(req.URL.Path)
+	if !ok {
+		http.Error(rw, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	g := p.getGroup(group)
+	if g == nil {
+		http.Error(rw, "no such group: "+group, http.StatusNotFound)
+		return
+	}
+
+	ctx := req.Context()
+	if p.opts.Context != nil {
+		ctx = p.opts.Context(req)
+	}
+
+	switch req.Method {
+	case http.MethodDelete:
+		g.localRemove(key)
+	case http.MethodPut:
+		serveSet(rw, req, g)
+	default:
+		serveGet(ctx, rw, g, key)
+	}
+}
+
+func (p *HTTPPool) parsePath(path string) (group, key string, ok bool) {
+	rest, ok := strings.CutPrefix(path, p.opts.BasePath)
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(rest, "/")
+}
+
+func serveGet(ctx context.Context, rw http.ResponseWriter, g *Group, key string) {
+	var dest cache.ByteSink
+
+	err := g.Get(ctx, key, &dest)
+	switch {
+	case errors.Is(err, cache.ErrNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	expire := unixNano(dest.Expire())
+	body, err := proto.Marshal(&pb.GetResponse{
+		Value:  dest.Bytes(),
+		Expire: &expire,
+	})
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = rw.Write(body)
+}
+
+func serveSet(rw http.ResponseWriter, req *http.Request, g *Group) {
+	b, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, maxSetBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(rw, err.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var in pb.SetRequest
+	if err := proto.Unmarshal(b, &in); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	g.localSet(in.GetKey(), in.GetValue(), fromUnixNano(in.GetExpire()))
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
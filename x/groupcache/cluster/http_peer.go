@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/mailgun/groupcache/v2/groupcachepb"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+var (
+	_ Peer = (*httpPeer)(nil)
+)
+
+// maxErrorBody limits how much of an error response is read
+const maxErrorBody = 1 << 20
+
+// httpPeer talks to another member of an [HTTPPool]
+type httpPeer struct {
+	name      string
+	baseURL   string
+	transport func(context.Context) http.RoundTripper
+}
+
+func (h *httpPeer) Name() string { return h.name }
+
+func (h *httpPeer) do(ctx context.Context, method, group, key string, body []byte) (*http.Response, error) {
+	u := h.baseURL + url.PathEscape(group) + "/" + url.PathEscape(key)
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := http.DefaultTransport
+	if h.transport != nil {
+		tr = h.transport(ctx)
+	}
+	return tr.RoundTrip(req)
+}
+
+// Get asks the peer for the value of a key
+func (h *httpPeer) Get(ctx context.Context, group, key string) ([]byte, time.Time, error) {
+	res, err := h.do(ctx, http.MethodGet, group, key, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, time.Time{}, responseError(res)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, time.Time{}, core.Wrap(err, "reading response body")
+	}
+
+	var out pb.GetResponse
+	if err := proto.Unmarshal(b, &out); err != nil {
+		return nil, time.Time{}, core.Wrap(err, "decoding response body")
+	}
+	return out.GetValue(), fromUnixNano(out.GetExpire()), nil
+}
+
+// Set stores a value on the peer
+func (h *httpPeer) Set(ctx context.Context, group, key string, value []byte, expire time.Time) error {
+	ex := unixNano(expire)
+	body, err := proto.Marshal(&pb.SetRequest{
+		Group:  &group,
+		Key:    &key,
+		Value:  value,
+		Expire: &ex,
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.simple(ctx, http.MethodPut, group, key, body)
+}
+
+// Remove evicts a key from the peer
+func (h *httpPeer) Remove(ctx context.Context, group, key string) error {
+	return h.simple(ctx, http.MethodDelete, group, key, nil)
+}
+
+func (h *httpPeer) simple(ctx context.Context, method, group, key string, body []byte) error {
+	res, err := h.do(ctx, method, group, key, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError(res)
+	}
+	return nil
+}
+
+// responseError converts an error response into
+// [cache.ErrNotFound] or [ErrRemoteCall] like mailgun's groupcache
+func responseError(res *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	msg := strings.TrimSpace(string(b))
+
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return core.Wrap(cache.ErrNotFound, msg)
+	case http.StatusServiceUnavailable:
+		return core.Wrap(ErrRemoteCall, msg)
+	default:
+		return fmt.Errorf("server returned: %v, %v", res.Status, msg)
+	}
+}
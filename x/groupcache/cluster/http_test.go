@@ -0,0 +1,227 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestHTTPPool(t *testing.T) {
+	t.Run("get", runTestHTTPPoolGet)
+	t.Run("set", runTestHTTPPoolSet)
+	t.Run("remove", runTestHTTPPoolRemove)
+	t.Run("remove unreachable", runTestHTTPPoolRemoveUnreachable)
+	t.Run("errors", runTestHTTPPoolErrors)
+	t.Run("bad requests", runTestHTTPPoolBadRequests)
+}
+
+// httpTestPeer is a member of a test cluster talking HTTP
+type httpTestPeer struct {
+	name   string
+	pool   *HTTPPool
+	srv    *httptest.Server
+	getter *ownerGetter
+	group  *Group
+}
+
+// newHTTPTestCluster creates the given number of [HTTPPool]s served
+// by [httptest.Server]s, with a "test" group each
+func newHTTPTestCluster(t *testing.T, n int) []*httpTestPeer {
+	t.Helper()
+
+	var names []string
+	peers := make([]*httpTestPeer, n)
+	for i := range peers {
+		p := &httpTestPeer{}
+		p.srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			p.pool.ServeHTTP(rw, req)
+		}))
+		t.Cleanup(p.srv.Close)
+
+		p.name = p.srv.URL
+		p.pool = NewHTTPPoolOpts(p.name, &HTTPPoolOptions{
+			// spread the similar names and keys of the test evenly
+			HashFn: testHash,
+		})
+		p.getter = &ownerGetter{name: p.name}
+		g := p.pool.NewCache("test", 1<<20, p.getter)
+		p.group = core.AssertMustTypeIs[*Group](t, g, "NewCache")
+
+		peers[i] = p
+		names = append(names, p.name)
+	}
+
+	for _, p := range peers {
+		p.pool.Set(names...)
+	}
+	return peers
+}
+
+// httpOwnedKey returns a key with the given prefix owned by the second
+// peer, as seen by the first
+func httpOwnedKey(t *testing.T, peers []*httpTestPeer, prefix string) string {
+	t.Helper()
+
+	for i := range 1000 {
+		key := cache.MustJoinKey(prefix, i)
+		if peer, ok := peers[0].pool.PickPeer(key); ok && peer.Name() == peers[1].name {
+			return key
+		}
+	}
+
+	t.Fatalf("no key owned by %s", peers[1].name)
+	return ""
+}
+
+func runTestHTTPPoolGet(t *testing.T) {
+	peers := newHTTPTestCluster(t, 2)
+	local, remote := peers[0], peers[1]
+	ctx := context.Background()
+	key := httpOwnedKey(t, peers, "k")
+
+	v, err := getString(ctx, local.group, key)
+	core.AssertNoError(t, err, "Get")
+	core.AssertEqual(t, remote.name+"-"+key, v, "loaded by the owner")
+	core.AssertEqual(t, int32(0), local.getter.calls.Load(), "local getter calls")
+	core.AssertEqual(t, int32(1), remote.getter.calls.Load(), "remote getter calls")
+
+	// served by the owner's cache
+	v, err = getString(ctx, local.group, key)
+	core.AssertNoError(t, err, "second Get")
+	core.AssertEqual(t, remote.name+"-"+key, v, "second Get")
+	core.AssertEqual(t, int32(1), remote.getter.calls.Load(), "remote getter calls")
+	core.AssertEqual(t, int64(0), local.group.ExtendedStats(cache.MainCache).PeerErrors, "peer errors")
+}
+
+func runTestHTTPPoolSet(t *testing.T) {
+	peers := newHTTPTestCluster(t, 2)
+	local, remote := peers[0], peers[1]
+	ctx := context.Background()
+	key := httpOwnedKey(t, peers, "k")
+	expire := time.Now().Add(time.Hour).Round(0)
+
+	err := local.group.Set(ctx, key, []byte("stored"), expire, cache.MainCache)
+	core.AssertNoError(t, err, "Set")
+
+	var sink cache.ByteSink
+	err = remote.group.Get(ctx, key, &sink)
+	core.AssertNoError(t, err, "Get on the owner")
+	core.AssertEqual(t, "stored", string(sink.Bytes()), "Get on the owner")
+	core.AssertTrue(t, expire.Equal(sink.Expire()), "expire kept")
+	core.AssertEqual(t, int32(0), remote.getter.calls.Load(), "remote getter calls")
+	core.AssertEqual(t, int64(0), local.group.Stats(cache.HotCache).Items, "no hot copy")
+
+	err = local.group.Set(ctx, key, []byte("hot"), time.Time{}, cache.HotCache)
+	core.AssertNoError(t, err, "Set hot")
+	core.AssertEqual(t, int64(1), local.group.Stats(cache.HotCache).Items, "hot copy")
+}
+
+func runTestHTTPPoolRemove(t *testing.T) {
+	peers := newHTTPTestCluster(t, 2)
+	local, remote := peers[0], peers[1]
+	ctx := context.Background()
+	key := httpOwnedKey(t, peers, "k")
+
+	err := local.group.Set(ctx, key, []byte("stored"), time.Time{}, cache.HotCache)
+	core.AssertMustNoError(t, err, "Set")
+
+	local.group.Remove(ctx, key)
+	core.AssertEqual(t, int64(0), remote.group.Stats(cache.MainCache).Items, "removed from the owner")
+	core.AssertEqual(t, int64(0), local.group.Stats(cache.HotCache).Items, "removed locally")
+
+	v, err := getString(ctx, local.group, key)
+	core.AssertNoError(t, err, "Get after Remove")
+	core.AssertEqual(t, remote.name+"-"+key, v, "reloaded")
+}
+
+func runTestHTTPPoolRemoveUnreachable(t *testing.T) {
+	peers := newHTTPTestCluster(t, 2)
+	local, remote := peers[0], peers[1]
+	ctx := context.Background()
+	key := httpOwnedKey(t, peers, "k")
+
+	err := local.group.Set(ctx, key, []byte("stored"), time.Time{}, cache.HotCache)
+	core.AssertMustNoError(t, err, "Set")
+
+	// the owner failing doesn't keep the local copy
+	remote.srv.Close()
+	local.group.Remove(ctx, key)
+	core.AssertEqual(t, int64(0), local.group.Stats(cache.HotCache).Items, "removed locally")
+}
+
+func runTestHTTPPoolErrors(t *testing.T) {
+	peers := newHTTPTestCluster(t, 2)
+	local, remote := peers[0], peers[1]
+	ctx := context.Background()
+
+	_, err := getString(ctx, local.group, httpOwnedKey(t, peers, "missing"))
+	core.AssertErrorIs(t, err, cache.ErrNotFound, "not found")
+
+	_, err = getString(ctx, local.group, httpOwnedKey(t, peers, "broken"))
+	core.AssertErrorIs(t, err, ErrRemoteCall, "failed load")
+
+	// remote failures aren't retried locally
+	core.AssertEqual(t, int32(0), local.getter.calls.Load(), "local getter calls")
+	core.AssertEqual(t, int32(2), remote.getter.calls.Load(), "remote getter calls")
+	core.AssertEqual(t, int64(0), local.group.ExtendedStats(cache.MainCache).PeerErrors, "peer errors")
+
+	// unknown groups
+	peer, ok := local.pool.PickPeer(httpOwnedKey(t, peers, "k"))
+	core.AssertMustTrue(t, ok, "PickPeer")
+	_, _, err = peer.Get(ctx, "other", "k")
+	core.AssertErrorIs(t, err, cache.ErrNotFound, "unknown group")
+}
+
+func runTestHTTPPoolBadRequests(t *testing.T) {
+	peers := newHTTPTestCluster(t, 1)
+	pool := peers[0].pool
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		body   io.Reader
+		status int
+	}{
+		{"bad path", http.MethodGet, "/other/test/k", nil, http.StatusBadRequest},
+		{"bad body", http.MethodPut, DefaultBasePath + "test/k",
+			strings.NewReader("\xff\xff"), http.StatusBadRequest},
+		{"large body", http.MethodPut, DefaultBasePath + "test/k",
+			bytes.NewReader(make([]byte, maxSetBody+1)), http.StatusRequestEntityTooLarge},
+	} {
+		rec := httptest.NewRecorder()
+		pool.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, tc.body))
+		core.AssertEqual(t, tc.status, rec.Code, tc.name)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		err    error
+	}{
+		{http.StatusNotFound, cache.ErrNotFound},
+		{http.StatusServiceUnavailable, ErrRemoteCall},
+		{http.StatusInternalServerError, nil},
+	} {
+		rec := httptest.NewRecorder()
+		http.Error(rec, "failed", tc.status)
+
+		err := responseError(rec.Result())
+		core.AssertMustNotNil(t, err, "%v", tc.status)
+		core.AssertTrue(t, strings.Contains(err.Error(), "failed"), "%v message", tc.status)
+		if tc.err != nil {
+			core.AssertErrorIs(t, err, tc.err, "%v", tc.status)
+		} else {
+			core.AssertTrue(t, isTransportError(context.Background(), err), "%v transport error", tc.status)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	_ PeerPicker = NoPeers{}
+)
+
+// A Peer is another member of the pool, owning part of the keys
+type Peer interface {
+	// Name identifies the peer, usually by its base URL
+	Name() string
+	// Get asks the peer for the value of a key, loading it if needed
+	Get(ctx context.Context, group, key string) ([]byte, time.Time, error)
+	// Set stores a value on the peer
+	Set(ctx context.Context, group, key string, value []byte, expire time.Time) error
+	// Remove evicts a key from the peer
+	Remove(ctx context.Context, group, key string) error
+}
+
+// A PeerPicker finds the owner of a key
+type PeerPicker interface {
+	// PickPeer returns the owner of a key, or false if it's ourselves
+	PickPeer(key string) (Peer, bool)
+	// GetAll returns all the other peers
+	GetAll() []Peer
+}
+
+// NoPeers is a [PeerPicker] that never finds a peer
+type NoPeers struct{}
+
+// PickPeer always returns false, all keys are ours
+func (NoPeers) PickPeer(string) (Peer, bool) { return nil, false }
+
+// GetAll returns no peers
+func (NoPeers) GetAll() []Peer { return nil }
@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Store[string] = (*Pool)(nil)
+	_ cache.Lister        = (*Pool)(nil)
+)
+
+// Pool is a [cache.Store] whose [Group]s are distributed among peers
+// chosen by a [PeerPicker]. Unlike mailgun's groupcache, each Pool has
+// its own registry of groups.
+type Pool struct {
+	mu     sync.Mutex
+	picker PeerPicker
+	groups map[string]*Group
+	log    slog.Logger
+}
+
+// NewPool creates a [Pool] using the given [PeerPicker], or [NoPeers]
+// if nil
+func NewPool(picker PeerPicker) *Pool {
+	if picker == nil {
+		picker = NoPeers{}
+	}
+
+	return &Pool{
+		picker: picker,
+		groups: make(map[string]*Group),
+	}
+}
+
+// NewNoPeersPool creates a [Pool] without peers
+func NewNoPeersPool() *Pool {
+	return NewPool(nil)
+}
+
+// NewCache creates a new [Group] on the [Pool]. All peers need to
+// create the same groups.
+func (p *Pool) NewCache(name string, cacheBytes int64, getter cache.Getter[string]) cache.Cache[string] {
+	if name == "" || getter == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.groups[name]; ok {
+		core.Panicf("%s: %s", name, "cache already registered")
+	}
+
+	g := newGroup(p, name, cacheBytes, getter)
+	p.groups[name] = g
+
+	if log, ok := p.withDebug(); ok {
+		log.Printf("cache:%q created", name)
+	}
+	return g
+}
+
+// GetCache finds a [Group] by name in the [Pool]
+func (p *Pool) GetCache(name string) cache.Cache[string] {
+	if g := p.getGroup(name); g != nil {
+		return g
+	}
+	return nil
+}
+
+// CacheNames returns the names of the [Group]s of the [Pool], sorted
+func (p *Pool) CacheNames() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(p.groups))
+}
+
+func (p *Pool) getGroup(name string) *Group {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.groups[name]
+}
+
+// DeregisterCache removes a [Group] from the [Pool]
+func (p *Pool) DeregisterCache(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.groups, name)
+}
+
+func (p *Pool) pickPeer(key string) (Peer, bool) {
+	return p.picker.PickPeer(key)
+}
+
+func (p *Pool) peers() []Peer {
+	return p.picker.GetAll()
+}
+
+// SetLogger attaches a [slog.Logger] to the [Pool]
+func (p *Pool) SetLogger(log slog.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.log = log
+}
+
+func (p *Pool) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	if p.log != nil {
+		return p.log.WithLevel(level).WithEnabled()
+	}
+	return nil, false
+}
+
+func (p *Pool) withDebug() (slog.Logger, bool) {
+	return p.withLogger(slog.Debug)
+}
+
+func (p *Pool) logPeerError(peer Peer, group, key string, err error) {
+	p.mu.Lock()
+	log, ok := p.withLogger(slog.Warn)
+	p.mu.Unlock()
+
+	if ok {
+		log.WithField("peer", peer.Name()).
+			WithField("cache", group).
+			WithField("key", key).
+			WithField(slog.ErrorFieldName, err).
+			Print("peer request failed")
+	}
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"sync"
+
+	"github.com/mailgun/groupcache/v2/consistenthash"
+)
+
+// ring distributes keys among named peers using consistent hashing,
+// as mailgun's groupcache
+type ring struct {
+	self     string
+	replicas int
+	hashFn   consistenthash.Hash
+
+	mu    sync.RWMutex
+	hash  *consistenthash.Map
+	peers map[string]Peer
+}
+
+func newRing(self string, replicas int, hashFn consistenthash.Hash) *ring {
+	if replicas == 0 {
+		replicas = DefaultReplicas
+	}
+
+	return &ring{
+		self:     self,
+		replicas: replicas,
+		hashFn:   hashFn,
+		hash:     consistenthash.New(replicas, hashFn),
+		peers:    make(map[string]Peer),
+	}
+}
+
+// set replaces the list of peers, including ourselves, reusing the
+// existing [Peer]s by name or calling newPeer for the new ones. It
+// returns the [Peer]s no longer present.
+func (r *ring) set(names []string, newPeer func(name string) Peer) []Peer {
+	hash := consistenthash.New(r.replicas, r.hashFn)
+	hash.Add(names...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := make(map[string]Peer, len(names))
+	for _, name := range names {
+		if peer, ok := r.peers[name]; ok {
+			m[name] = peer
+		} else {
+			m[name] = newPeer(name)
+		}
+	}
+
+	var removed []Peer
+	for name, peer := range r.peers {
+		if _, ok := m[name]; !ok {
+			removed = append(removed, peer)
+		}
+	}
+
+	r.hash, r.peers = hash, m
+	return removed
+}
+
+// PickPeer returns the owner of a key, or false if it's ourselves
+func (r *ring) PickPeer(key string) (Peer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.hash.IsEmpty() {
+		return nil, false
+	}
+
+	if name := r.hash.Get(key); name != r.self {
+		return r.peers[name], true
+	}
+	return nil, false
+}
+
+// GetAll returns all the other peers
+func (r *ring) GetAll() []Peer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Peer, 0, len(r.peers))
+	for name, peer := range r.peers {
+		if name != r.self {
+			out = append(out, peer)
+		}
+	}
+	return out
+}
+
+// get finds a peer by name
+func (r *ring) get(name string) (Peer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	peer, ok := r.peers[name]
+	return peer, ok
+}
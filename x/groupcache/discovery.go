@@ -0,0 +1,192 @@
+package groupcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ Discovery   = StaticPeers(nil)
+	_ Discovery   = (*FileDiscovery)(nil)
+	_ Discovery   = (*SRVDiscovery)(nil)
+	_ SRVResolver = (*net.Resolver)(nil)
+)
+
+// DefaultDiscoveryInterval is the period used by the polling
+// discovery providers when none is specified.
+const DefaultDiscoveryInterval = 30 * time.Second
+
+// Discovery provides the list of peers of an [HTTPPool].
+type Discovery interface {
+	// Watch calls fn with the full list of peers, initially and
+	// whenever it changes, until the context is cancelled.
+	Watch(ctx context.Context, fn func(peers []string)) error
+}
+
+// Discover keeps the peers of the [HTTPPool] updated using the given
+// [Discovery] provider, until the context is cancelled.
+func (p *HTTPPool) Discover(ctx context.Context, d Discovery) error {
+	if p == nil {
+		return core.ErrNilReceiver
+	} else if d == nil {
+		return core.Wrap(core.ErrInvalid, "missing discovery provider")
+	}
+
+	return d.Watch(ctx, func(peers []string) {
+		p.SetPeers(peers...)
+	})
+}
+
+// StaticPeers is a [Discovery] provider with a fixed list of peers.
+type StaticPeers []string
+
+// Watch calls fn once and waits for the context to be cancelled
+func (s StaticPeers) Watch(ctx context.Context, fn func([]string)) error {
+	fn(slices.Clone(s))
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// FileDiscovery is a [Discovery] provider that polls a text file
+// containing one peer per line. Empty lines and those starting
+// with '#' are ignored.
+type FileDiscovery struct {
+	Path     string
+	Interval time.Duration
+}
+
+// Watch reads the file periodically and calls fn when its content
+// changes. Failing to read the file initially is fatal, later errors
+// keep the previous list of peers.
+func (fd *FileDiscovery) Watch(ctx context.Context, fn func([]string)) error {
+	if fd == nil || fd.Path == "" {
+		return core.Wrap(core.ErrInvalid, "missing file path")
+	}
+
+	prev, err := os.ReadFile(fd.Path)
+	if err != nil {
+		return err
+	}
+	fn(parsePeerList(prev))
+
+	return poll(ctx, fd.Interval, func() {
+		b, err := os.ReadFile(fd.Path)
+		if err == nil && !bytes.Equal(b, prev) {
+			prev = b
+			fn(parsePeerList(b))
+		}
+	})
+}
+
+func parsePeerList(b []byte) []string {
+	var out []string
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		s := strings.TrimSpace(sc.Text())
+		if s != "" && s[0] != '#' {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SRVResolver is the subset of [net.Resolver] used by [SRVDiscovery].
+type SRVResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// SRVDiscovery is a [Discovery] provider that periodically resolves
+// DNS SRV records into peer URLs of the form scheme://target:port.
+type SRVDiscovery struct {
+	// Service, Proto and Name are passed to LookupSRV. If Service
+	// and Proto are empty, Name is looked up directly.
+	Service string
+	Proto   string
+	Name    string
+
+	// Scheme of the peer URLs, "http" if empty.
+	Scheme string
+	// Interval between lookups, [DefaultDiscoveryInterval] if zero.
+	Interval time.Duration
+	// Resolver to use, [net.DefaultResolver] if nil.
+	Resolver SRVResolver
+}
+
+// Watch resolves the SRV records periodically and calls fn when the
+// list of peers changes. Failing to resolve initially is fatal, later
+// errors keep the previous list of peers.
+func (sd *SRVDiscovery) Watch(ctx context.Context, fn func([]string)) error {
+	if sd == nil || sd.Name == "" {
+		return core.Wrap(core.ErrInvalid, "missing SRV name")
+	}
+
+	prev, err := sd.lookup(ctx)
+	if err != nil {
+		return err
+	}
+	fn(slices.Clone(prev))
+
+	return poll(ctx, sd.Interval, func() {
+		peers, err := sd.lookup(ctx)
+		if err == nil && !slices.Equal(peers, prev) {
+			prev = peers
+			fn(slices.Clone(peers))
+		}
+	})
+}
+
+func (sd *SRVDiscovery) lookup(ctx context.Context) ([]string, error) {
+	var r SRVResolver = net.DefaultResolver
+	if sd.Resolver != nil {
+		r = sd.Resolver
+	}
+
+	scheme := sd.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	_, addrs, err := r.LookupSRV(ctx, sd.Service, sd.Proto, sd.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]string, 0, len(addrs))
+	for _, srv := range addrs {
+		host := strings.TrimSuffix(srv.Target, ".")
+		peers = append(peers, fmt.Sprintf("%s://%s", scheme,
+			net.JoinHostPort(host, fmt.Sprint(srv.Port))))
+	}
+	slices.Sort(peers)
+	return slices.Compact(peers), nil
+}
+
+// poll calls fn periodically until the context is cancelled
+func poll(ctx context.Context, interval time.Duration, fn func()) error {
+	if interval <= 0 {
+		interval = DefaultDiscoveryInterval
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			fn()
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package groupcache
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestDiscovery(t *testing.T) {
+	t.Run("static", runTestDiscoveryStatic)
+	t.Run("file", runTestDiscoveryFile)
+	t.Run("SRV", runTestDiscoverySRV)
+	t.Run("pool", runTestDiscoveryPool)
+}
+
+// testWatch runs a [Discovery] provider in the background, sending
+// every list of peers through the returned channel
+func testWatch(t *testing.T, d Discovery) <-chan []string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	peers := make(chan []string, 16)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- d.Watch(ctx, func(s []string) { peers <- s })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return peers
+}
+
+// nextPeers waits for the next list of peers
+func nextPeers(t *testing.T, peers <-chan []string) []string {
+	t.Helper()
+
+	select {
+	case s := <-peers:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for peers")
+		return nil
+	}
+}
+
+// noPeers checks no list of peers arrives for a while
+func noPeers(t *testing.T, peers <-chan []string, name string) {
+	t.Helper()
+
+	select {
+	case s := <-peers:
+		t.Errorf("%s: unexpected peers %v", name, s)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func runTestDiscoveryStatic(t *testing.T) {
+	static := StaticPeers{"http://a", "http://b"}
+	peers := testWatch(t, static)
+
+	s := nextPeers(t, peers)
+	core.AssertSliceEqual(t, []string(static), s, "peers")
+
+	s[0] = "http://x"
+	core.AssertEqual(t, "http://a", static[0], "copy")
+}
+
+func runTestDiscoveryFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "peers")
+	// replaced atomically so it's never read half written
+	write := func(s string) {
+		core.AssertMustNoError(t, os.WriteFile(filename+".tmp", []byte(s), 0o600), "WriteFile")
+		core.AssertMustNoError(t, os.Rename(filename+".tmp", filename), "Rename")
+	}
+
+	write("# peers\nhttp://a\n\n  http://b  \n")
+	peers := testWatch(t, &FileDiscovery{Path: filename, Interval: 10 * time.Millisecond})
+	core.AssertSliceEqual(t, []string{"http://a", "http://b"}, nextPeers(t, peers), "initial")
+
+	write("http://c\n")
+	core.AssertSliceEqual(t, []string{"http://c"}, nextPeers(t, peers), "changed")
+
+	// unreadable files keep the previous list
+	core.AssertMustNoError(t, os.Remove(filename), "Remove")
+	noPeers(t, peers, "removed")
+
+	write("http://c\nhttp://d\n")
+	core.AssertSliceEqual(t, []string{"http://c", "http://d"}, nextPeers(t, peers), "restored")
+
+	// fatal errors
+	ctx := context.Background()
+	err := (&FileDiscovery{}).Watch(ctx, func([]string) {})
+	core.AssertErrorIs(t, err, core.ErrInvalid, "missing path")
+
+	err = (&FileDiscovery{Path: filename + ".missing"}).Watch(ctx, func([]string) {})
+	core.AssertErrorIs(t, err, os.ErrNotExist, "missing file")
+}
+
+// fakeResolver answers SRV lookups with a configurable list of
+// records or error
+type fakeResolver struct {
+	mu    sync.Mutex
+	addrs []*net.SRV
+	err   error
+	names []string
+}
+
+func (r *fakeResolver) Set(err error, addrs ...*net.SRV) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.addrs, r.err = addrs, err
+}
+
+func (r *fakeResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.names = append(r.names, service+"/"+proto+"/"+name)
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	return name, r.addrs, nil
+}
+
+func runTestDiscoverySRV(t *testing.T) {
+	r := new(fakeResolver)
+	r.Set(nil,
+		&net.SRV{Target: "b.example.org.", Port: 8080},
+		&net.SRV{Target: "a.example.org.", Port: 8080},
+		&net.SRV{Target: "b.example.org.", Port: 8080})
+
+	peers := testWatch(t, &SRVDiscovery{
+		Service:  "groupcache",
+		Proto:    "tcp",
+		Name:     "example.org",
+		Scheme:   "https",
+		Interval: 10 * time.Millisecond,
+		Resolver: r,
+	})
+	core.AssertSliceEqual(t, []string{
+		"https://a.example.org:8080",
+		"https://b.example.org:8080",
+	}, nextPeers(t, peers), "initial")
+
+	// failed lookups keep the previous list
+	r.Set(core.ErrUnknown)
+	noPeers(t, peers, "failed lookup")
+
+	r.Set(nil, &net.SRV{Target: "::1", Port: 80})
+	core.AssertSliceEqual(t, []string{"https://[::1]:80"}, nextPeers(t, peers), "changed")
+
+	r.mu.Lock()
+	core.AssertEqual(t, "groupcache/tcp/example.org", r.names[0], "lookup")
+	r.mu.Unlock()
+
+	// fatal errors
+	ctx := context.Background()
+	err := (&SRVDiscovery{Resolver: r}).Watch(ctx, func([]string) {})
+	core.AssertErrorIs(t, err, core.ErrInvalid, "missing name")
+
+	r.Set(core.ErrNotExists)
+	err = (&SRVDiscovery{Name: "example.org", Resolver: r}).Watch(ctx, func([]string) {})
+	core.AssertErrorIs(t, err, core.ErrNotExists, "failed lookup")
+}
+
+func runTestDiscoveryPool(t *testing.T) {
+	p := newTestPool(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Discover(ctx, StaticPeers{"http://b", "http://a"}) }()
+
+	for i := 0; len(p.Peers()) == 0; i++ {
+		core.AssertMustTrue(t, i < 500, "peers discovered")
+		time.Sleep(10 * time.Millisecond)
+	}
+	core.AssertSliceEqual(t, []string{"http://a", "http://b"}, p.Peers(), "discovered")
+
+	cancel()
+	core.AssertErrorIs(t, <-done, context.Canceled, "Discover")
+
+	core.AssertErrorIs(t, p.Discover(ctx, nil), core.ErrInvalid, "missing provider")
+}
@@ -4,19 +4,30 @@ go 1.24.0
 
 require (
 	darvaza.org/cache v0.5.0
+	darvaza.org/cache/x/memcache v0.2.0
 	darvaza.org/core v0.19.1
 	darvaza.org/slog v0.9.1
 )
 
-require github.com/mailgun/groupcache/v2 v2.6.0
+require (
+	github.com/golang/protobuf v1.5.4
+	github.com/mailgun/groupcache/v2 v2.6.0
+	google.golang.org/grpc v1.70.0
+	google.golang.org/protobuf v1.36.5
+)
 
 require (
-	github.com/golang/protobuf v1.5.4 // indirect
-	github.com/google/go-cmp v0.6.0 // indirect
+	darvaza.org/cache/x/simplelru v0.3.0 // indirect
 	github.com/segmentio/fasthash v1.0.3 // indirect
 	github.com/sirupsen/logrus v1.9.3 // indirect
 	golang.org/x/net v0.50.0 // indirect
 	golang.org/x/sys v0.41.0 // indirect
 	golang.org/x/text v0.34.0 // indirect
-	google.golang.org/protobuf v1.36.5 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241202173237-19429a94021a // indirect
+)
+
+replace (
+	darvaza.org/cache => ../..
+	darvaza.org/cache/x/memcache => ../memcache
+	darvaza.org/cache/x/simplelru => ../simplelru
 )
@@ -1,10 +1,19 @@
 // Package groupcache provides a groupcache backed implementation
 // of darvaza.org/cache
+//
+// mailgun's groupcache is process-global, so only one pool can exist
+// per process. The cluster sub-package provides an instance-scoped
+// and wire compatible alternative.
+//
+// KeyedStore adapts either of them to key types other than string
+// using a cache.KeyCodec.
 package groupcache
 
 import (
 	"context"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mailgun/groupcache/v2"
@@ -19,6 +28,7 @@ var (
 	_ http.Handler        = (*HTTPPool)(nil)
 	_ cache.Store[string] = (*Pool)(nil)
 	_ cache.Cache[string] = (*Group)(nil)
+	_ cache.Resizer       = (*Group)(nil)
 )
 
 // NewPool creates a Store placeholder to be used as entrypoint
@@ -32,13 +42,41 @@ type HTTPPool struct {
 	Pool
 
 	pool *groupcache.HTTPPool
+	sec  *SecurityConfig
+
+	mu    sync.Mutex
+	peers []string
+	log   groupcache.Logger
 }
 
 // ServeHTTP handles the BasePath of the cache. "/_groupcache/" if unspecified.
+// Requests from unauthenticated peers are rejected if the pool was created
+// with a [SecurityConfig].
 func (p *HTTPPool) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if p.sec != nil {
+		if err := p.sec.verify(req); err != nil {
+			p.logRejected(req, err)
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
 	p.pool.ServeHTTP(rw, req)
 }
 
+func (p *HTTPPool) logRejected(req *http.Request, err error) {
+	p.mu.Lock()
+	log := p.log
+	p.mu.Unlock()
+
+	if log != nil {
+		log.Warn().
+			ErrorField("error", err).
+			StringField("remote", req.RemoteAddr).
+			Printf("peer request rejected")
+	}
+}
+
 // NewHTTPPoolOpts initializes an HTTP pool of peers with the given options.
 // Unlike NewHTTPPool, this function does not register the created pool as
 // an HTTP handler.
@@ -46,7 +84,13 @@ func (p *HTTPPool) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 // Due to groupcache's own limitations you have to choose to use either
 // HTTPPool or NoPeersPool. There can be only one.
 func NewHTTPPoolOpts(self string, opts *groupcache.HTTPPoolOptions) *HTTPPool {
-	if pool := groupcache.NewHTTPPoolOpts(self, opts); pool != nil {
+	var o groupcache.HTTPPoolOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Transport = observePeers(o.Transport)
+
+	if pool := groupcache.NewHTTPPoolOpts(self, &o); pool != nil {
 		return &HTTPPool{
 			pool: pool,
 		}
@@ -61,6 +105,19 @@ func NewHTTPPool(self string) *HTTPPool {
 	return NewHTTPPoolOpts(self, nil)
 }
 
+// SetLogger attaches a slog.Logger to groupcache, also used to
+// report changes on the list of peers
+func (p *HTTPPool) SetLogger(log slog.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.log = nil
+	if log != nil {
+		p.log = NewLogger(log)
+	}
+	SetLogger(log)
+}
+
 // Pool implements a cache.Store using mailgun's groupcache.
 // Groupcache is global so the Pool object doesn't contain anything
 type Pool struct{}
@@ -78,21 +135,27 @@ func NewNoPeersPool() *Pool {
 
 // NewCache creates a new Group
 func (p *Pool) NewCache(name string, cacheBytes int64, getter cache.Getter[string]) cache.Cache[string] {
+	loads := newGroupLoads(name)
+
 	// wrap
 	fn := func(ctx context.Context, key string, dst groupcache.Sink) error {
 		sink, ok := ctxSink.Get(ctx)
-		if ok {
-			return p.getBridged(ctx, key, getter, sink, dst)
+		if !ok {
+			// loading on behalf of a peer
+			sink = new(cache.ByteSink)
 		}
 
-		// unreachable
-		core.Panicf("groupcache[%q]: %s", name, "where is my sink??")
-		return core.ErrInvalid
+		ctx, done := cache.ObserveLoad(ctx)
+		start := time.Now()
+		err := p.getBridged(ctx, key, getter, sink, dst)
+		loads.RecordLoad(time.Since(start), err)
+		done(err)
+		return err
 	}
 
 	if g := groupcache.NewGroup(name, cacheBytes,
 		groupcache.GetterFunc(fn)); g != nil {
-		return &Group{g}
+		return &Group{g: g, loads: loads}
 	}
 	return nil
 }
@@ -113,7 +176,7 @@ func (*Pool) getBridged(ctx context.Context, key string,
 // GetCache returns a named Group previously created
 func (*Pool) GetCache(name string) cache.Cache[string] {
 	if g := groupcache.GetGroup(name); g != nil {
-		return &Group{g}
+		return &Group{g: g, loads: getGroupLoads(name)}
 	}
 	return nil
 }
@@ -121,6 +184,7 @@ func (*Pool) GetCache(name string) cache.Cache[string] {
 // DeregisterCache removes a Group from the Pool
 func (*Pool) DeregisterCache(name string) {
 	groupcache.DeregisterGroup(name)
+	groupLoads.Delete(name)
 }
 
 // SetLogger attaches a slog.Logger to groupcache
@@ -130,7 +194,8 @@ func (*Pool) SetLogger(log slog.Logger) {
 
 // Group implements cache.Cache around a groupcache.Group
 type Group struct {
-	g *groupcache.Group
+	g     *groupcache.Group
+	loads *cache.LoadStats
 }
 
 // Name returns the name of the Group
@@ -162,6 +227,12 @@ func (g *Group) Get(ctx context.Context, key string, sink cache.Sink) error {
 	sink.Reset()
 	ctx = ctxSink.WithValue(ctx, sink)
 
+	// and a flag to tell peer requests from cache hits
+	peer := new(atomic.Bool)
+	if _, ok := cache.GetObserverFromContext(ctx); ok {
+		ctx = ctxPeer.WithValue(ctx, peer)
+	}
+
 	s := groupcache.ByteViewSink(&b)
 	err := g.g.Get(ctx, key, s)
 	if err != nil {
@@ -174,8 +245,14 @@ func (g *Group) Get(ctx context.Context, key string, sink cache.Sink) error {
 		return nil
 	}
 
-	// it was a cache hit, so we read the encoded data
-	// for the ByteView we created
+	if !peer.Load() {
+		// callers waiting for a load by another are
+		// reported as hits too
+		cache.ObserveHit(ctx)
+	}
+
+	// it was a cache hit or the value came from a peer, so
+	// we read the encoded data for the ByteView we created
 	e := b.Expire()
 	bytes := b.ByteSlice()
 	return sink.SetBytes(bytes, e)
@@ -186,6 +263,16 @@ func (g *Group) Remove(ctx context.Context, key string) {
 	_ = g.g.Remove(ctx, key)
 }
 
+// Resize isn't supported by groupcache
+func (*Group) Resize(context.Context, int64) (int, error) {
+	return 0, cache.ErrNotSupported
+}
+
+// Purge isn't supported by groupcache
+func (*Group) Purge(context.Context) error {
+	return cache.ErrNotSupported
+}
+
 // Stats returns stats about the Group
 func (g *Group) Stats(t cache.Type) cache.Stats {
 	var t1 groupcache.CacheType
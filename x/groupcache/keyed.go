@@ -0,0 +1,164 @@
+package groupcache
+
+import (
+	"context"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Store[[32]byte] = (*KeyedStore[[32]byte])(nil)
+	_ cache.Cache[[32]byte] = (*KeyedGroup[[32]byte])(nil)
+	_ cache.Resizer         = (*KeyedGroup[[32]byte])(nil)
+	_ cache.ExtendedStatser = (*KeyedGroup[[32]byte])(nil)
+)
+
+// KeyedStore is a cache.Store for any key type, on top of a
+// groupcache-backed cache.Store of strings like [Pool], [HTTPPool] or
+// those of the cluster sub-package. Keys are converted using a
+// [cache.KeyCodec], which needs to be the same on all peers.
+type KeyedStore[K comparable] struct {
+	store cache.Store[string]
+	codec cache.KeyCodec[K]
+}
+
+// NewKeyedStore creates a [KeyedStore] using the given string store
+// and [cache.KeyCodec]. It returns nil if either is missing.
+func NewKeyedStore[K comparable](store cache.Store[string], codec cache.KeyCodec[K]) *KeyedStore[K] {
+	if store == nil || codec == nil {
+		return nil
+	}
+
+	return &KeyedStore[K]{
+		store: store,
+		codec: codec,
+	}
+}
+
+// Store returns the underlying string store
+func (s *KeyedStore[K]) Store() cache.Store[string] {
+	return s.store
+}
+
+// NewCache creates a new [KeyedGroup]. The getter receives the keys
+// decoded by the [cache.KeyCodec].
+func (s *KeyedStore[K]) NewCache(name string, cacheBytes int64, getter cache.Getter[K]) cache.Cache[K] {
+	if getter == nil {
+		return nil
+	}
+
+	fn := func(ctx context.Context, key string, dest cache.Sink) error {
+		k, err := s.codec.DecodeKey(key)
+		if err != nil {
+			return err
+		}
+		return getter.Get(ctx, k, dest)
+	}
+
+	c := s.store.NewCache(name, cacheBytes, cache.GetterFunc[string](fn))
+	return s.wrap(c)
+}
+
+// GetCache returns a named [KeyedGroup] previously created
+func (s *KeyedStore[K]) GetCache(name string) cache.Cache[K] {
+	return s.wrap(s.store.GetCache(name))
+}
+
+func (s *KeyedStore[K]) wrap(c cache.Cache[string]) cache.Cache[K] {
+	if c == nil {
+		return nil
+	}
+
+	return &KeyedGroup[K]{
+		c:     c,
+		codec: s.codec,
+	}
+}
+
+// DeregisterCache removes a [KeyedGroup] from the underlying store
+func (s *KeyedStore[K]) DeregisterCache(name string) {
+	s.store.DeregisterCache(name)
+}
+
+// SetLogger attaches a slog.Logger to the underlying store
+func (s *KeyedStore[K]) SetLogger(log slog.Logger) {
+	s.store.SetLogger(log)
+}
+
+// KeyedGroup is a cache.Cache for any key type, on top of a
+// groupcache-backed cache.Cache of strings
+type KeyedGroup[K comparable] struct {
+	c     cache.Cache[string]
+	codec cache.KeyCodec[K]
+}
+
+// Cache returns the underlying string cache
+func (g *KeyedGroup[K]) Cache() cache.Cache[string] {
+	return g.c
+}
+
+// Name returns the name of the Group
+func (g *KeyedGroup[K]) Name() string {
+	return g.c.Name()
+}
+
+// Set adds an entry to the Group
+func (g *KeyedGroup[K]) Set(ctx context.Context, key K, value []byte,
+	expire time.Time, t cache.Type) error {
+	//
+	s, err := g.codec.EncodeKey(key)
+	if err != nil {
+		return err
+	}
+	return g.c.Set(ctx, s, value, expire, t)
+}
+
+// Get reads an entry into a Sink
+func (g *KeyedGroup[K]) Get(ctx context.Context, key K, sink cache.Sink) error {
+	s, err := g.codec.EncodeKey(key)
+	if err != nil {
+		return err
+	}
+	return g.c.Get(ctx, s, sink)
+}
+
+// Remove removes an entry from the Group
+func (g *KeyedGroup[K]) Remove(ctx context.Context, key K) {
+	if s, err := g.codec.EncodeKey(key); err == nil {
+		g.c.Remove(ctx, s)
+	}
+}
+
+// Stats returns stats about the Group
+func (g *KeyedGroup[K]) Stats(t cache.Type) cache.Stats {
+	return g.c.Stats(t)
+}
+
+// ExtendedStats returns extended stats about the Group, if supported by
+// the underlying cache, or only its basic ones otherwise
+func (g *KeyedGroup[K]) ExtendedStats(t cache.Type) cache.ExtendedStats {
+	if es, ok := g.c.(cache.ExtendedStatser); ok {
+		return es.ExtendedStats(t)
+	}
+	return cache.ExtendedStats{Stats: g.c.Stats(t)}
+}
+
+// Resize changes the capacity of the Group, if supported by the
+// underlying cache
+func (g *KeyedGroup[K]) Resize(ctx context.Context, cacheBytes int64) (int, error) {
+	if r, ok := g.c.(cache.Resizer); ok {
+		return r.Resize(ctx, cacheBytes)
+	}
+	return 0, cache.ErrNotSupported
+}
+
+// Purge removes all entries of the Group, if supported by the
+// underlying cache
+func (g *KeyedGroup[K]) Purge(ctx context.Context) error {
+	if r, ok := g.c.(cache.Resizer); ok {
+		return r.Purge(ctx)
+	}
+	return cache.ErrNotSupported
+}
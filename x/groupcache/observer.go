@@ -0,0 +1,62 @@
+package groupcache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+var (
+	ctxPeer = core.NewContextKey[*atomic.Bool]("groupcache.Peer")
+)
+
+// observePeers wraps the Transport of an [HTTPPool] so peer requests
+// made on behalf of a Get call with a [cache.GetObserver] are reported
+// to it
+func observePeers(next func(context.Context) http.RoundTripper) func(context.Context) http.RoundTripper {
+	return func(ctx context.Context) http.RoundTripper {
+		tr := http.DefaultTransport
+		if next != nil {
+			tr = next(ctx)
+		}
+
+		if _, ok := cache.GetObserverFromContext(ctx); ok {
+			tr = &observingTransport{next: tr}
+		}
+		return tr
+	}
+}
+
+// observingTransport reports the Get requests to peers
+type observingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	if flag, ok := ctxPeer.Get(ctx); ok {
+		flag.Store(true)
+	}
+
+	pctx, done := cache.ObservePeer(ctx, req.URL.Host)
+	res, err := t.next.RoundTrip(req.WithContext(pctx))
+	switch {
+	case err != nil:
+		done(err)
+	case res.StatusCode == http.StatusNotFound:
+		done(cache.ErrNotFound)
+	case res.StatusCode != http.StatusOK:
+		done(fmt.Errorf("server returned: %v", res.Status))
+	default:
+		done(nil)
+	}
+	return res, err
+}
@@ -0,0 +1,82 @@
+package groupcache
+
+import (
+	"slices"
+)
+
+// SetPeers replaces the list of peers of the pool, given by their
+// base URLs, and including ourselves.
+func (p *HTTPPool) SetPeers(peers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.setPeers(peers)
+}
+
+// AddPeer adds peers to the pool, ignoring those already present
+func (p *HTTPPool) AddPeer(peers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.setPeers(append(slices.Clone(p.peers), peers...))
+}
+
+// RemovePeer removes peers from the pool, ignoring those not present
+func (p *HTTPPool) RemovePeer(peers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.setPeers(slices.DeleteFunc(slices.Clone(p.peers), func(s string) bool {
+		return slices.Contains(peers, s)
+	}))
+}
+
+// Peers returns the current list of peers of the pool
+func (p *HTTPPool) Peers() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.peers)
+}
+
+// setPeers applies a new list of peers if it changed, and logs
+// the difference. Must hold the lock.
+func (p *HTTPPool) setPeers(peers []string) {
+	peers = slices.DeleteFunc(slices.Clone(peers), func(s string) bool {
+		return s == ""
+	})
+	slices.Sort(peers)
+	peers = slices.Compact(peers)
+
+	if slices.Equal(peers, p.peers) {
+		return
+	}
+
+	added, removed := diffPeers(p.peers, peers)
+	p.peers = peers
+	p.pool.Set(peers...)
+
+	if p.log != nil {
+		p.log.Info().WithFields(map[string]any{
+			"added":   added,
+			"removed": removed,
+			"peers":   len(peers),
+		}).Printf("peers changed")
+	}
+}
+
+// diffPeers compares two sorted lists of peers
+func diffPeers(before, after []string) (added, removed []string) {
+	for _, s := range after {
+		if _, found := slices.BinarySearch(before, s); !found {
+			added = append(added, s)
+		}
+	}
+
+	for _, s := range before {
+		if _, found := slices.BinarySearch(after, s); !found {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,85 @@
+package groupcache
+
+import (
+	"sync"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+var (
+	testPoolOnce sync.Once
+	testPoolInst *HTTPPool
+)
+
+// newTestPool returns the [HTTPPool] of the tests without peers, as
+// mailgun's groupcache only allows one per process
+func newTestPool(t *testing.T) *HTTPPool {
+	t.Helper()
+
+	testPoolOnce.Do(func() {
+		testPoolInst = NewHTTPPoolOpts("http://self", nil)
+	})
+	core.AssertMustNotNil(t, testPoolInst, "NewHTTPPoolOpts")
+
+	testPoolInst.SetPeers()
+	return testPoolInst
+}
+
+func TestHTTPPoolPeers(t *testing.T) {
+	t.Run("set", runTestHTTPPoolPeersSet)
+	t.Run("add", runTestHTTPPoolPeersAdd)
+	t.Run("remove", runTestHTTPPoolPeersRemove)
+}
+
+func runTestHTTPPoolPeersSet(t *testing.T) {
+	p := newTestPool(t)
+
+	p.SetPeers("http://b", "", "http://a", "http://b")
+	core.AssertSliceEqual(t, []string{"http://a", "http://b"}, p.Peers(), "sorted and unique")
+
+	p.SetPeers("http://c")
+	core.AssertSliceEqual(t, []string{"http://c"}, p.Peers(), "replaced")
+
+	p.SetPeers()
+	core.AssertEqual(t, 0, len(p.Peers()), "cleared")
+}
+
+func runTestHTTPPoolPeersAdd(t *testing.T) {
+	p := newTestPool(t)
+
+	p.AddPeer("http://b")
+	p.AddPeer("http://a", "http://b")
+	core.AssertSliceEqual(t, []string{"http://a", "http://b"}, p.Peers(), "added")
+
+	// the result is a copy
+	peers := p.Peers()
+	peers[0] = "http://x"
+	core.AssertSliceEqual(t, []string{"http://a", "http://b"}, p.Peers(), "copy")
+}
+
+func runTestHTTPPoolPeersRemove(t *testing.T) {
+	p := newTestPool(t)
+
+	p.SetPeers("http://a", "http://b", "http://c")
+	p.RemovePeer("http://b", "http://unknown")
+	core.AssertSliceEqual(t, []string{"http://a", "http://c"}, p.Peers(), "removed")
+
+	p.RemovePeer("http://a", "http://c")
+	core.AssertEqual(t, 0, len(p.Peers()), "all removed")
+}
+
+func TestDiffPeers(t *testing.T) {
+	added, removed := diffPeers(
+		[]string{"http://a", "http://b", "http://c"},
+		[]string{"http://b", "http://c", "http://d", "http://e"})
+	core.AssertSliceEqual(t, []string{"http://d", "http://e"}, added, "added")
+	core.AssertSliceEqual(t, []string{"http://a"}, removed, "removed")
+
+	added, removed = diffPeers(nil, []string{"http://a"})
+	core.AssertSliceEqual(t, []string{"http://a"}, added, "from empty")
+	core.AssertEqual(t, 0, len(removed), "from empty")
+
+	added, removed = diffPeers([]string{"http://a"}, []string{"http://a"})
+	core.AssertEqual(t, 0, len(added)+len(removed), "unchanged")
+}
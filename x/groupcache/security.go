@@ -0,0 +1,312 @@
+package groupcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+)
+
+const (
+	// SignatureHeader is the HTTP header carrying the HMAC signature
+	// of a peer request
+	SignatureHeader = "X-Groupcache-Signature"
+	// SignatureDateHeader is the HTTP header carrying the Unix time
+	// in seconds when a peer request was signed
+	SignatureDateHeader = "X-Groupcache-Date"
+	// SignatureNonceHeader is the HTTP header carrying the random
+	// value making each signed peer request unique
+	SignatureNonceHeader = "X-Groupcache-Nonce"
+
+	// DefaultMaxSkew is the maximum age of a signed request when
+	// none is specified
+	DefaultMaxSkew = 5 * time.Minute
+
+	// minHMACKeySize is the shortest HMAC key accepted
+	minHMACKeySize = 16
+	// nonceSize is the number of random bytes of a nonce
+	nonceSize = 16
+	// maxSignedBody limits how much of a request is read to verify
+	// its signature
+	maxSignedBody = 64 << 20
+)
+
+var (
+	errMissingSignature = core.Wrap(core.ErrInvalid, "missing signature")
+	errBadSignature     = core.Wrap(core.ErrInvalid, "bad signature")
+	errExpiredSignature = core.Wrap(core.ErrInvalid, "expired signature")
+	errReplayedRequest  = core.Wrap(core.ErrInvalid, "replayed request")
+	errBodyTooLarge     = core.Wrap(core.ErrInvalid, "request body too large")
+	errNoClientCert     = core.Wrap(core.ErrInvalid, "missing client certificate")
+)
+
+// SecurityConfig describes how the peers of an [HTTPPool] connect to
+// and authenticate each other. Peers using TLS need https:// URLs, and
+// the server side is configured on the [http.Server] serving the pool.
+type SecurityConfig struct {
+	// TLSConfig is used to connect to HTTPS peers, and it may carry
+	// a client certificate for mutual TLS. Ignored if a Transport
+	// is given.
+	TLSConfig *tls.Config
+
+	// Transport is the [http.RoundTripper] used to talk to peers.
+	// If nil, one is created using TLSConfig.
+	Transport http.RoundTripper
+
+	// VerifyPeer, if set, requires incoming requests to present a
+	// client certificate verified by the [http.Server], and decides
+	// if the leaf is allowed to talk to us.
+	VerifyPeer func(cert *x509.Certificate) error
+
+	// HMACKey, if set, is used to sign outgoing requests with
+	// HMAC-SHA256 and to reject incoming requests not signed with it.
+	HMACKey []byte
+
+	// MaxSkew is the maximum difference between the time a request
+	// was signed and the time it's verified, [DefaultMaxSkew] if zero.
+	// The nonces of the requests accepted are remembered for that
+	// long, so a signed request can't be replayed.
+	MaxSkew time.Duration
+
+	nonces *nonceCache
+}
+
+// SetDefaults fills the gaps in the [SecurityConfig] and validates it
+func (sc *SecurityConfig) SetDefaults() error {
+	if sc == nil {
+		return core.ErrNilReceiver
+	}
+
+	if sc.HMACKey != nil && len(sc.HMACKey) < minHMACKeySize {
+		return core.Wrap(core.ErrInvalid, "HMAC key too short")
+	}
+
+	if sc.MaxSkew <= 0 {
+		sc.MaxSkew = DefaultMaxSkew
+	}
+
+	if sc.HMACKey != nil && sc.nonces == nil {
+		sc.nonces = new(nonceCache)
+	}
+
+	if sc.Transport == nil && sc.TLSConfig != nil {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = sc.TLSConfig.Clone()
+		sc.Transport = tr
+	}
+	return nil
+}
+
+// NewSecureHTTPPool initialises an HTTP pool of peers like
+// [NewHTTPPoolOpts], talking to and authenticating its peers as
+// described by the [SecurityConfig]. A Transport set on the options
+// is replaced.
+func NewSecureHTTPPool(self string, opts *HTTPPoolOptions, sc *SecurityConfig) (*HTTPPool, error) {
+	if sc == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing security config")
+	}
+
+	sec := *sc
+	if err := sec.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	var o HTTPPoolOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Transport = sec.transport
+
+	p := NewHTTPPoolOpts(self, &o)
+	if p == nil {
+		return nil, core.Wrap(core.ErrInvalid, "failed to create pool")
+	}
+
+	p.sec = &sec
+	return p, nil
+}
+
+// transport returns the [http.RoundTripper] used to talk to peers
+func (sc *SecurityConfig) transport(context.Context) http.RoundTripper {
+	tr := sc.Transport
+	if tr == nil {
+		tr = http.DefaultTransport
+	}
+
+	if sc.HMACKey != nil {
+		return &signingTransport{key: sc.HMACKey, next: tr}
+	}
+	return tr
+}
+
+// verify checks an incoming peer request
+func (sc *SecurityConfig) verify(req *http.Request) error {
+	if sc.VerifyPeer != nil {
+		if err := sc.verifyCert(req); err != nil {
+			return err
+		}
+	}
+
+	if sc.HMACKey != nil {
+		return sc.verifySignature(req)
+	}
+	return nil
+}
+
+func (sc *SecurityConfig) verifyCert(req *http.Request) error {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+		return errNoClientCert
+	}
+	return sc.VerifyPeer(req.TLS.VerifiedChains[0][0])
+}
+
+func (sc *SecurityConfig) verifySignature(req *http.Request) error {
+	sig, err := hex.DecodeString(req.Header.Get(SignatureHeader))
+	if err != nil || len(sig) == 0 {
+		return errMissingSignature
+	}
+
+	date := req.Header.Get(SignatureDateHeader)
+	ts, err := strconv.ParseInt(date, 10, 64)
+	if err != nil {
+		return errMissingSignature
+	}
+
+	nonce := req.Header.Get(SignatureNonceHeader)
+	if len(nonce) != 2*nonceSize {
+		return errMissingSignature
+	}
+
+	signed := time.Unix(ts, 0)
+	if skew := time.Since(signed).Abs(); skew > sc.MaxSkew {
+		return errExpiredSignature
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	mac := signature(sc.HMACKey, req.Method, req.URL.EscapedPath(), date, nonce, body)
+	if !hmac.Equal(sig, mac) {
+		return errBadSignature
+	}
+
+	// only authenticated nonces are remembered
+	if !sc.nonces.add(nonce, signed.Add(sc.MaxSkew)) {
+		return errReplayedRequest
+	}
+	return nil
+}
+
+// nonceCache remembers the nonces of the signed requests accepted
+// until they would be rejected as expired anyway
+type nonceCache struct {
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	pruned time.Time
+}
+
+// add records a nonce valid until the given time, and tells if it
+// wasn't seen before
+func (c *nonceCache) add(nonce string, until time.Time) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen == nil {
+		c.seen = make(map[string]time.Time)
+	}
+
+	if ex, ok := c.seen[nonce]; ok && now.Before(ex) {
+		return false
+	}
+
+	// drop the expired nonces at most once a second
+	if now.Sub(c.pruned) >= time.Second {
+		for k, ex := range c.seen {
+			if !now.Before(ex) {
+				delete(c.seen, k)
+			}
+		}
+		c.pruned = now
+	}
+
+	c.seen[nonce] = until
+	return true
+}
+
+// readBody reads the body of a request, and puts it back
+// so it can be read again. Bodies larger than maxSignedBody
+// are refused.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxSignedBody+1))
+	_ = req.Body.Close()
+	switch {
+	case err != nil:
+		return nil, err
+	case len(body) > maxSignedBody:
+		return nil, errBodyTooLarge
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// signature computes the HMAC-SHA256 of a request
+func signature(key []byte, method, path, date, nonce string, body []byte) []byte {
+	sum := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, key)
+	_, _ = io.WriteString(mac, strings.Join([]string{
+		method, path, date, nonce, hex.EncodeToString(sum[:]),
+	}, "\n"))
+	return mac.Sum(nil)
+}
+
+// signingTransport signs outgoing peer requests
+type signingTransport struct {
+	key  []byte
+	next http.RoundTripper
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the given request
+	req = req.Clone(req.Context())
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var b [nonceSize]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+
+	date := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := hex.EncodeToString(b[:])
+	sig := signature(t.key, req.Method, req.URL.EscapedPath(), date, nonce, body)
+
+	req.Header.Set(SignatureDateHeader, date)
+	req.Header.Set(SignatureNonceHeader, nonce)
+	req.Header.Set(SignatureHeader, hex.EncodeToString(sig))
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,308 @@
+package groupcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestSecurityConfig(t *testing.T) {
+	t.Run("mTLS", runTestSecurityConfigMTLS)
+	t.Run("HMAC", runTestSecurityConfigHMAC)
+	t.Run("expired HMAC", runTestSecurityConfigExpiredHMAC)
+	t.Run("replay", runTestSecurityConfigReplay)
+	t.Run("body too large", runTestSecurityConfigBodyTooLarge)
+	t.Run("short key", runTestSecurityConfigShortKey)
+}
+
+// testCA issues certificates for tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	core.AssertMustNoError(t, err, "GenerateKey")
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	core.AssertMustNoError(t, err, "CreateCertificate")
+	cert, err := x509.ParseCertificate(der)
+	core.AssertMustNoError(t, err, "ParseCertificate")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// Issue creates a certificate for servers on 127.0.0.1 and clients
+func (ca *testCA) Issue(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	core.AssertMustNoError(t, err, "GenerateKey")
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth,
+		},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	core.AssertMustNoError(t, err, "CreateCertificate")
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newTestServer starts a server verifying requests using the given
+// [SecurityConfig], and echoing their bodies back
+func newTestServer(t *testing.T, sc *SecurityConfig, tlsConfig *tls.Config) *httptest.Server {
+	t.Helper()
+
+	core.AssertMustNoError(t, sc.SetDefaults(), "SetDefaults")
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if err := sc.verify(req); err != nil {
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.Copy(rw, req.Body)
+	}))
+
+	if tlsConfig != nil {
+		srv.TLS = tlsConfig
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// do sends a request using the [SecurityConfig] of a client, and
+// returns the status code and body of the response
+func do(t *testing.T, sc *SecurityConfig, method, url, body string) (int, string) {
+	t.Helper()
+
+	core.AssertMustNoError(t, sc.SetDefaults(), "SetDefaults")
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	core.AssertMustNoError(t, err, "NewRequest")
+
+	return send(t, sc.transport(context.Background()), req)
+}
+
+func send(t *testing.T, tr http.RoundTripper, req *http.Request) (int, string) {
+	t.Helper()
+
+	res, err := (&http.Client{Transport: tr}).Do(req)
+	if err != nil {
+		// refused during the TLS handshake
+		return 0, err.Error()
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	core.AssertMustNoError(t, err, "ReadAll")
+	return res.StatusCode, strings.TrimSpace(string(b))
+}
+
+func runTestSecurityConfigMTLS(t *testing.T) {
+	ca := newTestCA(t)
+	server := ca.Issue(t, "server")
+
+	srv := newTestServer(t, &SecurityConfig{
+		VerifyPeer: func(cert *x509.Certificate) error {
+			if cert.Subject.CommonName != "peer" {
+				return core.Wrap(core.ErrInvalid, "unknown peer")
+			}
+			return nil
+		},
+	}, &tls.Config{
+		Certificates: []tls.Certificate{server},
+		ClientCAs:    ca.pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	client := func(certs ...tls.Certificate) *SecurityConfig {
+		return &SecurityConfig{
+			TLSConfig: &tls.Config{
+				RootCAs:      ca.pool,
+				Certificates: certs,
+				MinVersion:   tls.VersionTLS12,
+			},
+		}
+	}
+
+	code, body := do(t, client(ca.Issue(t, "peer")), http.MethodPost, srv.URL+"/x", "hello")
+	core.AssertEqual(t, http.StatusOK, code, "accepted peer")
+	core.AssertEqual(t, "hello", body, "accepted peer")
+
+	code, _ = do(t, client(ca.Issue(t, "intruder")), http.MethodGet, srv.URL+"/x", "")
+	core.AssertEqual(t, http.StatusUnauthorized, code, "rejected peer")
+
+	code, body = do(t, client(), http.MethodGet, srv.URL+"/x", "")
+	core.AssertEqual(t, http.StatusUnauthorized, code, "no client certificate")
+	core.AssertContains(t, body, errNoClientCert.Error(), "no client certificate")
+
+	// certificates from another CA fail the handshake
+	code, _ = do(t, client(newTestCA(t).Issue(t, "peer")), http.MethodGet, srv.URL+"/x", "")
+	core.AssertEqual(t, 0, code, "untrusted certificate")
+}
+
+func runTestSecurityConfigHMAC(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	srv := newTestServer(t, &SecurityConfig{HMACKey: key}, nil)
+
+	code, body := do(t, &SecurityConfig{HMACKey: key}, http.MethodPost, srv.URL+"/x", "hello")
+	core.AssertEqual(t, http.StatusOK, code, "signed")
+	core.AssertEqual(t, "hello", body, "body read back")
+
+	code, _ = do(t, &SecurityConfig{HMACKey: []byte("fedcba9876543210")},
+		http.MethodPost, srv.URL+"/x", "hello")
+	core.AssertEqual(t, http.StatusUnauthorized, code, "wrong key")
+
+	code, body = do(t, &SecurityConfig{}, http.MethodGet, srv.URL+"/x", "")
+	core.AssertEqual(t, http.StatusUnauthorized, code, "unsigned")
+	core.AssertContains(t, body, errMissingSignature.Error(), "unsigned")
+
+	// tampered body
+	tr := &signingTransport{key: key, next: tamperingTransport{}}
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/x", strings.NewReader("hello"))
+	core.AssertMustNoError(t, err, "NewRequest")
+	code, body = send(t, tr, req)
+	core.AssertEqual(t, http.StatusUnauthorized, code, "tampered")
+	core.AssertContains(t, body, errBadSignature.Error(), "tampered")
+}
+
+// tamperingTransport replaces the body of requests after they
+// were signed
+type tamperingTransport struct{}
+
+func (tamperingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Body = io.NopCloser(strings.NewReader("bye"))
+	req.ContentLength = 3
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// signedRequest creates a request signed at the given time
+func signedRequest(t *testing.T, key []byte, url string, date time.Time, nonce string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	core.AssertMustNoError(t, err, "NewRequest")
+
+	ts := strconv.FormatInt(date.Unix(), 10)
+	sig := signature(key, req.Method, req.URL.EscapedPath(), ts, nonce, nil)
+
+	req.Header.Set(SignatureDateHeader, ts)
+	req.Header.Set(SignatureNonceHeader, nonce)
+	req.Header.Set(SignatureHeader, hex.EncodeToString(sig))
+	return req
+}
+
+func runTestSecurityConfigExpiredHMAC(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	srv := newTestServer(t, &SecurityConfig{HMACKey: key, MaxSkew: time.Minute}, nil)
+	nonce := strings.Repeat("ab", nonceSize)
+
+	for name, date := range map[string]time.Time{
+		"old":    time.Now().Add(-2 * time.Minute),
+		"future": time.Now().Add(2 * time.Minute),
+	} {
+		code, body := send(t, http.DefaultTransport, signedRequest(t, key, srv.URL+"/x", date, nonce))
+		core.AssertEqual(t, http.StatusUnauthorized, code, name)
+		core.AssertContains(t, body, errExpiredSignature.Error(), name)
+	}
+
+	code, _ := send(t, http.DefaultTransport, signedRequest(t, key, srv.URL+"/x", time.Now(), nonce))
+	core.AssertEqual(t, http.StatusOK, code, "current")
+}
+
+func runTestSecurityConfigReplay(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	srv := newTestServer(t, &SecurityConfig{HMACKey: key}, nil)
+	now := time.Now()
+
+	req := signedRequest(t, key, srv.URL+"/x", now, strings.Repeat("01", nonceSize))
+	code, _ := send(t, http.DefaultTransport, req)
+	core.AssertEqual(t, http.StatusOK, code, "first")
+
+	req = signedRequest(t, key, srv.URL+"/x", now, strings.Repeat("01", nonceSize))
+	code, body := send(t, http.DefaultTransport, req)
+	core.AssertEqual(t, http.StatusUnauthorized, code, "replayed")
+	core.AssertContains(t, body, errReplayedRequest.Error(), "replayed")
+
+	req = signedRequest(t, key, srv.URL+"/x", now, strings.Repeat("02", nonceSize))
+	code, _ = send(t, http.DefaultTransport, req)
+	core.AssertEqual(t, http.StatusOK, code, "another nonce")
+
+	req = signedRequest(t, key, srv.URL+"/x", now, "short")
+	code, body = send(t, http.DefaultTransport, req)
+	core.AssertEqual(t, http.StatusUnauthorized, code, "bad nonce")
+	core.AssertContains(t, body, errMissingSignature.Error(), "bad nonce")
+
+	// signed by the transport
+	sc := &SecurityConfig{HMACKey: key}
+	for i := range 2 {
+		code, _ = do(t, sc, http.MethodGet, srv.URL+"/x", "")
+		core.AssertEqual(t, http.StatusOK, code, "request %d", i)
+	}
+}
+
+func runTestSecurityConfigBodyTooLarge(t *testing.T) {
+	body := bytes.Repeat([]byte{'x'}, maxSignedBody)
+
+	req := httptest.NewRequest(http.MethodPost, "/x", bytes.NewReader(body))
+	b, err := readBody(req)
+	core.AssertNoError(t, err, "at the limit")
+	core.AssertEqual(t, maxSignedBody, len(b), "at the limit")
+
+	req = httptest.NewRequest(http.MethodPost, "/x", io.MultiReader(bytes.NewReader(body),
+		strings.NewReader("x")))
+	_, err = readBody(req)
+	core.AssertErrorIs(t, err, errBodyTooLarge, "over the limit")
+}
+
+func runTestSecurityConfigShortKey(t *testing.T) {
+	sc := &SecurityConfig{HMACKey: []byte("short")}
+	core.AssertErrorIs(t, sc.SetDefaults(), core.ErrInvalid, "short key")
+}
@@ -0,0 +1,43 @@
+package groupcache
+
+import (
+	"sync"
+
+	"darvaza.org/cache"
+)
+
+var (
+	_ cache.ExtendedStatser = (*Group)(nil)
+)
+
+// groupLoads keeps the [cache.LoadStats] of each Group, as groupcache
+// Groups are process-global and our wrappers are created on demand
+var groupLoads sync.Map
+
+func newGroupLoads(name string) *cache.LoadStats {
+	ls := new(cache.LoadStats)
+	groupLoads.Store(name, ls)
+	return ls
+}
+
+func getGroupLoads(name string) *cache.LoadStats {
+	if v, ok := groupLoads.Load(name); ok {
+		return v.(*cache.LoadStats)
+	}
+	// not created by us
+	return new(cache.LoadStats)
+}
+
+// ExtendedStats returns stats about the Group, combining the counters
+// kept by groupcache with the latency of the loads done through
+// the cache.Getter.
+func (g *Group) ExtendedStats(t cache.Type) cache.ExtendedStats {
+	s := g.loads.Extend(g.Stats(t))
+
+	gs := &g.g.Stats
+	s.LocalHits = gs.CacheHits.Get()
+	s.PeerHits = gs.PeerLoads.Get()
+	s.PeerErrors = gs.PeerErrors.Get()
+	s.DedupWaits = max(gs.Loads.Get()-gs.LoadsDeduped.Get(), 0)
+	return s
+}
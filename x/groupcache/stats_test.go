@@ -0,0 +1,158 @@
+package groupcache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestGroupExtendedStats(t *testing.T) {
+	t.Run("load and hit", runTestGroupExtendedStatsLoad)
+	t.Run("dedup wait", runTestGroupExtendedStatsDedup)
+	t.Run("load error", runTestGroupExtendedStatsError)
+	t.Run("get cache", runTestGroupExtendedStatsGetCache)
+}
+
+// blockingGetter returns the key, fails keys containing "missing",
+// and holds the loads until released
+type blockingGetter struct {
+	mu      sync.Mutex
+	calls   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingGetter() *blockingGetter {
+	return &blockingGetter{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *blockingGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.mu.Lock()
+	g.calls++
+	g.mu.Unlock()
+
+	select {
+	case g.started <- struct{}{}:
+	default:
+	}
+	<-g.release
+
+	if strings.Contains(key, "missing") {
+		return cache.ErrNotFound
+	}
+	return dest.SetBytes([]byte(key), time.Time{})
+}
+
+func (g *blockingGetter) Calls() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.calls
+}
+
+// newStatsGroup creates a Group on the test pool, removed at the end
+// of the test
+func newStatsGroup(t *testing.T, getter cache.Getter[string]) *Group {
+	t.Helper()
+
+	p := newTestPool(t)
+	name := cache.MustJoinKey("stats", t.Name())
+	g := core.AssertMustTypeIs[*Group](t, p.NewCache(name, 1<<20, getter), "NewCache")
+	t.Cleanup(func() { p.DeregisterCache(name) })
+	return g
+}
+
+func getString(ctx context.Context, g cache.Getter[string], key string) (string, error) {
+	var sink cache.ByteSink
+	err := g.Get(ctx, key, &sink)
+	return string(sink.Bytes()), err
+}
+
+func runTestGroupExtendedStatsLoad(t *testing.T) {
+	getter := newBlockingGetter()
+	close(getter.release)
+	g := newStatsGroup(t, getter)
+	ctx := context.Background()
+
+	for i := range 2 {
+		v, err := getString(ctx, g, "k1")
+		core.AssertNoError(t, err, "Get %v", i)
+		core.AssertEqual(t, "k1", v, "Get %v", i)
+	}
+
+	es := g.ExtendedStats(cache.MainCache)
+	core.AssertEqual(t, int64(1), es.Loads, "Loads")
+	core.AssertEqual(t, int64(0), es.LoadErrors, "LoadErrors")
+	core.AssertEqual(t, int64(1), es.LocalHits, "LocalHits")
+	core.AssertEqual(t, int64(0), es.DedupWaits, "DedupWaits")
+	core.AssertEqual(t, int64(0), es.PeerHits, "PeerHits")
+	core.AssertEqual(t, int64(1), es.LoadLatency.Count, "LoadLatency")
+}
+
+func runTestGroupExtendedStatsDedup(t *testing.T) {
+	getter := newBlockingGetter()
+	g := newStatsGroup(t, getter)
+	ctx := context.Background()
+
+	done := make(chan error, 2)
+	get := func() {
+		_, err := getString(ctx, g, "k1")
+		done <- err
+	}
+
+	go get()
+	<-getter.started
+	go get()
+
+	// wait for the second Get to join the load in progress
+	for i := 0; g.g.Stats.Loads.Get() < 2; i++ {
+		core.AssertMustTrue(t, i < 500, "second load")
+		time.Sleep(time.Millisecond)
+	}
+
+	close(getter.release)
+	core.AssertNoError(t, <-done, "first Get")
+	core.AssertNoError(t, <-done, "second Get")
+
+	es := g.ExtendedStats(cache.MainCache)
+	core.AssertEqual(t, int64(1), es.Loads, "Loads")
+	core.AssertEqual(t, int64(1), es.DedupWaits, "DedupWaits")
+	core.AssertEqual(t, 1, getter.Calls(), "getter calls")
+}
+
+func runTestGroupExtendedStatsError(t *testing.T) {
+	getter := newBlockingGetter()
+	close(getter.release)
+	g := newStatsGroup(t, getter)
+
+	_, err := getString(context.Background(), g, "missing")
+	core.AssertErrorIs(t, err, cache.ErrNotFound, "Get")
+
+	es := g.ExtendedStats(cache.MainCache)
+	core.AssertEqual(t, int64(1), es.Loads, "Loads")
+	core.AssertEqual(t, int64(1), es.LoadErrors, "LoadErrors")
+	core.AssertEqual(t, int64(0), es.LocalHits, "LocalHits")
+}
+
+func runTestGroupExtendedStatsGetCache(t *testing.T) {
+	getter := newBlockingGetter()
+	close(getter.release)
+	g := newStatsGroup(t, getter)
+
+	_, err := getString(context.Background(), g, "k1")
+	core.AssertMustNoError(t, err, "Get")
+
+	// wrappers of the same Group share the load counters
+	g2 := core.AssertMustTypeIs[*Group](t, newTestPool(t).GetCache(g.Name()), "GetCache")
+	es, es2 := g.ExtendedStats(cache.MainCache), g2.ExtendedStats(cache.MainCache)
+	core.AssertEqual(t, int64(1), es2.Loads, "Loads")
+	core.AssertEqual(t, es.LoadLatency.Count, es2.LoadLatency.Count, "LoadLatency")
+}
@@ -0,0 +1,282 @@
+package memcache
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+const (
+	metricHeapGoal    = "/gc/heap/goal:bytes"
+	metricHeapLive    = "/gc/heap/live:bytes"
+	metricMemoryLimit = "/gc/gomemlimit:bytes"
+)
+
+// Limiter represents a cache whose effective capacity can be
+// restricted below its nominal size
+type Limiter interface {
+	// MaxSize returns the nominal capacity in bytes
+	MaxSize() int64
+	// SetLimit restricts the effective capacity, evicting
+	// entries as needed, and returns the number of evictions
+	SetLimit(bytes int64) int
+}
+
+// AdaptiveConfig tunes a [MemoryController]
+type AdaptiveConfig struct {
+	// Interval between samples when using Run. Defaults to one second.
+	Interval time.Duration
+	// MemoryLimit overrides the limit set via GOMEMLIMIT or
+	// debug.SetMemoryLimit(). If neither is set the caches
+	// are never shrunk.
+	MemoryLimit int64
+	// HighWater is the fraction of the memory limit the heap goal has to
+	// exceed before shrinking the caches. Defaults to 0.9.
+	HighWater float64
+	// LowWater is the fraction of the memory limit the heap goal has to
+	// be below before regrowing the caches. Defaults to 0.7.
+	LowWater float64
+	// Step is the fraction of the nominal capacity removed or restored
+	// on each decision. Defaults to 0.1.
+	Step float64
+	// MinRatio is the smallest fraction of the nominal capacity the
+	// caches will be shrunk to. Defaults to 0.1.
+	MinRatio float64
+}
+
+// SetDefaults fills the gaps and identifies errors.
+func (cfg *AdaptiveConfig) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
+	setDefault(&cfg.Interval, time.Second)
+	setDefault(&cfg.HighWater, 0.9)
+	setDefault(&cfg.LowWater, 0.7)
+	setDefault(&cfg.Step, 0.1)
+	setDefault(&cfg.MinRatio, 0.1)
+
+	switch {
+	case cfg.Interval < 0, cfg.MemoryLimit < 0:
+		return core.Wrap(core.ErrInvalid, "negative interval or memory limit")
+	case cfg.LowWater >= cfg.HighWater:
+		return core.Wrap(core.ErrInvalid, "low water must be below high water")
+	case cfg.Step <= 0, cfg.Step > 1:
+		return core.Wrap(core.ErrInvalid, "step out of range")
+	case cfg.MinRatio <= 0, cfg.MinRatio > 1:
+		return core.Wrap(core.ErrInvalid, "minimum ratio out of range")
+	default:
+		return nil
+	}
+}
+
+func setDefault[T int64 | float64 | time.Duration](p *T, def T) {
+	if *p == 0 {
+		*p = def
+	}
+}
+
+// AdaptiveStats provides a snapshot on the state of a [MemoryController]
+type AdaptiveStats struct {
+	// Ratio is the fraction of the nominal capacity currently allowed
+	Ratio float64
+	// HeapGoal is the last heap size goal reported by the runtime
+	HeapGoal int64
+	// HeapLive is the last live heap size reported by the runtime
+	HeapLive int64
+	// MemoryLimit is the limit the heap goal is compared against
+	MemoryLimit int64
+	// Shrinks counts the decisions to reduce the capacity
+	Shrinks int64
+	// Regrows counts the decisions to restore capacity
+	Regrows int64
+	// Evictions counts the entries removed by shrinking
+	Evictions int64
+}
+
+// MemoryController watches the runtime/metrics heap goal against the
+// memory limit and shrinks or regrows the effective capacity of the
+// registered caches accordingly
+type MemoryController struct {
+	mu      sync.Mutex
+	log     slog.Logger
+	cfg     AdaptiveConfig
+	caches  map[string]Limiter
+	stats   AdaptiveStats
+	samples []metrics.Sample
+}
+
+// NewMemoryController creates a new [MemoryController]. A nil config
+// means all defaults.
+func NewMemoryController(cfg *AdaptiveConfig) (*MemoryController, error) {
+	if cfg == nil {
+		cfg = new(AdaptiveConfig)
+	}
+
+	if err := cfg.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	mc := &MemoryController{
+		cfg:    *cfg,
+		caches: make(map[string]Limiter),
+		stats:  AdaptiveStats{Ratio: 1},
+		samples: []metrics.Sample{
+			{Name: metricHeapGoal},
+			{Name: metricHeapLive},
+			{Name: metricMemoryLimit},
+		},
+	}
+	return mc, nil
+}
+
+// Register adds a cache to be controlled, and applies the
+// current ratio to it
+func (mc *MemoryController) Register(name string, c Limiter) {
+	if c == nil {
+		return
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.caches[name] = c
+	if mc.stats.Ratio < 1 {
+		mc.stats.Evictions += int64(mc.applyRatio(c))
+	}
+}
+
+// Deregister removes a cache from the controller, restoring its
+// nominal capacity
+func (mc *MemoryController) Deregister(name string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if c, ok := mc.caches[name]; ok {
+		delete(mc.caches, name)
+		c.SetLimit(0)
+	}
+}
+
+// SetLogger attaches a [slog.Logger] to the [MemoryController]
+func (mc *MemoryController) SetLogger(log slog.Logger) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.log = log
+}
+
+// Stats returns statistics about the [MemoryController]
+func (mc *MemoryController) Stats() AdaptiveStats {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	return mc.stats
+}
+
+// Run periodically samples the runtime metrics and adjusts the
+// registered caches. It runs until the provided context is cancelled.
+func (mc *MemoryController) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(mc.cfg.Interval):
+			mc.Update()
+		}
+	}
+}
+
+// Update samples the runtime metrics once and shrinks or regrows
+// the registered caches if needed
+func (mc *MemoryController) Update() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	goal, limit := mc.sample()
+	if limit < 1 {
+		// no limit, nothing to do
+		return
+	}
+
+	pressure := float64(goal) / float64(limit)
+	switch {
+	case pressure > mc.cfg.HighWater && mc.stats.Ratio > mc.cfg.MinRatio:
+		mc.stats.Shrinks++
+		mc.setRatio(math.Max(mc.stats.Ratio-mc.cfg.Step, mc.cfg.MinRatio), "shrinking")
+	case pressure < mc.cfg.LowWater && mc.stats.Ratio < 1:
+		mc.stats.Regrows++
+		mc.setRatio(math.Min(mc.stats.Ratio+mc.cfg.Step, 1), "regrowing")
+	}
+}
+
+func (mc *MemoryController) sample() (goal, limit int64) {
+	metrics.Read(mc.samples)
+
+	goal = sampleInt64(mc.samples[0])
+	mc.stats.HeapGoal = goal
+	mc.stats.HeapLive = sampleInt64(mc.samples[1])
+
+	limit = mc.cfg.MemoryLimit
+	if limit == 0 {
+		limit = sampleInt64(mc.samples[2])
+		if limit == math.MaxInt64 {
+			// GOMEMLIMIT not set
+			limit = 0
+		}
+	}
+	mc.stats.MemoryLimit = limit
+
+	return goal, limit
+}
+
+func (mc *MemoryController) setRatio(ratio float64, action string) {
+	var evicted int
+
+	mc.stats.Ratio = ratio
+	for _, c := range mc.caches {
+		evicted += mc.applyRatio(c)
+	}
+	mc.stats.Evictions += int64(evicted)
+
+	if log, ok := mc.withInfo(); ok {
+		log.WithField("ratio", ratio).
+			WithField("heap-goal", mc.stats.HeapGoal).
+			WithField("memory-limit", mc.stats.MemoryLimit).
+			WithField("evicted", evicted).
+			Print(action)
+	}
+}
+
+func (mc *MemoryController) applyRatio(c Limiter) int {
+	if mc.stats.Ratio >= 1 {
+		return c.SetLimit(0)
+	}
+
+	limit := int64(float64(c.MaxSize()) * mc.stats.Ratio)
+	return c.SetLimit(max(limit, 1))
+}
+
+func (mc *MemoryController) withInfo() (slog.Logger, bool) {
+	if mc.log != nil {
+		return mc.log.Info().WithEnabled()
+	}
+	return nil, false
+}
+
+func sampleInt64(s metrics.Sample) int64 {
+	if s.Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	v := s.Value.Uint64()
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
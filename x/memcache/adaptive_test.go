@@ -0,0 +1,140 @@
+package memcache
+
+import (
+	"math"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func TestMemoryController(t *testing.T) {
+	t.Run("shrink and regrow", runTestMemoryControllerShrinkRegrow)
+	t.Run("minimum ratio", runTestMemoryControllerMinRatio)
+	t.Run("register reduced", runTestMemoryControllerRegister)
+	t.Run("deregister", runTestMemoryControllerDeregister)
+	t.Run("cache", runTestMemoryControllerCache)
+}
+
+// fakeLimiter is a [Limiter] remembering the last limit set, which
+// claims to evict one entry whenever the limit is lowered
+type fakeLimiter struct {
+	maxSize int64
+	limit   int64
+}
+
+func (l *fakeLimiter) MaxSize() int64 { return l.maxSize }
+
+func (l *fakeLimiter) SetLimit(bytes int64) int {
+	var n int
+	if bytes > 0 && (l.limit == 0 || bytes < l.limit) {
+		n = 1
+	}
+	l.limit = bytes
+	return n
+}
+
+const (
+	// underPressure is a memory limit any heap goal exceeds
+	underPressure = 1
+	// noPressure is a memory limit no heap goal gets near
+	noPressure = math.MaxInt64 / 2
+)
+
+func newTestController(t *testing.T, minRatio float64) *MemoryController {
+	t.Helper()
+
+	mc, err := NewMemoryController(&AdaptiveConfig{
+		MemoryLimit: underPressure,
+		Step:        0.25,
+		MinRatio:    minRatio,
+	})
+	core.AssertMustNoError(t, err, "NewMemoryController")
+	return mc
+}
+
+func runTestMemoryControllerShrinkRegrow(t *testing.T) {
+	mc := newTestController(t, 0.25)
+	l := &fakeLimiter{maxSize: 1000}
+	mc.Register("test", l)
+	core.AssertEqual(t, int64(0), l.limit, "registered without pressure")
+
+	for _, want := range []int64{750, 500, 250} {
+		mc.Update()
+		core.AssertEqual(t, want, l.limit, "shrinking")
+	}
+
+	mc.cfg.MemoryLimit = noPressure
+	for _, want := range []int64{500, 750, 0} {
+		mc.Update()
+		core.AssertEqual(t, want, l.limit, "regrowing")
+	}
+
+	// no further changes
+	l.limit = -1
+	mc.Update()
+	core.AssertEqual(t, int64(-1), l.limit, "stable")
+
+	stats := mc.Stats()
+	core.AssertEqual(t, 1.0, stats.Ratio, "Ratio")
+	core.AssertEqual(t, int64(3), stats.Shrinks, "Shrinks")
+	core.AssertEqual(t, int64(3), stats.Regrows, "Regrows")
+	core.AssertEqual(t, int64(3), stats.Evictions, "Evictions")
+	core.AssertEqual(t, int64(noPressure), stats.MemoryLimit, "MemoryLimit")
+	core.AssertTrue(t, stats.HeapGoal > 0, "HeapGoal")
+}
+
+func runTestMemoryControllerMinRatio(t *testing.T) {
+	mc := newTestController(t, 0.3)
+	l := &fakeLimiter{maxSize: 1000}
+	mc.Register("test", l)
+
+	for range 5 {
+		mc.Update()
+	}
+
+	stats := mc.Stats()
+	core.AssertEqual(t, 0.3, stats.Ratio, "Ratio")
+	core.AssertEqual(t, int64(3), stats.Shrinks, "Shrinks")
+	core.AssertEqual(t, int64(300), l.limit, "limit")
+}
+
+func runTestMemoryControllerRegister(t *testing.T) {
+	mc := newTestController(t, 0.25)
+	mc.Register("first", &fakeLimiter{maxSize: 1000})
+	mc.Update()
+	mc.Update()
+
+	l := &fakeLimiter{maxSize: 2000}
+	mc.Register("second", l)
+	core.AssertEqual(t, int64(1000), l.limit, "limit on Register")
+	core.AssertEqual(t, int64(3), mc.Stats().Evictions, "Evictions")
+}
+
+func runTestMemoryControllerDeregister(t *testing.T) {
+	mc := newTestController(t, 0.25)
+	l := &fakeLimiter{maxSize: 1000}
+	mc.Register("test", l)
+	mc.Update()
+	core.AssertEqual(t, int64(750), l.limit, "shrunk")
+
+	mc.Deregister("test")
+	core.AssertEqual(t, int64(0), l.limit, "restored")
+
+	l.limit = -1
+	mc.Update()
+	core.AssertEqual(t, int64(-1), l.limit, "no longer controlled")
+}
+
+func runTestMemoryControllerCache(t *testing.T) {
+	mc := newTestController(t, 0.25)
+	s := New[string]()
+	s.SetMemoryController(mc)
+
+	g := core.AssertMustTypeIs[*Cache[string]](t, s.NewCache("test", 4*KiB, notFoundGetter), "NewCache")
+	mc.Update()
+	mc.Update()
+	core.AssertEqual(t, int64(2*KiB), g.lru.Limit(), "shrunk")
+
+	s.DeregisterCache("test")
+	core.AssertEqual(t, int64(4*KiB), g.lru.Limit(), "restored")
+}
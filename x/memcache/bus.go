@@ -0,0 +1,177 @@
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sync"
+
+	"darvaza.org/core"
+)
+
+// EventType identifies the kind of an invalidation [Event].
+// There is no type for the namespace generations of x/generations,
+// as invalidating a namespace removes its generation from the control
+// [Cache], which travels as an [EventRemove].
+type EventType uint8
+
+const (
+	// EventRemove asks to remove a key from a [Cache]
+	EventRemove EventType = iota + 1
+	// EventPurge asks to remove all entries from a [Cache]
+	EventPurge
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventRemove:
+		return "remove"
+	case EventPurge:
+		return "purge"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is an invalidation broadcast to the replicas of a [Store]
+type Event struct {
+	// Origin identifies the [Store] that sent the event
+	Origin string
+	// Seq is the sequence number of the event on its Origin
+	Seq uint64
+	// Type is the kind of invalidation
+	Type EventType
+	// Cache is the name of the [Cache]
+	Cache string
+	// Key is the encoded key, for [EventRemove]
+	Key string
+}
+
+// Bus delivers invalidation [Event]s among the replicas of a [Store].
+// Delivery is best effort, and events may be duplicated or reach
+// their own sender.
+type Bus interface {
+	// Publish sends an event to all subscribers
+	Publish(ctx context.Context, ev Event) error
+	// Subscribe registers a function to be called for each
+	// event received, and returns a function to unsubscribe
+	Subscribe(fn func(Event)) (unsubscribe func())
+}
+
+// eventMagic prefixes encoded events, followed by a version byte
+const (
+	eventMagic   = "DVZB"
+	eventVersion = 1
+
+	// maxEventSize is the largest encoded event that fits
+	// in a datagram
+	maxEventSize = 65507
+)
+
+var (
+	// ErrBadEvent indicates an encoded [Event] is corrupted or
+	// uses an unsupported format
+	ErrBadEvent = core.Wrap(core.ErrInvalid, "bad event")
+
+	errEventTooLarge = core.Wrap(core.ErrInvalid, "event too large")
+)
+
+// MarshalBinary encodes an [Event] for datagram based buses
+func (ev Event) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 32+len(ev.Origin)+len(ev.Cache)+len(ev.Key))
+	b = append(b, eventMagic...)
+	b = append(b, eventVersion, byte(ev.Type))
+	b = binary.AppendUvarint(b, ev.Seq)
+	for _, s := range []string{ev.Origin, ev.Cache, ev.Key} {
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		b = append(b, s...)
+	}
+
+	if len(b) > maxEventSize {
+		return nil, errEventTooLarge
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes an [Event] encoded by MarshalBinary
+func (ev *Event) UnmarshalBinary(b []byte) error {
+	rest, ok := bytes.CutPrefix(b, []byte(eventMagic))
+	if !ok || len(rest) < 2 || rest[0] != eventVersion {
+		return ErrBadEvent
+	}
+
+	r := bytes.NewReader(rest[2:])
+	seq, err := binary.ReadUvarint(r)
+	if err != nil {
+		return ErrBadEvent
+	}
+
+	var fields [3]string
+	for i := range fields {
+		if fields[i], err = readEventString(r); err != nil {
+			return err
+		}
+	}
+
+	*ev = Event{
+		Origin: fields[0],
+		Seq:    seq,
+		Type:   EventType(rest[1]),
+		Cache:  fields[1],
+		Key:    fields[2],
+	}
+	return nil
+}
+
+func readEventString(r *bytes.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(r.Len()) {
+		return "", ErrBadEvent
+	}
+
+	b := make([]byte, n)
+	_, _ = r.Read(b)
+	return string(b), nil
+}
+
+// subscribers implements the subscriptions of a [Bus]
+type subscribers struct {
+	mu   sync.Mutex
+	next int
+	fns  map[int]func(Event)
+}
+
+// Subscribe registers a function to be called for each event
+func (s *subscribers) Subscribe(fn func(Event)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fns == nil {
+		s.fns = make(map[int]func(Event))
+	}
+
+	id := s.next
+	s.next++
+	s.fns[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.fns, id)
+	}
+}
+
+// deliver calls all subscribers with an event
+func (s *subscribers) deliver(ev Event) {
+	s.mu.Lock()
+	fns := make([]func(Event), 0, len(s.fns))
+	for _, fn := range s.fns {
+		fns = append(fns, fn)
+	}
+	s.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(ev)
+	}
+}
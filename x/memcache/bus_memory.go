@@ -0,0 +1,30 @@
+package memcache
+
+import (
+	"context"
+)
+
+var (
+	_ Bus = (*MemoryBus)(nil)
+)
+
+// MemoryBus is an in-process [Bus] delivering events synchronously,
+// useful to connect several [Store]s in tests.
+type MemoryBus struct {
+	subscribers
+}
+
+// NewMemoryBus creates a [MemoryBus]
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+// Publish delivers an event to all subscribers
+func (b *MemoryBus) Publish(ctx context.Context, ev Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.deliver(ev)
+	return nil
+}
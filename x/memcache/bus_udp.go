@@ -0,0 +1,164 @@
+package memcache
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"net"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ Bus = (*UDPBus)(nil)
+)
+
+// minBusKeySize is the shortest HMAC key accepted by [NewSignedUDPBus]
+const minBusKeySize = 16
+
+// UDPBus is a [Bus] using UDP multicast, reaching the replicas on
+// the same network segment. Events are limited to a datagram.
+//
+// Events from [NewUDPBus] are not authenticated, so anyone able to
+// send to the group can invalidate entries. Use [NewSignedUDPBus] on
+// networks that aren't trusted. Signed events can still be replayed,
+// which only causes extra misses.
+type UDPBus struct {
+	subscribers
+
+	group *net.UDPAddr
+	recv  *net.UDPConn
+	send  *net.UDPConn
+	key   []byte
+}
+
+// NewUDPBus joins a multicast group given as "address:port", e.g.
+// "239.255.77.77:7946", on the given network interface or the system
+// default if nil. Events are neither signed nor verified.
+func NewUDPBus(group string, ifi *net.Interface) (*UDPBus, error) {
+	return newUDPBus(group, ifi, nil)
+}
+
+// NewSignedUDPBus is like [NewUDPBus] but signs the events sent with
+// HMAC-SHA256 using the given key, and drops those received without
+// a valid signature.
+func NewSignedUDPBus(group string, ifi *net.Interface, key []byte) (*UDPBus, error) {
+	if len(key) < minBusKeySize {
+		return nil, core.Wrap(core.ErrInvalid, "HMAC key too short")
+	}
+	return newUDPBus(group, ifi, append([]byte(nil), key...))
+}
+
+func newUDPBus(group string, ifi *net.Interface, key []byte) (*UDPBus, error) {
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		return nil, err
+	}
+
+	recv, err := net.ListenMulticastUDP("udp", ifi, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	send, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		_ = recv.Close()
+		return nil, err
+	}
+
+	b := &UDPBus{
+		group: addr,
+		recv:  recv,
+		send:  send,
+		key:   key,
+	}
+
+	go serveEvents(recv, &b.subscribers, b.open)
+	return b, nil
+}
+
+// Publish sends an event to the multicast group
+func (b *UDPBus) Publish(ctx context.Context, ev Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := ev.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	if b.key != nil {
+		data = b.seal(data)
+		if len(data) > maxEventSize {
+			return errEventTooLarge
+		}
+	}
+
+	_, err = b.send.Write(data)
+	return err
+}
+
+// seal appends the signature of an encoded event
+func (b *UDPBus) seal(data []byte) []byte {
+	mac := hmac.New(sha256.New, b.key)
+	_, _ = mac.Write(data)
+	return mac.Sum(data)
+}
+
+// open returns an encoded event without its signature, if valid
+func (b *UDPBus) open(data []byte) ([]byte, bool) {
+	if b.key == nil {
+		return data, true
+	}
+
+	n := len(data) - sha256.Size
+	if n < 0 {
+		return nil, false
+	}
+
+	mac := hmac.New(sha256.New, b.key)
+	_, _ = mac.Write(data[:n])
+	if !hmac.Equal(mac.Sum(nil), data[n:]) {
+		return nil, false
+	}
+	return data[:n], true
+}
+
+// Close leaves the multicast group
+func (b *UDPBus) Close() error {
+	err := b.send.Close()
+	if err2 := b.recv.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// serveEvents reads events from a datagram connection and delivers
+// them until it's closed. Malformed datagrams, and those rejected by
+// open if given, are ignored.
+func serveEvents(pc net.PacketConn, s *subscribers, open func([]byte) ([]byte, bool)) {
+	buf := make([]byte, maxEventSize)
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return
+		}
+
+		data := buf[:n]
+		if open != nil {
+			var ok bool
+			if data, ok = open(data); !ok {
+				continue
+			}
+		}
+
+		var ev Event
+		if ev.UnmarshalBinary(data) == nil {
+			s.deliver(ev)
+		}
+	}
+}
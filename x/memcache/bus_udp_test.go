@@ -0,0 +1,41 @@
+package memcache
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func TestUDPBusSignature(t *testing.T) {
+	_, err := NewSignedUDPBus("239.255.77.77:7946", nil, []byte("short"))
+	core.AssertErrorIs(t, err, core.ErrInvalid, "short key")
+
+	b := &UDPBus{key: []byte("0123456789abcdef")}
+	other := &UDPBus{key: []byte("fedcba9876543210")}
+
+	data, err := Event{Origin: "a", Seq: 1, Type: EventRemove, Cache: "c", Key: "k"}.MarshalBinary()
+	core.AssertMustNoError(t, err, "MarshalBinary")
+	sealed := b.seal(append([]byte(nil), data...))
+
+	opened, ok := b.open(sealed)
+	core.AssertTrue(t, ok, "valid signature")
+	core.AssertSliceEqual(t, data, opened, "opened")
+
+	_, ok = other.open(sealed)
+	core.AssertFalse(t, ok, "wrong key")
+
+	_, ok = b.open(data)
+	core.AssertFalse(t, ok, "unsigned")
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(data)-1] ^= 1
+	_, ok = b.open(tampered)
+	core.AssertFalse(t, ok, "tampered")
+
+	_, ok = b.open(nil)
+	core.AssertFalse(t, ok, "empty")
+
+	opened, ok = (&UDPBus{}).open(data)
+	core.AssertTrue(t, ok, "unsigned bus")
+	core.AssertSliceEqual(t, data, opened, "unsigned bus")
+}
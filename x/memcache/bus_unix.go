@@ -0,0 +1,110 @@
+package memcache
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+var (
+	_ Bus = (*UnixBus)(nil)
+)
+
+// unixBusSuffix is the extension of the sockets of a [UnixBus]
+const unixBusSuffix = ".sock"
+
+// UnixBus is a [Bus] using Unix datagram sockets in a shared
+// directory, reaching the replicas running on the same host.
+// Each member binds a socket named after itself, and events are
+// sent to all the other sockets found in the directory.
+type UnixBus struct {
+	subscribers
+
+	dir  string
+	path string
+	conn *net.UnixConn
+}
+
+// NewUnixBus joins the bus on the given directory as name, which
+// needs to be unique among its members.
+func NewUnixBus(dir, name string) (*UnixBus, error) {
+	if name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return nil, core.Wrap(core.ErrInvalid, "invalid name")
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, name+unixBusSuffix)
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	b := &UnixBus{
+		dir:  dir,
+		path: path,
+		conn: conn,
+	}
+
+	go serveEvents(conn, &b.subscribers, nil)
+	return b, nil
+}
+
+// removeStaleSocket removes a Unix socket left behind by a previous
+// run, refusing to remove anything that isn't a socket
+func removeStaleSocket(filename string) error {
+	fi, err := os.Lstat(filename)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	case fi.Mode()&os.ModeSocket == 0:
+		return core.Wrapf(core.ErrExists, "%s: not a socket", filename)
+	default:
+		return os.Remove(filename)
+	}
+}
+
+// Publish sends an event to all the other members. Members that
+// can't be reached are skipped.
+func (b *UnixBus) Publish(ctx context.Context, ev Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := ev.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	peers, err := filepath.Glob(filepath.Join(b.dir, "*"+unixBusSuffix))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range peers {
+		if path != b.path {
+			addr := &net.UnixAddr{Name: path, Net: "unixgram"}
+			_, _ = b.conn.WriteToUnix(data, addr)
+		}
+	}
+	return nil
+}
+
+// Close leaves the bus, removing our socket
+func (b *UnixBus) Close() error {
+	err := b.conn.Close()
+	_ = os.Remove(b.path)
+	return err
+}
@@ -0,0 +1,32 @@
+package memcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"darvaza.org/core"
+)
+
+func TestNewUnixBusStale(t *testing.T) {
+	dir := t.TempDir()
+
+	b, err := NewUnixBus(dir, "a")
+	core.AssertMustNoError(t, err, "NewUnixBus")
+	// leave the socket behind
+	core.AssertMustNoError(t, b.conn.Close(), "Close conn")
+
+	b, err = NewUnixBus(dir, "a")
+	core.AssertMustNoError(t, err, "NewUnixBus over stale socket")
+	core.AssertNoError(t, b.Close(), "Close")
+
+	filename := filepath.Join(dir, "b"+unixBusSuffix)
+	core.AssertMustNoError(t, os.WriteFile(filename, []byte("data"), 0o600), "WriteFile")
+
+	_, err = NewUnixBus(dir, "b")
+	core.AssertErrorIs(t, err, core.ErrExists, "NewUnixBus over a file")
+
+	data, err := os.ReadFile(filename)
+	core.AssertNoError(t, err, "file kept")
+	core.AssertEqual(t, "data", string(data), "file kept")
+}
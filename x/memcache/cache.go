@@ -2,11 +2,21 @@ package memcache
 
 import (
 	"context"
+	"time"
 
 	"darvaza.org/cache"
 )
 
 var (
+	_ Limiter       = (*Cache[string])(nil)
+	_ cache.Resizer = (*Cache[string])(nil)
+
+	_ cache.ExtendedStatser = (*Cache[string])(nil)
+
+	_ cache.Inspector[string]   = (*Cache[string])(nil)
+	_ cache.Inspector[uint32]   = (*Cache[uint32])(nil)
+	_ cache.Inspector[[32]byte] = (*Cache[[32]byte])(nil)
+
 	_ cache.Cache[string]   = (*Cache[string])(nil)
 	_ cache.Cache[uint32]   = (*Cache[uint32])(nil)
 	_ cache.Cache[[32]byte] = (*Cache[[32]byte])(nil)
@@ -16,13 +26,16 @@ var (
 type Cache[K comparable] struct {
 	*SingleFlight[K]
 
-	lru *LRU[K]
+	lru  *LRU[K]
+	tags tagIndex[K]
+	inv  *invalidator[K]
 }
 
 // NewCache creates a new [Cache] with a maximum size and [cache.Getter]
 func NewCache[K comparable](name string, cacheBytes int64, getter cache.Getter[K]) *Cache[K] {
 	g := &Cache[K]{}
-	lru := NewLRU(cacheBytes, nil, g.onEvict)
+	lru := NewLRU[K](cacheBytes, nil, nil)
+	lru.SetEvictReasonCallback(g.onEvict)
 
 	g.lru = lru
 	g.SingleFlight = NewSingleFlight(name, lru, getter)
@@ -30,11 +43,13 @@ func NewCache[K comparable](name string, cacheBytes int64, getter cache.Getter[K
 	return g
 }
 
-func (g *Cache[K]) onEvict(key K, _ []byte, size int64) {
+func (g *Cache[K]) onEvict(key K, _ []byte, size int64, reason EvictReason) {
+	g.tags.drop(key)
+
 	if log, ok := g.withDebug(); ok {
 		log.WithField("key", key).
 			WithField("size", size).
-			Print("removed")
+			Print(reason)
 	}
 }
 
@@ -44,10 +59,106 @@ func (g *Cache[K]) Stats(_ cache.Type) cache.Stats {
 	return g.lru.Stats()
 }
 
-// Remove evicts an entry from the [Cache]
-func (g *Cache[K]) Remove(_ context.Context, key K) {
+// ExtendedStats returns statistics about the Cache, including those of
+// the loads done through the [cache.Getter]. This implementation doesn't
+// distinguish among types
+func (g *Cache[K]) ExtendedStats(_ cache.Type) cache.ExtendedStats {
+	return g.loads.Extend(g.lru.Stats())
+}
+
+// Remove evicts an entry from the [Cache], and from the replicas
+// if the [Store] is connected to a [Bus]
+func (g *Cache[K]) Remove(ctx context.Context, key K) {
+	g.removeLocal(key)
+
+	if g.inv != nil {
+		g.inv.publish(ctx, g, EventRemove, &key)
+	}
+}
+
+func (g *Cache[K]) removeLocal(key K) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	g.lru.Evict(key)
+	g.unsafeForget(key)
+}
+
+// MaxSize returns the nominal capacity of the [Cache] in bytes
+func (g *Cache[K]) MaxSize() int64 {
+	return g.lru.MaxSize()
+}
+
+// SetLimit restricts the effective capacity of the [Cache].
+// See [LRU.SetLimit] for details.
+func (g *Cache[K]) SetLimit(bytes int64) int {
+	return g.lru.SetLimit(bytes)
+}
+
+// Resize changes the capacity of the [Cache], evicting entries as needed,
+// and returns how many were evicted
+func (g *Cache[K]) Resize(_ context.Context, cacheBytes int64) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.lru.Resize(cacheBytes), nil
+}
+
+// Purge removes all entries from the [Cache], and from the replicas
+// if the [Store] is connected to a [Bus]
+func (g *Cache[K]) Purge(ctx context.Context) error {
+	g.purgeLocal()
+
+	if g.inv != nil {
+		g.inv.publish(ctx, g, EventPurge, nil)
+	}
+	return nil
+}
+
+func (g *Cache[K]) purgeLocal() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.lru.Purge()
+	g.tags.reset()
+	g.unsafeForgetAll()
+}
+
+// Peek reads an entry into a [cache.Sink] without updating its recency
+// nor the statistics, and without reaching out to the [cache.Getter]
+// if missing.
+func (g *Cache[K]) Peek(_ context.Context, key K, dest cache.Sink) error {
+	v, ex, ok := g.lru.Peek(key)
+	if !ok {
+		return cache.ErrNotFound
+	}
+
+	var expire time.Time
+	if ex != nil {
+		expire = *ex
+	}
+	return dest.SetBytes(v, expire)
+}
+
+// Contains tells if the [Cache] has an entry for the key
+func (g *Cache[K]) Contains(_ context.Context, key K) bool {
+	return g.lru.Contains(key)
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was found.
+// A zero duration on a found entry means it never expires.
+func (g *Cache[K]) TTL(_ context.Context, key K) (time.Duration, bool) {
+	return g.lru.TTL(key)
+}
+
+// Touch sets a new expiration date to an entry without changing its value,
+// and returns true if it was found. A zero expire means it never expires.
+func (g *Cache[K]) Touch(_ context.Context, key K, expire time.Time) bool {
+	return g.lru.Touch(key, expire)
+}
+
+// SetSlidingExpiration makes every hit extend the life of the entries
+// of the [Cache]. See [LRU.SetSlidingExpiration] for details.
+func (g *Cache[K]) SetSlidingExpiration(extend, maxAge time.Duration) {
+	g.lru.SetSlidingExpiration(extend, maxAge)
 }
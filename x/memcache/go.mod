@@ -13,3 +13,8 @@ require (
 	golang.org/x/net v0.50.0 // indirect
 	golang.org/x/text v0.34.0 // indirect
 )
+
+replace (
+	darvaza.org/cache => ../..
+	darvaza.org/cache/x/simplelru => ../simplelru
+)
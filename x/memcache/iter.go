@@ -0,0 +1,81 @@
+package memcache
+
+import (
+	"iter"
+	"time"
+)
+
+// EntryView is a read-only view of an [LRU] entry taken by
+// [LRU.All]
+type EntryView struct {
+	value  []byte
+	size   int64
+	expire time.Time
+}
+
+// Bytes returns a copy of the stored value
+func (e EntryView) Bytes() []byte {
+	return append([]byte(nil), e.value...)
+}
+
+// Len returns the length of the stored value
+func (e EntryView) Len() int {
+	return len(e.value)
+}
+
+// Size returns the space accounted for the entry in bytes
+func (e EntryView) Size() int64 {
+	return e.size
+}
+
+// Expire returns the expiration date of the entry, or zero if
+// it doesn't expire
+func (e EntryView) Expire() time.Time {
+	return e.expire
+}
+
+type snapshotEntry[K comparable] struct {
+	key K
+	EntryView
+}
+
+// All returns an iterator over a snapshot of all non-expired entries,
+// from the least recently used to the most recently used. The lock is
+// only held while taking the snapshot, so entries may be changed or
+// evicted during the iteration without affecting the values yielded.
+// Neither hits nor recency are affected, and expired entries are
+// skipped but not evicted.
+func (m *LRU[K]) All() iter.Seq2[K, EntryView] {
+	return func(yield func(K, EntryView) bool) {
+		for _, e := range m.snapshot() {
+			if !yield(e.key, e.EntryView) {
+				return
+			}
+		}
+	}
+}
+
+func (m *LRU[K]) snapshot() []snapshotEntry[K] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]snapshotEntry[K], 0, m.lru.Len())
+	m.lru.PeekEach(func(key K, value []byte, size int, expire time.Time) bool {
+		out = append(out, snapshotEntry[K]{
+			key: key,
+			EntryView: EntryView{
+				value:  value,
+				size:   m.fromUnit(size),
+				expire: expire,
+			},
+		})
+		return false
+	})
+	return out
+}
+
+// All returns an iterator over a snapshot of all non-expired entries
+// in the [Cache]. See [LRU.All] for details.
+func (g *Cache[K]) All() iter.Seq2[K, EntryView] {
+	return g.lru.All()
+}
@@ -0,0 +1,40 @@
+package memcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestCacheAll(t *testing.T) {
+	ctx := context.Background()
+	g := newSnapshotCache(t, "test", "k1", "k2")
+	core.AssertMustNoError(t, g.Set(ctx, "old", []byte("v"), time.Now().Add(-time.Second), cache.MainCache), "Set old")
+	core.AssertMustNoError(t, g.Set(ctx, "k3", []byte("v-k3"), time.Time{}, cache.MainCache), "Set k3")
+
+	var sink cache.ByteSink
+	core.AssertMustNoError(t, g.Get(ctx, "k1", &sink), "Get k1")
+	before := g.Stats(cache.MainCache)
+
+	var keys []string
+	for k, e := range g.All() {
+		keys = append(keys, k)
+		core.AssertEqual(t, "v-"+k, string(e.Bytes()), "%s value", k)
+		core.AssertEqual(t, int64(len("v-"+k)), e.Size(), "%s size", k)
+	}
+	core.AssertSliceEqual(t, []string{"k2", "k3", "k1"}, keys, "keys")
+
+	// nothing changed by iterating
+	core.AssertEqual(t, before, g.Stats(cache.MainCache), "Stats")
+	core.AssertEqual(t, int64(4), before.Items, "Items")
+
+	keys = keys[:0]
+	for k := range g.All() {
+		keys = append(keys, k)
+		break
+	}
+	core.AssertSliceEqual(t, []string{"k2"}, keys, "early stop")
+}
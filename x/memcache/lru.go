@@ -36,6 +36,7 @@ var (
 	_ Adder[string]       = (*LRU[string])(nil)
 	_ Getter[string]      = (*LRU[string])(nil)
 	_ AdderGetter[string] = (*LRU[string])(nil)
+	_ Limiter             = (*LRU[string])(nil)
 )
 
 // LRU is a least-recently-used cache of bytes with TTL and maximum size
@@ -43,11 +44,21 @@ type LRU[K comparable] struct {
 	mu      sync.Mutex
 	lru     *simplelru.LRU[K, []byte]
 	unit    uint
+	maxSize int
+	limit   int
 	onSet   func(K, []byte, int64, *time.Time)
 	onEvict func(K, []byte, int64)
+	reason  func(K, []byte, int64, EvictReason)
 	stats   cache.Stats
+
+	// sliding expiration
+	extend time.Duration
+	maxAge time.Duration
 }
 
+// EvictReason tells why an entry was removed from the [LRU]
+type EvictReason = simplelru.EvictReason
+
 // NewLRU creates a new []byte [LRU] with maximum size and eviction
 func NewLRU[K comparable](cacheBytes int64,
 	onSet func(K, []byte, int64, *time.Time),
@@ -57,24 +68,43 @@ func NewLRU[K comparable](cacheBytes int64,
 
 	m := &LRU[K]{
 		unit:    unit,
+		maxSize: size,
 		onSet:   onSet,
 		onEvict: onEvict,
 	}
 
-	lru := simplelru.NewLRU(size, m.setCallback, m.evictionCallback)
+	lru := simplelru.NewLRU(size, m.setCallback, nil)
+	lru.SetEvictReasonCallback(m.evictionCallback)
 	m.lru = lru
 
 	return m
 }
 
-func (m *LRU[K]) evictionCallback(key K, value []byte, size int) {
-	// increment evictions count
-	m.stats.Evictions++
+// SetEvictReasonCallback sets a function to be called, in addition to
+// the eviction callback given to [NewLRU], when an entry is removed
+// and telling why
+func (m *LRU[K]) SetEvictReasonCallback(fn func(K, []byte, int64, EvictReason)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reason = fn
+}
+
+func (m *LRU[K]) evictionCallback(key K, value []byte, size int, reason EvictReason) {
+	switch reason {
+	case simplelru.Removed, simplelru.Purged:
+		// asked for, not evictions
+	default:
+		m.stats.Evictions++
+	}
 
 	if m.onEvict != nil {
 		// and inform the user
 		m.onEvict(key, value, m.fromUnit(size))
 	}
+	if m.reason != nil {
+		m.reason(key, value, m.fromUnit(size), reason)
+	}
 }
 
 func (m *LRU[K]) setCallback(key K, value []byte, size int, expire time.Time) {
@@ -126,6 +156,63 @@ func (m *LRU[K]) Add(key K, value []byte, expire time.Time) bool {
 	return m.lru.Add(key, value, size, expire)
 }
 
+// MaxSize returns the nominal capacity of the [LRU] in bytes
+func (m *LRU[K]) MaxSize() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.fromUnit(m.maxSize)
+}
+
+// Limit returns the effective capacity of the [LRU] in bytes
+func (m *LRU[K]) Limit() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.fromUnit(m.lru.MaxSize())
+}
+
+// SetLimit restricts the effective capacity of the [LRU] below its
+// nominal size, evicting expired entries first and then the least
+// recently used until it fits. A limit of zero removes the restriction.
+// SetLimit returns the number of evicted entries.
+func (m *LRU[K]) SetLimit(bytes int64) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.limit = m.toUnit(bytes)
+	return m.applySize()
+}
+
+// Resize changes the nominal capacity of the [LRU], evicting expired
+// entries first and then the least recently used if needed, and
+// returns the number of evicted entries.
+func (m *LRU[K]) Resize(cacheBytes int64) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.maxSize = m.toUnit(cacheBytes)
+	return m.applySize()
+}
+
+// applySize resizes the underlying LRU to the effective capacity
+func (m *LRU[K]) applySize() int {
+	size := m.maxSize
+	if m.limit > 0 && m.limit < size {
+		size = m.limit
+	}
+
+	return m.lru.Resize(size)
+}
+
+// Purge removes all entries from the [LRU]
+func (m *LRU[K]) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lru.Purge()
+}
+
 // Evict removes an entry if present
 func (m *LRU[K]) Evict(key K) {
 	m.mu.Lock()
@@ -144,14 +231,96 @@ func (m *LRU[K]) Get(key K) ([]byte, *time.Time, bool) {
 	v, ex, ok := m.lru.Get(key)
 	if ok {
 		m.stats.Hits++
+		ex = m.slide(key, ex)
+	}
+
+	if ex.IsZero() {
+		return v, nil, ok
+	}
+	return v, &ex, ok
+}
+
+// Touch sets a new expiration date to an entry without changing its
+// value, and returns true if it was found. A zero expire means it
+// never expires.
+func (m *LRU[K]) Touch(key K, expire time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Touch(key, expire)
+}
+
+// SetSlidingExpiration makes every hit extend the life of entries with
+// an expiration date to extend from now, but never beyond maxAge since
+// they were stored. A zero extend disables sliding expiration, and a
+// zero maxAge removes the limit.
+func (m *LRU[K]) SetSlidingExpiration(extend, maxAge time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.extend = max(extend, 0)
+	m.maxAge = max(maxAge, 0)
+}
+
+// slide extends the expiration date of an entry on a hit, if sliding
+// expiration is enabled, and returns the resulting expiration date
+func (m *LRU[K]) slide(key K, ex time.Time) time.Time {
+	if m.extend == 0 || ex.IsZero() {
+		return ex
+	}
+
+	now := time.Now()
+	next := now.Add(m.extend)
+	if m.maxAge > 0 {
+		if age, ok := m.lru.Age(key); ok {
+			next = minTime(next, now.Add(m.maxAge-age))
+		}
 	}
 
+	if next.After(ex) && m.lru.Touch(key, next) {
+		return next
+	}
+	return ex
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// Peek is like Get but it doesn't update the recency of the entry
+// nor the statistics
+func (m *LRU[K]) Peek(key K) ([]byte, *time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ex, ok := m.lru.Peek(key)
 	if ex.IsZero() {
 		return v, nil, ok
 	}
 	return v, &ex, ok
 }
 
+// Contains tells if a non-expired entry exists for the key, without
+// updating its recency nor the statistics
+func (m *LRU[K]) Contains(key K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Contains(key)
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was
+// found. A zero duration on a found entry means it never expires.
+func (m *LRU[K]) TTL(key K) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.TTL(key)
+}
+
 // EvictExpired periodically scans for expired entries and evicts them from the cache.
 // It runs until the provided context is cancelled.
 func (m *LRU[K]) EvictExpired(ctx context.Context, period time.Duration) error {
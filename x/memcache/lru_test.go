@@ -0,0 +1,122 @@
+package memcache
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestLRUEvictions(t *testing.T) {
+	m := NewLRU[string](20, nil, nil)
+	value := []byte("0123456789")
+
+	m.Add("k1", value, time.Time{})
+	m.Add("k2", value, time.Time{})
+	m.Add("k3", value, time.Time{})
+	core.AssertEqual(t, int64(1), m.Stats().Evictions, "pruned")
+
+	// expired entries go first when making space
+	m.Add("k4", value, time.Now().Add(-time.Second))
+	_, _, ok := m.Get("k4")
+	core.AssertFalse(t, ok, "expired")
+	core.AssertEqual(t, int64(2), m.Stats().Evictions, "pruned and expired")
+
+	m.Evict("k3")
+	core.AssertEqual(t, int64(2), m.Stats().Evictions, "removed")
+
+	m.Add("k5", value, time.Time{})
+	m.Purge()
+	core.AssertEqual(t, int64(2), m.Stats().Evictions, "purged")
+	core.AssertEqual(t, int64(0), m.Stats().Items, "Items")
+}
+
+func TestLRUPeek(t *testing.T) {
+	m := NewLRU[string](20, nil, nil)
+	value := []byte("0123456789")
+	m.Add("k1", value, time.Time{})
+	m.Add("k2", value, time.Time{})
+
+	v, ex, ok := m.Peek("k1")
+	core.AssertTrue(t, ok, "Peek k1")
+	core.AssertEqual(t, string(value), string(v), "Peek k1")
+	core.AssertNil(t, ex, "Peek k1 expire")
+	core.AssertTrue(t, m.Contains("k2"), "Contains k2")
+	_, ok = m.TTL("k2")
+	core.AssertTrue(t, ok, "TTL k2")
+
+	stats := m.Stats()
+	core.AssertEqual(t, int64(0), stats.Gets, "Gets")
+	core.AssertEqual(t, int64(0), stats.Hits, "Hits")
+
+	// k1 wasn't promoted, so it goes first
+	m.Add("k3", value, time.Time{})
+	core.AssertFalse(t, m.Contains("k1"), "k1 evicted")
+	core.AssertTrue(t, m.Contains("k2"), "k2 kept")
+}
+
+func TestLRUSlidingExpiration(t *testing.T) {
+	t.Run("touch", runTestLRUTouch)
+	t.Run("extend", runTestLRUSlidingExtend)
+	t.Run("max age", runTestLRUSlidingMaxAge)
+}
+
+func runTestLRUTouch(t *testing.T) {
+	m := NewLRU[string](1<<10, nil, nil)
+	m.Add("k1", []byte("v"), time.Now().Add(time.Minute))
+
+	expire := time.Now().Add(time.Hour).Round(0)
+	core.AssertTrue(t, m.Touch("k1", expire), "Touch")
+	_, ex, ok := m.Peek("k1")
+	core.AssertTrue(t, ok, "Peek")
+	core.AssertMustNotNil(t, ex, "Peek expire")
+	core.AssertTrue(t, expire.Equal(*ex), "expire extended")
+
+	core.AssertFalse(t, m.Touch("k2", expire), "Touch missing")
+}
+
+func runTestLRUSlidingExtend(t *testing.T) {
+	m := NewLRU[string](1<<10, nil, nil)
+	m.SetSlidingExpiration(time.Hour, 0)
+	m.Add("k1", []byte("v"), time.Now().Add(time.Minute))
+	m.Add("forever", []byte("v"), time.Time{})
+
+	_, ex, ok := m.Get("k1")
+	core.AssertTrue(t, ok, "Get k1")
+	core.AssertMustNotNil(t, ex, "Get k1 expire")
+	core.AssertTrue(t, time.Until(*ex) > 59*time.Minute, "extended")
+
+	_, ex, ok = m.Get("forever")
+	core.AssertTrue(t, ok, "Get forever")
+	core.AssertNil(t, ex, "still never expires")
+
+	// no sliding on Peek
+	m.Add("k2", []byte("v"), time.Now().Add(time.Minute))
+	_, ex, _ = m.Peek("k2")
+	core.AssertMustNotNil(t, ex, "Peek k2 expire")
+	core.AssertTrue(t, time.Until(*ex) <= time.Minute, "not extended")
+}
+
+func runTestLRUSlidingMaxAge(t *testing.T) {
+	m := NewLRU[string](1<<10, nil, nil)
+	m.SetSlidingExpiration(time.Hour, 10*time.Minute)
+
+	added := time.Now()
+	m.Add("k1", []byte("v"), added.Add(time.Minute))
+
+	for range 3 {
+		_, ex, ok := m.Get("k1")
+		core.AssertMustTrue(t, ok, "Get")
+		core.AssertMustNotNil(t, ex, "Get expire")
+
+		limit := added.Add(10 * time.Minute)
+		core.AssertFalse(t, ex.After(limit.Add(time.Second)), "capped at max age")
+		core.AssertTrue(t, ex.After(limit.Add(-time.Second)), "extended up to max age")
+	}
+
+	// never shortened below the original expiration
+	m.Add("k2", []byte("v"), time.Now().Add(time.Hour))
+	_, ex, _ := m.Get("k2")
+	core.AssertMustNotNil(t, ex, "Get k2 expire")
+	core.AssertTrue(t, time.Until(*ex) > 59*time.Minute, "not shortened")
+}
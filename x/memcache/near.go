@@ -0,0 +1,186 @@
+package memcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Cache[string] = (*NearCache[string])(nil)
+	_ cache.Resizer       = (*NearCache[string])(nil)
+)
+
+// NearCache is a two-level [cache.Cache], with a small in-memory [Cache]
+// (L1) in front of another [cache.Cache] (L2), usually remote.
+//
+// L1 is populated on L2 hits, capping the lifetime of its entries so
+// changes made to L2 by others are eventually seen. Set writes through
+// to both levels and Remove is propagated to both, while Resize and
+// Purge only affect L1.
+type NearCache[K comparable] struct {
+	l1     *Cache[K]
+	l2     cache.Cache[K]
+	maxTTL time.Duration
+
+	mu     sync.Mutex
+	l2Gets int64
+	l2Hits int64
+}
+
+// TierStats reports the statistics of each level of a [NearCache]
+type TierStats struct {
+	// L1 are the statistics of the in-memory [Cache]
+	L1 cache.Stats
+	// L2 counts the requests that reached the second level, and
+	// how many succeeded, as seen by this [NearCache]
+	L2 cache.Stats
+}
+
+// NewNearCache creates a [NearCache] with an L1 of the given size in
+// front of the given [cache.Cache]. L1 entries live at most maxTTL,
+// or as long as on L2 if zero.
+func NewNearCache[K comparable](l2 cache.Cache[K], cacheBytes int64,
+	maxTTL time.Duration) *NearCache[K] {
+	//
+	if l2 == nil {
+		return nil
+	}
+
+	nc := &NearCache[K]{
+		l2:     l2,
+		maxTTL: maxTTL,
+	}
+
+	l1 := &Cache[K]{
+		lru: NewLRU[K](cacheBytes, nil, nil),
+	}
+	l1.lru.SetEvictReasonCallback(l1.onEvict)
+	l1.SingleFlight = NewSingleFlight(l2.Name(), &nearInward[K]{
+		LRU:    l1.lru,
+		maxTTL: maxTTL,
+	}, cache.GetterFunc[K](nc.getRemote))
+
+	nc.l1 = l1
+	return nc
+}
+
+// nearInward caps the lifetime of the entries stored on L1
+type nearInward[K comparable] struct {
+	*LRU[K]
+	maxTTL time.Duration
+}
+
+func (p *nearInward[K]) Add(key K, value []byte, expire time.Time) bool {
+	return p.LRU.Add(key, value, capExpire(expire, p.maxTTL))
+}
+
+func capExpire(expire time.Time, maxTTL time.Duration) time.Time {
+	if maxTTL <= 0 {
+		return expire
+	}
+
+	limit := time.Now().Add(maxTTL)
+	if expire.IsZero() || expire.After(limit) {
+		return limit
+	}
+	return expire
+}
+
+// getRemote reads from L2 on L1 misses
+func (nc *NearCache[K]) getRemote(ctx context.Context, key K, dest cache.Sink) error {
+	err := nc.l2.Get(ctx, key, dest)
+
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	nc.l2Gets++
+	if err == nil {
+		nc.l2Hits++
+	}
+	return err
+}
+
+// Name returns the name of the L2 [cache.Cache]
+func (nc *NearCache[K]) Name() string {
+	return nc.l2.Name()
+}
+
+// L1 returns the in-memory [Cache] of the first level
+func (nc *NearCache[K]) L1() *Cache[K] {
+	return nc.l1
+}
+
+// L2 returns the [cache.Cache] of the second level
+func (nc *NearCache[K]) L2() cache.Cache[K] {
+	return nc.l2
+}
+
+// Get reads an entry from L1, or from L2 on a miss. Concurrent L1 misses
+// of the same key share a single L2 request.
+func (nc *NearCache[K]) Get(ctx context.Context, key K, dest cache.Sink) error {
+	return nc.l1.Get(ctx, key, dest)
+}
+
+// Set writes an entry through to L2, and then to L1 if successful
+func (nc *NearCache[K]) Set(ctx context.Context, key K, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	if err := nc.l2.Set(ctx, key, value, expire, cacheType); err != nil {
+		return err
+	}
+	return nc.l1.Set(ctx, key, value, expire, cacheType)
+}
+
+// Remove evicts an entry from both levels. L2 goes first, and reads
+// of L2 in progress won't store their value on L1.
+func (nc *NearCache[K]) Remove(ctx context.Context, key K) {
+	nc.l2.Remove(ctx, key)
+	nc.l1.Remove(ctx, key)
+}
+
+// Stats returns the combined statistics of the [NearCache]. Size and
+// evictions are those of L1, and hits on either level count as hits.
+func (nc *NearCache[K]) Stats(cacheType cache.Type) cache.Stats {
+	ts := nc.TierStats(cacheType)
+
+	out := ts.L1
+	out.Hits += ts.L2.Hits
+	return out
+}
+
+// TierStats returns the statistics of each level
+func (nc *NearCache[K]) TierStats(cacheType cache.Type) TierStats {
+	l1 := nc.l1.Stats(cacheType)
+
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	return TierStats{
+		L1: l1,
+		L2: cache.Stats{
+			Gets: nc.l2Gets,
+			Hits: nc.l2Hits,
+		},
+	}
+}
+
+// Resize changes the capacity of L1, evicting entries as needed,
+// and returns how many were evicted
+func (nc *NearCache[K]) Resize(ctx context.Context, cacheBytes int64) (int, error) {
+	return nc.l1.Resize(ctx, cacheBytes)
+}
+
+// Purge removes all entries from L1. L2 is shared, so it isn't
+// affected, but it can be purged through [NearCache.L2].
+func (nc *NearCache[K]) Purge(ctx context.Context) error {
+	return nc.l1.Purge(ctx)
+}
+
+// SetLogger attaches a [slog.Logger] to L1
+func (nc *NearCache[K]) SetLogger(log slog.Logger) {
+	nc.l1.SetLogger(log)
+}
@@ -0,0 +1,165 @@
+package memcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestNearCache(t *testing.T) {
+	t.Run("get", runTestNearCacheGet)
+	t.Run("max ttl", runTestNearCacheMaxTTL)
+	t.Run("set and remove", runTestNearCacheSetRemove)
+	t.Run("purge", runTestNearCachePurge)
+	t.Run("remove while loading", runTestNearCacheRemoveLoading)
+}
+
+// versionGetter returns the key followed by the number of the call,
+// holding the first call until released
+type versionGetter struct {
+	mu      sync.Mutex
+	calls   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func newVersionGetter() *versionGetter {
+	return &versionGetter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *versionGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.mu.Lock()
+	g.calls++
+	n := g.calls
+	g.mu.Unlock()
+
+	if n == 1 {
+		close(g.started)
+		<-g.release
+	}
+	return dest.SetBytes([]byte(fmt.Sprintf("%s#%v", key, n)), time.Time{})
+}
+
+func (g *versionGetter) Calls() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.calls
+}
+
+func newTestNearCache(t *testing.T, maxTTL time.Duration,
+	getter cache.Getter[string]) (*NearCache[string], *Cache[string]) {
+	//
+	t.Helper()
+
+	l2 := NewCache("test", 1<<20, getter)
+	nc := NewNearCache[string](l2, 1<<10, maxTTL)
+	core.AssertMustNotNil(t, nc, "NewNearCache")
+	return nc, l2
+}
+
+func nearGet(t *testing.T, g cache.Getter[string], key string) string {
+	t.Helper()
+
+	var sink cache.ByteSink
+	err := g.Get(context.Background(), key, &sink)
+	core.AssertMustNoError(t, err, "Get %q", key)
+	return string(sink.Bytes())
+}
+
+func runTestNearCacheGet(t *testing.T) {
+	getter := newVersionGetter()
+	close(getter.release)
+	nc, _ := newTestNearCache(t, 0, getter)
+
+	core.AssertEqual(t, "k1#1", nearGet(t, nc, "k1"), "first Get")
+	core.AssertEqual(t, "k1#1", nearGet(t, nc, "k1"), "second Get")
+	core.AssertEqual(t, 1, getter.Calls(), "getter calls")
+	core.AssertEqual(t, "test", nc.Name(), "Name")
+
+	ts := nc.TierStats(cache.MainCache)
+	core.AssertEqual(t, int64(2), ts.L1.Gets, "L1 Gets")
+	core.AssertEqual(t, int64(1), ts.L1.Hits, "L1 Hits")
+	core.AssertEqual(t, int64(1), ts.L2.Gets, "L2 Gets")
+	core.AssertEqual(t, int64(1), ts.L2.Hits, "L2 Hits")
+	core.AssertEqual(t, int64(2), nc.Stats(cache.MainCache).Hits, "Hits")
+}
+
+func runTestNearCacheMaxTTL(t *testing.T) {
+	ctx := context.Background()
+	nc, l2 := newTestNearCache(t, time.Minute, newVersionGetter())
+
+	err := l2.Set(ctx, "k1", []byte("v"), time.Now().Add(time.Hour), cache.MainCache)
+	core.AssertMustNoError(t, err, "Set L2")
+	core.AssertEqual(t, "v", nearGet(t, nc, "k1"), "Get")
+
+	ttl, ok := nc.L1().TTL(ctx, "k1")
+	core.AssertTrue(t, ok, "L1 TTL")
+	core.AssertTrue(t, ttl > 0 && ttl <= time.Minute, "L1 TTL capped")
+
+	ttl, ok = l2.TTL(ctx, "k1")
+	core.AssertTrue(t, ok, "L2 TTL")
+	core.AssertTrue(t, ttl > time.Minute, "L2 TTL kept")
+}
+
+func runTestNearCacheSetRemove(t *testing.T) {
+	ctx := context.Background()
+	nc, l2 := newTestNearCache(t, 0, newVersionGetter())
+
+	err := nc.Set(ctx, "k1", []byte("v"), time.Time{}, cache.MainCache)
+	core.AssertMustNoError(t, err, "Set")
+	core.AssertTrue(t, nc.L1().Contains(ctx, "k1"), "Set on L1")
+	core.AssertTrue(t, l2.Contains(ctx, "k1"), "Set on L2")
+
+	nc.Remove(ctx, "k1")
+	core.AssertFalse(t, nc.L1().Contains(ctx, "k1"), "removed from L1")
+	core.AssertFalse(t, l2.Contains(ctx, "k1"), "removed from L2")
+}
+
+func runTestNearCachePurge(t *testing.T) {
+	ctx := context.Background()
+	nc, l2 := newTestNearCache(t, 0, newVersionGetter())
+
+	err := nc.Set(ctx, "k1", []byte("v"), time.Time{}, cache.MainCache)
+	core.AssertMustNoError(t, err, "Set")
+
+	core.AssertNoError(t, nc.Purge(ctx), "Purge")
+	core.AssertFalse(t, nc.L1().Contains(ctx, "k1"), "purged from L1")
+	core.AssertTrue(t, l2.Contains(ctx, "k1"), "kept on L2")
+}
+
+func runTestNearCacheRemoveLoading(t *testing.T) {
+	ctx := context.Background()
+	getter := newVersionGetter()
+	nc, l2 := newTestNearCache(t, 0, getter)
+
+	first := make(chan string)
+	go func() {
+		var sink cache.ByteSink
+		_ = nc.Get(ctx, "k1", &sink)
+		first <- string(sink.Bytes())
+	}()
+
+	// remove while the first load is in progress
+	<-getter.started
+	nc.Remove(ctx, "k1")
+
+	// later requests don't wait for it
+	core.AssertEqual(t, "k1#2", nearGet(t, nc, "k1"), "Get after Remove")
+
+	close(getter.release)
+	core.AssertEqual(t, "k1#1", <-first, "Get before Remove")
+
+	// and the old value isn't stored on either level
+	core.AssertEqual(t, "k1#2", nearGet(t, nc, "k1"), "Get after load")
+	core.AssertEqual(t, "k1#2", nearGet(t, l2, "k1"), "L2 after load")
+	core.AssertEqual(t, 2, getter.Calls(), "getter calls")
+}
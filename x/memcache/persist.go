@@ -0,0 +1,243 @@
+package memcache
+
+import (
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+// WriteSnapshot writes the non-expired entries of the [Cache] to w, in
+// recency order, using the given [cache.KeyCodec] to encode the keys.
+func (g *Cache[K]) WriteSnapshot(w io.Writer, codec cache.KeyCodec[K]) error {
+	if codec == nil {
+		return errMissingKeyCodec
+	}
+
+	sw := newSnapshotWriter(w)
+	if err := g.writeSnapshot(sw, codec); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// ReadSnapshot restores entries from a snapshot previously written by
+// [Cache.WriteSnapshot] or [Store.WriteSnapshot], and returns how many
+// were restored. Only the namespace with the name of the [Cache] is
+// restored. Expired entries and those already present are skipped,
+// and the oldest are dropped if they don't fit in the available space.
+// Nothing is restored if the snapshot is corrupted.
+func (g *Cache[K]) ReadSnapshot(r io.Reader, codec cache.KeyCodec[K]) (int, error) {
+	if codec == nil {
+		return 0, errMissingKeyCodec
+	}
+
+	nss, err := readSnapshot(r)
+	if err != nil {
+		return 0, err
+	}
+
+	return g.restoreFrom(nss, codec)
+}
+
+// SaveSnapshot atomically writes a snapshot of the [Cache] to a file.
+func (g *Cache[K]) SaveSnapshot(filename string, codec cache.KeyCodec[K]) error {
+	return saveSnapshotFile(filename, func(w io.Writer) error {
+		return g.WriteSnapshot(w, codec)
+	})
+}
+
+// LoadSnapshot restores entries from a snapshot file. See [Cache.ReadSnapshot].
+func (g *Cache[K]) LoadSnapshot(filename string, codec cache.KeyCodec[K]) (int, error) {
+	if codec == nil {
+		return 0, errMissingKeyCodec
+	}
+
+	nss, err := loadSnapshotFile(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	return g.restoreFrom(nss, codec)
+}
+
+func (g *Cache[K]) writeSnapshot(sw *snapshotWriter, codec cache.KeyCodec[K]) error {
+	sw.Namespace(g.Name())
+	for key, e := range g.lru.All() {
+		s, err := codec.EncodeKey(key)
+		if err != nil {
+			return core.Wrap(err, "encode key")
+		}
+
+		sw.Entry(s, e.value, e.expire)
+	}
+	return sw.err
+}
+
+// restoreFrom restores the namespace matching the name of the [Cache].
+// Namespaces of other caches are ignored, as their keys may not be
+// understood by our codec.
+func (g *Cache[K]) restoreFrom(nss []snapshotNamespace, codec cache.KeyCodec[K]) (int, error) {
+	for i := range nss {
+		if nss[i].name == g.Name() {
+			return g.restore(&nss[i], codec)
+		}
+	}
+	return 0, nil
+}
+
+func (g *Cache[K]) restore(ns *snapshotNamespace, codec cache.KeyCodec[K]) (int, error) {
+	entries := make([]snapshotEntry[K], 0, len(ns.entries))
+	for _, rec := range ns.entries {
+		key, err := codec.DecodeKey(rec.key)
+		if err != nil {
+			return 0, core.Wrap(err, "decode key")
+		}
+
+		entries = append(entries, snapshotEntry[K]{
+			key: key,
+			EntryView: EntryView{
+				value:  rec.value,
+				expire: rec.expire,
+			},
+		})
+	}
+
+	n := g.lru.restore(entries)
+	if log, ok := g.withDebug(); ok {
+		log.WithField("entries", len(entries)).
+			WithField("restored", n).
+			Print("snapshot restored")
+	}
+	return n, nil
+}
+
+// restore adds snapshot entries in order, skipping the expired and those
+// already present, and dropping the oldest that don't fit in the
+// available space
+func (m *LRU[K]) restore(entries []snapshotEntry[K]) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var count int
+
+	now := time.Now()
+	for _, e := range entries[m.restoreStart(entries, now):] {
+		if m.restorable(e, now) {
+			m.lru.Add(e.key, e.value, m.toUnit(len64(e.value)), e.expire)
+			count++
+		}
+	}
+	return count
+}
+
+func (m *LRU[K]) restoreStart(entries []snapshotEntry[K], now time.Time) int {
+	avail := m.lru.Available()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if m.restorable(entries[i], now) {
+			size := m.toUnit(len64(entries[i].value))
+			if size > avail {
+				return i + 1
+			}
+			avail -= size
+		}
+	}
+	return 0
+}
+
+func (m *LRU[K]) restorable(e snapshotEntry[K], now time.Time) bool {
+	if !e.expire.IsZero() && !now.Before(e.expire) {
+		return false
+	}
+	return !m.lru.Contains(e.key)
+}
+
+// WriteSnapshot writes the non-expired entries of every [Cache] in the
+// [Store] to w, using the given [cache.KeyCodec] to encode the keys.
+func (s *Store[K]) WriteSnapshot(w io.Writer, codec cache.KeyCodec[K]) error {
+	if codec == nil {
+		return errMissingKeyCodec
+	}
+
+	sw := newSnapshotWriter(w)
+	for _, g := range s.caches() {
+		if err := g.writeSnapshot(sw, codec); err != nil {
+			return err
+		}
+	}
+	return sw.Close()
+}
+
+// ReadSnapshot restores entries from a snapshot into the [Cache]s of the
+// [Store] with matching names, and returns how many were restored.
+// Namespaces not present in the [Store] are ignored. See [Cache.ReadSnapshot].
+func (s *Store[K]) ReadSnapshot(r io.Reader, codec cache.KeyCodec[K]) (int, error) {
+	if codec == nil {
+		return 0, errMissingKeyCodec
+	}
+
+	nss, err := readSnapshot(r)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.restore(nss, codec)
+}
+
+// SaveSnapshot atomically writes a snapshot of the [Store] to a file.
+func (s *Store[K]) SaveSnapshot(filename string, codec cache.KeyCodec[K]) error {
+	return saveSnapshotFile(filename, func(w io.Writer) error {
+		return s.WriteSnapshot(w, codec)
+	})
+}
+
+// LoadSnapshot restores entries from a snapshot file. See [Store.ReadSnapshot].
+func (s *Store[K]) LoadSnapshot(filename string, codec cache.KeyCodec[K]) (int, error) {
+	if codec == nil {
+		return 0, errMissingKeyCodec
+	}
+
+	nss, err := loadSnapshotFile(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.restore(nss, codec)
+}
+
+func (s *Store[K]) restore(nss []snapshotNamespace, codec cache.KeyCodec[K]) (int, error) {
+	var total int
+
+	for i := range nss {
+		if g := s.getCache(nss[i].name); g != nil {
+			n, err := g.restore(&nss[i], codec)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+	}
+	return total, nil
+}
+
+// caches returns the [Cache]s of the [Store] sorted by name
+func (s *Store[K]) caches() []*Cache[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*Cache[K], 0, len(s.m))
+	for _, name := range slices.Sorted(maps.Keys(s.m)) {
+		out = append(out, s.m[name])
+	}
+	return out
+}
+
+func (s *Store[K]) getCache(name string) *Cache[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.m[name]
+}
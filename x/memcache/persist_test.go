@@ -0,0 +1,188 @@
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Run("round trip", runTestSnapshotRoundTrip)
+	t.Run("file", runTestSnapshotFile)
+	t.Run("namespaces", runTestSnapshotNamespaces)
+	t.Run("truncated", runTestSnapshotTruncated)
+	t.Run("checksum", runTestSnapshotChecksum)
+	t.Run("header", runTestSnapshotHeader)
+	t.Run("expired", runTestSnapshotExpired)
+}
+
+var notFoundGetter = cache.GetterFunc[string](func(context.Context, string, cache.Sink) error {
+	return cache.ErrNotFound
+})
+
+func newSnapshotCache(t *testing.T, name string, keys ...string) *Cache[string] {
+	t.Helper()
+
+	g := NewCache(name, 1<<20, notFoundGetter)
+	for _, k := range keys {
+		core.AssertMustNoError(t, g.Set(context.Background(), k, []byte("v-"+k), time.Time{}, cache.MainCache), "Set %q", k)
+	}
+	return g
+}
+
+func cacheKeys(g *Cache[string]) []string {
+	var out []string
+	for k := range g.All() {
+		out = append(out, k)
+	}
+	return out
+}
+
+func writeTestSnapshot(t *testing.T, g *Cache[string]) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	core.AssertMustNoError(t, g.WriteSnapshot(&buf, cache.StringKeys{}), "WriteSnapshot")
+	return buf.Bytes()
+}
+
+func runTestSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	expire := time.Now().Add(time.Hour).Round(0)
+
+	src := newSnapshotCache(t, "test", "k1", "k2")
+	core.AssertMustNoError(t, src.Set(ctx, "k3", []byte("v-k3"), expire, cache.MainCache), "Set k3")
+	// k1 becomes the most recent
+	var sink cache.ByteSink
+	core.AssertMustNoError(t, src.Get(ctx, "k1", &sink), "Get k1")
+
+	data := writeTestSnapshot(t, src)
+
+	dst := newSnapshotCache(t, "test")
+	n, err := dst.ReadSnapshot(bytes.NewReader(data), cache.StringKeys{})
+	core.AssertNoError(t, err, "ReadSnapshot")
+	core.AssertEqual(t, 3, n, "restored")
+	core.AssertSliceEqual(t, []string{"k2", "k3", "k1"}, cacheKeys(dst), "recency order")
+
+	for k, e := range dst.All() {
+		core.AssertEqual(t, "v-"+k, string(e.Bytes()), "%s value", k)
+		if k == "k3" {
+			core.AssertTrue(t, expire.Equal(e.Expire()), "%s expire", k)
+		} else {
+			core.AssertTrue(t, e.Expire().IsZero(), "%s expire", k)
+		}
+	}
+}
+
+func runTestSnapshotFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.snap")
+
+	src := newSnapshotCache(t, "test", "k1", "k2")
+	core.AssertMustNoError(t, src.SaveSnapshot(filename, cache.StringKeys{}), "SaveSnapshot")
+
+	dst := newSnapshotCache(t, "test")
+	n, err := dst.LoadSnapshot(filename, cache.StringKeys{})
+	core.AssertNoError(t, err, "LoadSnapshot")
+	core.AssertEqual(t, 2, n, "restored")
+	core.AssertSliceEqual(t, []string{"k1", "k2"}, cacheKeys(dst), "keys")
+}
+
+func runTestSnapshotNamespaces(t *testing.T) {
+	s := New[string]()
+	for name, keys := range map[string][]string{"a": {"a1", "a2"}, "b": {"b1"}} {
+		g := core.AssertMustTypeIs[*Cache[string]](t, s.NewCache(name, 1<<20, notFoundGetter), "NewCache")
+		for _, k := range keys {
+			core.AssertMustNoError(t, g.Set(context.Background(), k, []byte("v"), time.Time{}, cache.MainCache), "Set")
+		}
+	}
+
+	var buf bytes.Buffer
+	core.AssertMustNoError(t, s.WriteSnapshot(&buf, cache.StringKeys{}), "WriteSnapshot")
+	data := buf.Bytes()
+
+	// a cache only restores its own namespace
+	g := newSnapshotCache(t, "b")
+	n, err := g.ReadSnapshot(bytes.NewReader(data), cache.StringKeys{})
+	core.AssertNoError(t, err, "ReadSnapshot b")
+	core.AssertEqual(t, 1, n, "restored b")
+	core.AssertSliceEqual(t, []string{"b1"}, cacheKeys(g), "keys b")
+
+	g = newSnapshotCache(t, "c")
+	n, err = g.ReadSnapshot(bytes.NewReader(data), cache.StringKeys{})
+	core.AssertNoError(t, err, "ReadSnapshot c")
+	core.AssertEqual(t, 0, n, "restored c")
+
+	// a store restores those it has
+	s2 := New[string]()
+	a := core.AssertMustTypeIs[*Cache[string]](t, s2.NewCache("a", 1<<20, notFoundGetter), "NewCache")
+	n, err = s2.ReadSnapshot(bytes.NewReader(data), cache.StringKeys{})
+	core.AssertNoError(t, err, "Store.ReadSnapshot")
+	core.AssertEqual(t, 2, n, "restored by store")
+	core.AssertSliceEqual(t, []string{"a1", "a2"}, cacheKeys(a), "keys a")
+}
+
+func runTestSnapshotTruncated(t *testing.T) {
+	data := writeTestSnapshot(t, newSnapshotCache(t, "test", "k1", "k2"))
+
+	for _, size := range []int{0, 4, len(snapshotMagic) + 1, len(data) / 2, len(data) - 5, len(data) - 1} {
+		g := newSnapshotCache(t, "test")
+		n, err := g.ReadSnapshot(bytes.NewReader(data[:size]), cache.StringKeys{})
+		core.AssertErrorIs(t, err, ErrBadSnapshot, "truncated at %v", size)
+		core.AssertEqual(t, 0, n, "restored at %v", size)
+		core.AssertEqual(t, 0, len(cacheKeys(g)), "keys at %v", size)
+	}
+}
+
+func runTestSnapshotChecksum(t *testing.T) {
+	data := writeTestSnapshot(t, newSnapshotCache(t, "test", "k1", "k2"))
+
+	// last byte of the last value, and of the checksum
+	for _, i := range []int{len(data) - 7, len(data) - 1} {
+		bad := slices.Clone(data)
+		bad[i] ^= 0xff
+
+		g := newSnapshotCache(t, "test")
+		n, err := g.ReadSnapshot(bytes.NewReader(bad), cache.StringKeys{})
+		core.AssertErrorIs(t, err, ErrBadSnapshot, "corrupted at %v", i)
+		core.AssertEqual(t, 0, n, "restored")
+		core.AssertEqual(t, 0, len(cacheKeys(g)), "keys")
+	}
+}
+
+func runTestSnapshotHeader(t *testing.T) {
+	data := writeTestSnapshot(t, newSnapshotCache(t, "test", "k1"))
+
+	badMagic := slices.Clone(data)
+	badMagic[0] = 'X'
+	badVersion := slices.Clone(data)
+	badVersion[len(snapshotMagic)] = snapshotVersion + 1
+
+	for name, b := range map[string][]byte{"magic": badMagic, "version": badVersion} {
+		_, err := readSnapshot(bytes.NewReader(b))
+		core.AssertErrorIs(t, err, ErrBadSnapshot, "bad %s", name)
+	}
+}
+
+func runTestSnapshotExpired(t *testing.T) {
+	var buf bytes.Buffer
+
+	now := time.Now()
+	sw := newSnapshotWriter(&buf)
+	sw.Namespace("test")
+	sw.Entry("old", []byte("v"), now.Add(-time.Second))
+	sw.Entry("new", []byte("v"), now.Add(time.Hour))
+	sw.Entry("forever", []byte("v"), time.Time{})
+	core.AssertMustNoError(t, sw.Close(), "Close")
+
+	g := newSnapshotCache(t, "test")
+	n, err := g.ReadSnapshot(&buf, cache.StringKeys{})
+	core.AssertNoError(t, err, "ReadSnapshot")
+	core.AssertEqual(t, 2, n, "restored")
+	core.AssertSliceEqual(t, []string{"new", "forever"}, cacheKeys(g), "keys")
+}
@@ -30,6 +30,7 @@ type SingleFlight[K comparable] struct {
 	inward  AdderGetter[K]
 	outward cache.Getter[K]
 	getters map[K]*outreacher[K]
+	loads   cache.LoadStats
 }
 
 // NewSingleFlight creates a new [SingleFlight] controller, with an [LRU] for
@@ -72,6 +73,8 @@ func (sf *SingleFlight[K]) Get(ctx context.Context, key K, dest cache.Sink) erro
 	if v, ex, ok := sf.inward.Get(key); ok {
 		// cache hit
 		defer sf.mu.Unlock()
+		sf.loads.RecordLocalHit()
+		cache.ObserveHit(ctx)
 
 		if log, ok := sf.withDebug(); ok {
 			log.WithField("key", key).
@@ -98,6 +101,8 @@ func (sf *SingleFlight[K]) Get(ctx context.Context, key K, dest cache.Sink) erro
 			log.WithField("key", key).
 				Print("waiting...")
 		}
+		sf.loads.RecordDedupWait()
+		cache.ObserveWait(ctx)
 		cond.Wait()
 
 		defer sf.mu.Unlock()
@@ -125,7 +130,11 @@ func (sf *SingleFlight[K]) Get(ctx context.Context, key K, dest cache.Sink) erro
 		log.WithField("key", key).
 			Print("getting...")
 	}
-	err := sf.outward.Get(ctx, key, dest)
+	lctx, done := cache.ObserveLoad(ctx)
+	start := time.Now()
+	err := sf.outward.Get(lctx, key, dest)
+	sf.loads.RecordLoad(time.Since(start), err)
+	done(err)
 	// and lock again
 	sf.mu.Lock()
 	defer sf.mu.Unlock()
@@ -140,8 +149,10 @@ func (sf *SingleFlight[K]) Get(ctx context.Context, key K, dest cache.Sink) erro
 				Print("success")
 		}
 
-		// store inward
-		sf.inward.Add(key, value, expire)
+		// store inward, unless invalidated or replaced meanwhile
+		if !cond.stale && !cond.done {
+			sf.inward.Add(key, value, expire)
+		}
 		// and on the condition variable if anyone is waiting
 		if !cond.Done() {
 			cond.SetBytes(value, expire)
@@ -179,6 +190,13 @@ func (sf *SingleFlight[K]) Set(_ context.Context, key K, value []byte,
 	sf.mu.Lock()
 	defer sf.mu.Unlock()
 
+	sf.unsafeSet(key, value, expire)
+	return nil
+}
+
+// unsafeSet stores the value for a key inward, and shares it with anyone
+// waiting for it. The caller must hold the lock.
+func (sf *SingleFlight[K]) unsafeSet(key K, value []byte, expire time.Time) {
 	sf.inward.Add(key, value, expire)
 	if p, ok := sf.getters[key]; ok {
 		defer p.Done()
@@ -186,7 +204,24 @@ func (sf *SingleFlight[K]) Set(_ context.Context, key K, value []byte,
 		// there is people waiting
 		p.SetBytes(value, expire)
 	}
-	return nil
+}
+
+// unsafeForget prevents a load in progress of a key from storing a
+// value that may predate an invalidation, and detaches it so later
+// requests start a new one. The caller must hold the lock.
+func (sf *SingleFlight[K]) unsafeForget(key K) {
+	if p, ok := sf.getters[key]; ok {
+		p.stale = true
+		delete(sf.getters, key)
+	}
+}
+
+// unsafeForgetAll is like unsafeForget but for all loads in progress.
+// The caller must hold the lock.
+func (sf *SingleFlight[K]) unsafeForgetAll() {
+	for key := range sf.getters {
+		sf.unsafeForget(key)
+	}
 }
 
 // SetLogger attaches a [slog.Logger] to this [SingleFlight] quasi-[cache.Cache]
@@ -235,6 +270,7 @@ type outreacher[K comparable] struct {
 	count  int
 	cond   *sync.Cond
 	key    K
+	stale  bool
 
 	done bool
 	err  error
@@ -287,7 +323,9 @@ func (p *outreacher[K]) Wait() {
 // Done makes the [SingleFlight] parent forget about the block on this key
 func (p *outreacher[K]) Done() bool {
 	if p.count < 1 {
-		delete(p.parent.getters, p.key)
+		if p.parent.getters[p.key] == p {
+			delete(p.parent.getters, p.key)
+		}
 		return true
 	}
 	return false
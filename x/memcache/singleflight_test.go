@@ -0,0 +1,82 @@
+package memcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestSingleFlightStats(t *testing.T) {
+	t.Run("load and hit", runTestSingleFlightStatsLoad)
+	t.Run("dedup wait", runTestSingleFlightStatsDedup)
+	t.Run("load error", runTestSingleFlightStatsError)
+}
+
+func runTestSingleFlightStatsLoad(t *testing.T) {
+	getter := newVersionGetter()
+	close(getter.release)
+	g := NewCache("test", 1<<20, getter)
+
+	core.AssertEqual(t, "k1#1", nearGet(t, g, "k1"), "first Get")
+	core.AssertEqual(t, "k1#1", nearGet(t, g, "k1"), "second Get")
+
+	es := g.ExtendedStats(cache.MainCache)
+	core.AssertEqual(t, int64(1), es.Loads, "Loads")
+	core.AssertEqual(t, int64(0), es.LoadErrors, "LoadErrors")
+	core.AssertEqual(t, int64(1), es.LocalHits, "LocalHits")
+	core.AssertEqual(t, int64(0), es.DedupWaits, "DedupWaits")
+	core.AssertEqual(t, int64(1), es.LoadLatency.Count, "LoadLatency")
+	core.AssertEqual(t, int64(2), es.Gets, "Gets")
+	core.AssertEqual(t, int64(1), es.Hits, "Hits")
+}
+
+func runTestSingleFlightStatsDedup(t *testing.T) {
+	getter := newVersionGetter()
+	g := NewCache("test", 1<<20, getter)
+
+	get := func(out chan<- string) {
+		var sink cache.ByteSink
+		_ = g.Get(context.Background(), "k1", &sink)
+		out <- string(sink.Bytes())
+	}
+
+	first := make(chan string)
+	go get(first)
+	<-getter.started
+
+	second := make(chan string)
+	go get(second)
+
+	// wait for the second Get to join the load in progress
+	for g.ExtendedStats(cache.MainCache).DedupWaits == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	close(getter.release)
+	core.AssertEqual(t, "k1#1", <-first, "first Get")
+	core.AssertEqual(t, "k1#1", <-second, "second Get")
+
+	es := g.ExtendedStats(cache.MainCache)
+	core.AssertEqual(t, int64(1), es.Loads, "Loads")
+	core.AssertEqual(t, int64(1), es.DedupWaits, "DedupWaits")
+	core.AssertEqual(t, int64(0), es.LocalHits, "LocalHits")
+	core.AssertEqual(t, 1, getter.Calls(), "getter calls")
+}
+
+func runTestSingleFlightStatsError(t *testing.T) {
+	g := NewCache("test", 1<<20, cache.GetterFunc[string](
+		func(context.Context, string, cache.Sink) error { return cache.ErrNotFound }))
+
+	var sink cache.ByteSink
+	err := g.Get(context.Background(), "k1", &sink)
+	core.AssertErrorIs(t, err, cache.ErrNotFound, "Get")
+
+	es := g.ExtendedStats(cache.MainCache)
+	core.AssertEqual(t, int64(1), es.Loads, "Loads")
+	core.AssertEqual(t, int64(1), es.LoadErrors, "LoadErrors")
+	core.AssertEqual(t, int64(0), es.LocalHits, "LocalHits")
+	core.AssertEqual(t, int64(1), es.LoadLatency.Count, "LoadLatency")
+}
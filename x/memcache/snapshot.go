@@ -0,0 +1,311 @@
+package memcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// Snapshot file format, version 1:
+//
+//	magic    "DVZCACHE"
+//	version  byte
+//	records  'N' name | 'E' key value expire | 'Z'
+//	checksum CRC-32C of everything above, big-endian
+//
+// Strings and byte slices are prefixed by their uvarint length,
+// and the expiration is a varint of Unix nanoseconds, zero if
+// the entry doesn't expire. Entries follow their namespace
+// record, from the least recently used to the most recent.
+const (
+	snapshotMagic   = "DVZCACHE"
+	snapshotVersion = 1
+
+	recordNamespace = 'N'
+	recordEntry     = 'E'
+	recordEnd       = 'Z'
+
+	maxSnapshotField = GiB
+)
+
+var (
+	// ErrBadSnapshot indicates the snapshot is corrupted or
+	// uses an unsupported format
+	ErrBadSnapshot = core.Wrap(core.ErrInvalid, "bad snapshot")
+
+	errMissingKeyCodec = core.Wrap(core.ErrInvalid, "missing key codec")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type snapshotRecord struct {
+	key    string
+	value  []byte
+	expire time.Time
+}
+
+type snapshotNamespace struct {
+	name    string
+	entries []snapshotRecord
+}
+
+// snapshotWriter encodes a snapshot stream, remembering the
+// first error
+type snapshotWriter struct {
+	w   *bufio.Writer
+	crc hash.Hash32
+	out io.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func newSnapshotWriter(w io.Writer) *snapshotWriter {
+	sw := &snapshotWriter{
+		w:   bufio.NewWriter(w),
+		crc: crc32.New(crcTable),
+	}
+	sw.out = io.MultiWriter(sw.w, sw.crc)
+
+	sw.write([]byte(snapshotMagic))
+	sw.writeByte(snapshotVersion)
+	return sw
+}
+
+func (sw *snapshotWriter) write(b []byte) {
+	if sw.err == nil {
+		_, sw.err = sw.out.Write(b)
+	}
+}
+
+func (sw *snapshotWriter) writeByte(b byte) {
+	sw.buf[0] = b
+	sw.write(sw.buf[:1])
+}
+
+func (sw *snapshotWriter) writeBytes(b []byte) {
+	n := binary.PutUvarint(sw.buf[:], uint64(len(b)))
+	sw.write(sw.buf[:n])
+	sw.write(b)
+}
+
+func (sw *snapshotWriter) Namespace(name string) {
+	sw.writeByte(recordNamespace)
+	sw.writeBytes([]byte(name))
+}
+
+func (sw *snapshotWriter) Entry(key string, value []byte, expire time.Time) {
+	var ex int64
+	if !expire.IsZero() {
+		ex = expire.UnixNano()
+	}
+
+	sw.writeByte(recordEntry)
+	sw.writeBytes([]byte(key))
+	sw.writeBytes(value)
+	n := binary.PutVarint(sw.buf[:], ex)
+	sw.write(sw.buf[:n])
+}
+
+// Close writes the end record and checksum, and flushes the output
+func (sw *snapshotWriter) Close() error {
+	sw.writeByte(recordEnd)
+	if sw.err == nil {
+		binary.BigEndian.PutUint32(sw.buf[:4], sw.crc.Sum32())
+		_, sw.err = sw.w.Write(sw.buf[:4])
+	}
+	if sw.err == nil {
+		sw.err = sw.w.Flush()
+	}
+	return sw.err
+}
+
+// snapshotReader decodes a snapshot stream
+type snapshotReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (sr *snapshotReader) ReadByte() (byte, error) {
+	b, err := sr.r.ReadByte()
+	if err == nil {
+		_, _ = sr.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+func (sr *snapshotReader) readFull(n uint64) ([]byte, error) {
+	if n > maxSnapshotField {
+		return nil, ErrBadSnapshot
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(sr.r, b); err != nil {
+		return nil, err
+	}
+	_, _ = sr.crc.Write(b)
+	return b, nil
+}
+
+func (sr *snapshotReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return nil, err
+	}
+	return sr.readFull(n)
+}
+
+func (sr *snapshotReader) readHeader() error {
+	b, err := sr.readFull(uint64(len(snapshotMagic)) + 1)
+	switch {
+	case err != nil:
+		return err
+	case !bytes.Equal(b[:len(snapshotMagic)], []byte(snapshotMagic)):
+		return core.Wrap(ErrBadSnapshot, "bad magic")
+	case b[len(snapshotMagic)] != snapshotVersion:
+		return core.Wrap(ErrBadSnapshot, "unsupported version")
+	default:
+		return nil
+	}
+}
+
+func (sr *snapshotReader) readEntry() (snapshotRecord, error) {
+	var rec snapshotRecord
+
+	key, err := sr.readBytes()
+	if err != nil {
+		return rec, err
+	}
+
+	value, err := sr.readBytes()
+	if err != nil {
+		return rec, err
+	}
+
+	ex, err := binary.ReadVarint(sr)
+	if err != nil {
+		return rec, err
+	}
+
+	rec.key, rec.value = string(key), value
+	if ex != 0 {
+		rec.expire = time.Unix(0, ex)
+	}
+	return rec, nil
+}
+
+func (sr *snapshotReader) readChecksum() error {
+	var b [4]byte
+
+	sum := sr.crc.Sum32()
+	if _, err := io.ReadFull(sr.r, b[:]); err != nil {
+		return err
+	}
+
+	if binary.BigEndian.Uint32(b[:]) != sum {
+		return core.Wrap(ErrBadSnapshot, "checksum mismatch")
+	}
+	return nil
+}
+
+// readSnapshot decodes and verifies a whole snapshot stream
+func readSnapshot(r io.Reader) ([]snapshotNamespace, error) {
+	sr := &snapshotReader{
+		r:   bufio.NewReader(r),
+		crc: crc32.New(crcTable),
+	}
+
+	if err := sr.readHeader(); err != nil {
+		return nil, snapshotError(err)
+	}
+
+	out, err := sr.readRecords()
+	if err == nil {
+		err = sr.readChecksum()
+	}
+
+	if err != nil {
+		return nil, snapshotError(err)
+	}
+	return out, nil
+}
+
+func (sr *snapshotReader) readRecords() ([]snapshotNamespace, error) {
+	var out []snapshotNamespace
+
+	for {
+		kind, err := sr.ReadByte()
+		switch {
+		case err != nil:
+			return nil, err
+		case kind == recordEnd:
+			return out, nil
+		}
+
+		out, err = sr.readRecord(kind, out)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (sr *snapshotReader) readRecord(kind byte, out []snapshotNamespace) ([]snapshotNamespace, error) {
+	switch {
+	case kind == recordNamespace:
+		name, err := sr.readBytes()
+		return append(out, snapshotNamespace{name: string(name)}), err
+	case kind == recordEntry && len(out) > 0:
+		rec, err := sr.readEntry()
+		ns := &out[len(out)-1]
+		ns.entries = append(ns.entries, rec)
+		return out, err
+	default:
+		return out, core.Wrap(ErrBadSnapshot, "unexpected record")
+	}
+}
+
+func snapshotError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return core.Wrap(ErrBadSnapshot, "truncated")
+	}
+	return err
+}
+
+// saveSnapshotFile atomically replaces a file with the output of the given
+// function, by writing to a temporary file first and renaming it.
+func saveSnapshotFile(filename string, fn func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = fn(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	return err
+}
+
+func loadSnapshotFile(filename string) ([]snapshotNamespace, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readSnapshot(f)
+}
@@ -2,6 +2,8 @@
 package memcache
 
 import (
+	"maps"
+	"slices"
 	"sync"
 
 	"darvaza.org/cache"
@@ -13,6 +15,7 @@ var (
 	_ cache.Store[string]   = (*Store[string])(nil)
 	_ cache.Store[uint32]   = (*Store[uint32])(nil)
 	_ cache.Store[[32]byte] = (*Store[[32]byte])(nil)
+	_ cache.Lister          = (*Store[string])(nil)
 )
 
 // Store manages in-memory [cache.Cache]s
@@ -20,6 +23,8 @@ type Store[K comparable] struct {
 	mu  sync.Mutex
 	log slog.Logger
 	m   map[string]*Cache[K]
+	mc  *MemoryController
+	inv invalidator[K]
 }
 
 // New creates a new [Store]
@@ -38,6 +43,10 @@ func (s *Store[K]) DeregisterCache(name string) {
 	_, ok := s.m[name]
 	if ok {
 		delete(s.m, name)
+
+		if s.mc != nil {
+			s.mc.Deregister(name)
+		}
 	}
 }
 
@@ -53,6 +62,14 @@ func (s *Store[K]) GetCache(name string) cache.Cache[K] {
 	return nil
 }
 
+// CacheNames returns the names of the [Cache]s of the [Store], sorted
+func (s *Store[K]) CacheNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(s.m))
+}
+
 // NewCache creates a new in-memory [Cache] attached to the [Store]
 func (s *Store[K]) NewCache(name string, cacheBytes int64, getter cache.Getter[K]) cache.Cache[K] {
 	if name == "" || getter == nil {
@@ -68,8 +85,13 @@ func (s *Store[K]) NewCache(name string, cacheBytes int64, getter cache.Getter[K
 
 	g := NewCache(name, cacheBytes, getter)
 	g.SetLogger(s.log)
+	g.inv = &s.inv
 	s.m[name] = g
 
+	if s.mc != nil {
+		s.mc.Register(name, g)
+	}
+
 	if log, ok := s.withDebug(); ok {
 		log.Printf("cache:%q created", name)
 	}
@@ -82,6 +104,31 @@ func (s *Store[K]) SetLogger(log slog.Logger) {
 	defer s.mu.Unlock()
 
 	s.log = log
+	if s.mc != nil {
+		s.mc.SetLogger(log)
+	}
+}
+
+// SetMemoryController attaches a [MemoryController] to the store, registering
+// all current and future [Cache]s on it. Decisions are logged through the
+// store's logger.
+func (s *Store[K]) SetMemoryController(mc *MemoryController) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mc != nil {
+		for name := range s.m {
+			s.mc.Deregister(name)
+		}
+	}
+
+	s.mc = mc
+	if mc != nil {
+		mc.SetLogger(s.log)
+		for name, g := range s.m {
+			mc.Register(name, g)
+		}
+	}
 }
 
 func (s *Store[K]) withLogger(level slog.LogLevel) (slog.Logger, bool) {
@@ -0,0 +1,175 @@
+package memcache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/simplelru"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+// seenEvents is the number of recent events remembered
+// to discard duplicates
+const seenEvents = 4096
+
+type eventID struct {
+	origin string
+	seq    uint64
+}
+
+// busState connects a [Store] to a [Bus]
+type busState[K comparable] struct {
+	bus         Bus
+	codec       cache.KeyCodec[K]
+	origin      string
+	seq         atomic.Uint64
+	unsubscribe func()
+
+	mu   sync.Mutex
+	seen *simplelru.LRU[eventID, struct{}]
+}
+
+// isNew tells if an event wasn't seen before, and remembers it
+func (bs *busState[K]) isNew(ev Event) bool {
+	id := eventID{origin: ev.Origin, seq: ev.Seq}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.seen.Contains(id) {
+		return false
+	}
+	bs.seen.Add(id, struct{}{}, 1, time.Time{})
+	return true
+}
+
+// invalidator publishes the invalidations of the [Cache]s of
+// a [Store], if connected to a [Bus]
+type invalidator[K comparable] struct {
+	state atomic.Pointer[busState[K]]
+}
+
+func (inv *invalidator[K]) publish(ctx context.Context, g *Cache[K], typ EventType, key *K) {
+	bs := inv.state.Load()
+	if bs == nil {
+		return
+	}
+
+	ev := Event{
+		Origin: bs.origin,
+		Seq:    bs.seq.Add(1),
+		Type:   typ,
+		Cache:  g.Name(),
+	}
+
+	var err error
+	if key != nil {
+		ev.Key, err = bs.codec.EncodeKey(*key)
+	}
+	if err == nil {
+		err = bs.bus.Publish(ctx, ev)
+	}
+
+	if err != nil {
+		if log, ok := g.withLogger(slog.Warn); ok {
+			log.WithField(slog.ErrorFieldName, err).
+				Printf("failed to publish %s", typ)
+		}
+	}
+}
+
+// SetBus connects the [Store] to a [Bus], so Remove and Purge on its
+// [Cache]s are applied on all replicas, using the given [cache.KeyCodec]
+// to encode the keys. Events sent by the [Store] itself or already
+// applied are ignored, and applying an event doesn't publish it again.
+// A nil [Bus] disconnects the [Store].
+func (s *Store[K]) SetBus(bus Bus, codec cache.KeyCodec[K]) error {
+	var bs *busState[K]
+
+	if bus != nil {
+		if codec == nil {
+			return errMissingKeyCodec
+		}
+
+		origin, err := newOrigin()
+		if err != nil {
+			return err
+		}
+
+		bs = &busState[K]{
+			bus:    bus,
+			codec:  codec,
+			origin: origin,
+			seen:   simplelru.NewLRU[eventID, struct{}](seenEvents, nil, nil),
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old := s.inv.state.Swap(bs); old != nil {
+		old.unsubscribe()
+	}
+
+	if bs != nil {
+		bs.unsubscribe = bus.Subscribe(func(ev Event) {
+			s.receive(bs, ev)
+		})
+	}
+	return nil
+}
+
+func newOrigin() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// receive applies an event from another replica
+func (s *Store[K]) receive(bs *busState[K], ev Event) {
+	if ev.Origin == bs.origin || !bs.isNew(ev) {
+		return
+	}
+
+	s.mu.Lock()
+	g := s.m[ev.Cache]
+	s.mu.Unlock()
+
+	if g == nil {
+		return
+	}
+
+	if err := applyEvent(g, bs.codec, ev); err != nil {
+		if log, ok := s.withLogger(slog.Warn); ok {
+			log.WithField("cache", ev.Cache).
+				WithField("origin", ev.Origin).
+				WithField(slog.ErrorFieldName, err).
+				Printf("failed to apply %s", ev.Type)
+		}
+	}
+}
+
+func applyEvent[K comparable](g *Cache[K], codec cache.KeyCodec[K], ev Event) error {
+	switch ev.Type {
+	case EventRemove:
+		key, err := codec.DecodeKey(ev.Key)
+		if err != nil {
+			return err
+		}
+		g.removeLocal(key)
+		return nil
+	case EventPurge:
+		g.purgeLocal()
+		return nil
+	default:
+		return core.Wrap(ErrBadEvent, "unknown type")
+	}
+}
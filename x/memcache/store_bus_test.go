@@ -0,0 +1,167 @@
+package memcache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestStoreBus(t *testing.T) {
+	t.Run("remove", runTestStoreBusRemove)
+	t.Run("purge", runTestStoreBusPurge)
+	t.Run("invalidate tag", runTestStoreBusInvalidateTag)
+	t.Run("own events", runTestStoreBusOwnEvents)
+	t.Run("duplicates", runTestStoreBusDuplicates)
+	t.Run("no republish", runTestStoreBusNoRepublish)
+}
+
+// recordingBus is a [MemoryBus] remembering the events published
+type recordingBus struct {
+	MemoryBus
+
+	mu     sync.Mutex
+	events []Event
+}
+
+func (b *recordingBus) Publish(ctx context.Context, ev Event) error {
+	b.mu.Lock()
+	b.events = append(b.events, ev)
+	b.mu.Unlock()
+
+	return b.MemoryBus.Publish(ctx, ev)
+}
+
+func (b *recordingBus) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Event(nil), b.events...)
+}
+
+// newTestReplicas creates two [Store]s connected by a bus, each with
+// a "test" [Cache] holding k1 and k2
+func newTestReplicas(t *testing.T, bus Bus) (a, b *Store[string], ga, gb *Cache[string]) {
+	t.Helper()
+
+	getter := cache.GetterFunc[string](func(context.Context, string, cache.Sink) error {
+		return cache.ErrNotFound
+	})
+
+	caches := make([]*Cache[string], 2)
+	stores := make([]*Store[string], 2)
+	for i := range stores {
+		s := New[string]()
+		core.AssertMustNoError(t, s.SetBus(bus, cache.StringKeys{}), "SetBus")
+
+		g := core.AssertMustTypeIs[*Cache[string]](t, s.NewCache("test", 1<<20, getter), "NewCache")
+		for _, k := range []string{"k1", "k2"} {
+			core.AssertMustNoError(t, g.Set(context.Background(), k, []byte("v"), time.Time{}, cache.MainCache), "Set")
+		}
+
+		stores[i], caches[i] = s, g
+	}
+	return stores[0], stores[1], caches[0], caches[1]
+}
+
+func origin(s *Store[string]) string {
+	return s.inv.state.Load().origin
+}
+
+func runTestStoreBusRemove(t *testing.T) {
+	ctx := context.Background()
+	_, _, ga, gb := newTestReplicas(t, NewMemoryBus())
+
+	ga.Remove(ctx, "k1")
+	core.AssertFalse(t, ga.Contains(ctx, "k1"), "removed on sender")
+	core.AssertFalse(t, gb.Contains(ctx, "k1"), "removed on replica")
+	core.AssertTrue(t, gb.Contains(ctx, "k2"), "others kept on replica")
+}
+
+func runTestStoreBusPurge(t *testing.T) {
+	ctx := context.Background()
+	_, _, ga, gb := newTestReplicas(t, NewMemoryBus())
+
+	core.AssertNoError(t, gb.Purge(ctx), "Purge")
+	for _, k := range []string{"k1", "k2"} {
+		core.AssertFalse(t, ga.Contains(ctx, k), "%s purged on replica", k)
+		core.AssertFalse(t, gb.Contains(ctx, k), "%s purged on sender", k)
+	}
+}
+
+func runTestStoreBusInvalidateTag(t *testing.T) {
+	ctx := context.Background()
+	bus := new(recordingBus)
+	_, _, ga, gb := newTestReplicas(t, bus)
+
+	for _, k := range []string{"k1", "k2", "k3"} {
+		err := ga.SetTagged(ctx, k, []byte("v"), time.Time{}, cache.MainCache, "t1")
+		core.AssertMustNoError(t, err, "SetTagged %q", k)
+	}
+	core.AssertMustNoError(t, gb.Set(ctx, "k3", []byte("v"), time.Time{}, cache.MainCache), "Set k3")
+
+	n, err := ga.InvalidateTag(ctx, "t1")
+	core.AssertNoError(t, err, "InvalidateTag")
+	core.AssertEqual(t, 3, n, "InvalidateTag")
+	core.AssertEqual(t, 3, len(bus.Events()), "events published")
+
+	// replicas don't share tags, but get the removals
+	for _, k := range []string{"k1", "k2", "k3"} {
+		core.AssertFalse(t, gb.Contains(ctx, k), "%s removed on replica", k)
+	}
+}
+
+func runTestStoreBusOwnEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus()
+	a, _, ga, gb := newTestReplicas(t, bus)
+
+	ev := Event{Origin: origin(a), Seq: 1000, Type: EventRemove, Cache: "test", Key: "k1"}
+	core.AssertNoError(t, bus.Publish(ctx, ev), "Publish")
+
+	core.AssertTrue(t, ga.Contains(ctx, "k1"), "ignored by sender")
+	core.AssertFalse(t, gb.Contains(ctx, "k1"), "applied by replica")
+}
+
+func runTestStoreBusDuplicates(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus()
+	_, _, ga, gb := newTestReplicas(t, bus)
+
+	ev := Event{Origin: "other", Seq: 1, Type: EventRemove, Cache: "test", Key: "k1"}
+	core.AssertNoError(t, bus.Publish(ctx, ev), "Publish")
+	core.AssertFalse(t, ga.Contains(ctx, "k1"), "applied")
+
+	for _, g := range []*Cache[string]{ga, gb} {
+		core.AssertMustNoError(t, g.Set(ctx, "k1", []byte("v"), time.Time{}, cache.MainCache), "Set")
+	}
+
+	core.AssertNoError(t, bus.Publish(ctx, ev), "Publish again")
+	core.AssertTrue(t, ga.Contains(ctx, "k1"), "duplicate ignored")
+	core.AssertTrue(t, gb.Contains(ctx, "k1"), "duplicate ignored")
+
+	// same Seq, other Origin
+	ev.Origin = "another"
+	core.AssertNoError(t, bus.Publish(ctx, ev), "Publish other origin")
+	core.AssertFalse(t, ga.Contains(ctx, "k1"), "other origin applied")
+}
+
+func runTestStoreBusNoRepublish(t *testing.T) {
+	ctx := context.Background()
+	bus := new(recordingBus)
+	a, _, ga, _ := newTestReplicas(t, bus)
+
+	ga.Remove(ctx, "k1")
+	core.AssertNoError(t, ga.Purge(ctx), "Purge")
+
+	events := bus.Events()
+	core.AssertMustEqual(t, 2, len(events), "events published")
+	for _, ev := range events {
+		core.AssertEqual(t, origin(a), ev.Origin, "%s origin", ev.Type)
+	}
+	core.AssertEqual(t, EventRemove, events[0].Type, "first event")
+	core.AssertEqual(t, EventPurge, events[1].Type, "second event")
+}
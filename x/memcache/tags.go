@@ -0,0 +1,166 @@
+package memcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+)
+
+var (
+	_ cache.Tagger[string]   = (*Cache[string])(nil)
+	_ cache.Tagger[uint32]   = (*Cache[uint32])(nil)
+	_ cache.Tagger[[32]byte] = (*Cache[[32]byte])(nil)
+)
+
+// tagIndex keeps the tags of each key and the keys of each tag.
+// It's only changed with the lock of the [Cache] held, except by
+// the eviction callback, and its own lock is never held while calling
+// the [LRU], as the [LRU] calls back with its own lock held.
+type tagIndex[K comparable] struct {
+	mu    sync.Mutex
+	byTag map[string]map[K]struct{}
+	byKey map[K][]string
+}
+
+// set replaces the tags of a key
+func (ti *tagIndex[K]) set(key K, tags []string) {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	ti.unsafeDrop(key)
+	if len(tags) == 0 {
+		return
+	}
+
+	if ti.byKey == nil {
+		ti.byTag = make(map[string]map[K]struct{})
+		ti.byKey = make(map[K][]string)
+	}
+
+	for _, tag := range tags {
+		keys, ok := ti.byTag[tag]
+		if !ok {
+			keys = make(map[K]struct{})
+			ti.byTag[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	ti.byKey[key] = tags
+}
+
+// drop forgets the tags of a key
+func (ti *tagIndex[K]) drop(key K) {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	ti.unsafeDrop(key)
+}
+
+func (ti *tagIndex[K]) unsafeDrop(key K) {
+	tags, ok := ti.byKey[key]
+	if !ok {
+		return
+	}
+
+	for _, tag := range tags {
+		keys := ti.byTag[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(ti.byTag, tag)
+		}
+	}
+	delete(ti.byKey, key)
+}
+
+// keys returns the keys labelled with a tag
+func (ti *tagIndex[K]) keys(tag string) []K {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	keys := ti.byTag[tag]
+	out := make([]K, 0, len(keys))
+	for key := range keys {
+		out = append(out, key)
+	}
+	return out
+}
+
+// tags returns the tags of a key
+func (ti *tagIndex[K]) tags(key K) []string {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	return append([]string(nil), ti.byKey[key]...)
+}
+
+// reset forgets all tags
+func (ti *tagIndex[K]) reset() {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	ti.byTag, ti.byKey = nil, nil
+}
+
+// Set adds an entry to the [Cache], without tags
+func (g *Cache[K]) Set(_ context.Context, key K, value []byte,
+	expire time.Time, _ cache.Type) error {
+	//
+	g.setTagged(key, value, expire, nil)
+	return nil
+}
+
+// SetTagged adds an entry to the [Cache] labelled with the given tags,
+// replacing those of a previous entry. Tags are forgotten when the
+// entry is removed for any reason.
+func (g *Cache[K]) SetTagged(_ context.Context, key K, value []byte,
+	expire time.Time, _ cache.Type, tags ...string) error {
+	//
+	g.setTagged(key, value, expire, append([]string(nil), tags...))
+	return nil
+}
+
+// setTagged updates the index and the [LRU] under the same lock, so
+// concurrent calls can't leave them out of sync
+func (g *Cache[K]) setTagged(key K, value []byte, expire time.Time, tags []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// tagged first, so an immediate eviction untags it
+	g.tags.set(key, tags)
+	g.unsafeSet(key, value, expire)
+}
+
+// InvalidateTag removes all entries labelled with the tag, and from
+// the replicas if the [Store] is connected to a [Bus], and returns
+// how many were removed
+func (g *Cache[K]) InvalidateTag(ctx context.Context, tag string) (int, error) {
+	keys := g.evictTag(tag)
+
+	if g.inv != nil {
+		for _, key := range keys {
+			g.inv.publish(ctx, g, EventRemove, &key)
+		}
+	}
+	return len(keys), nil
+}
+
+// evictTag removes the entries labelled with the tag, whose tags
+// are dropped by the eviction callback
+func (g *Cache[K]) evictTag(tag string) []K {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := g.tags.keys(tag)
+	for _, key := range keys {
+		g.lru.Evict(key)
+		g.unsafeForget(key)
+	}
+	return keys
+}
+
+// Tags returns the tags of an entry
+func (g *Cache[K]) Tags(key K) []string {
+	return g.tags.tags(key)
+}
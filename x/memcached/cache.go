@@ -0,0 +1,285 @@
+package memcached
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Cache[string]     = (*Cache)(nil)
+	_ cache.Resizer           = (*Cache)(nil)
+	_ cache.Inspector[string] = (*Cache)(nil)
+)
+
+// Cache is a [cache.Cache] namespace on a fleet of memcached servers.
+// On a miss only one client loads the value while the others wait for
+// it, and entries close to their expiration can be refreshed in the
+// background.
+type Cache struct {
+	name   string
+	ns     string
+	c      *client
+	getter cache.Getter[string]
+	cfg    *Config
+
+	mu    sync.Mutex
+	log   slog.Logger
+	stats cache.Stats
+}
+
+// Name returns the name of the [Cache] namespace
+func (g *Cache) Name() string {
+	return g.name
+}
+
+// Stats returns the statistics kept by this client about the [Cache].
+// Only Gets and Hits are tracked, and it doesn't distinguish among types.
+func (g *Cache) Stats(_ cache.Type) cache.Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.stats
+}
+
+// Set stores an entry, or removes it if already expired. Expiration
+// dates are rounded up to whole seconds.
+func (g *Cache) Set(ctx context.Context, key string, value []byte,
+	expire time.Time, _ cache.Type) error {
+	//
+	return g.store(ctx, key, value, expire)
+}
+
+// Get reads an entry into a [cache.Sink], and reaches out to the
+// [cache.Getter] on a miss. If another client is already loading the
+// key, it waits for it to finish.
+func (g *Cache) Get(ctx context.Context, key string, dest cache.Sink) error {
+	if dest == nil {
+		return core.ErrInvalid
+	}
+
+	it, err := g.fetch(ctx, key, g.getFlags()...)
+	if err != nil {
+		return err
+	}
+
+	g.count(it.found)
+	if it.found {
+		return g.hit(ctx, key, it, dest)
+	}
+	return g.miss(ctx, key, it, dest)
+}
+
+// getFlags returns the flags to vivify missing entries, and to win
+// the refresh of entries about to expire
+func (g *Cache) getFlags() []string {
+	flags := []string{intFlag('N', seconds(g.cfg.LockTTL))}
+	if d := g.cfg.StaleWhileRevalidate; d > 0 {
+		flags = append(flags, intFlag('R', seconds(d)))
+	}
+	return flags
+}
+
+func (g *Cache) count(hit bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.stats.Gets++
+	if hit {
+		g.stats.Hits++
+	}
+}
+
+// hit returns the current value, refreshing it in the background
+// if we won the right to
+func (g *Cache) hit(ctx context.Context, key string, it *item, dest cache.Sink) error {
+	if it.won {
+		go g.refresh(ctx, key)
+	}
+	return dest.SetBytes(it.value, it.expire)
+}
+
+// miss loads the value if we won the placeholder, or waits for whoever
+// did to store it. If the value doesn't appear within LockWait, it's
+// loaded without waiting further.
+func (g *Cache) miss(ctx context.Context, key string, it *item, dest cache.Sink) error {
+	deadline := time.Now().Add(g.cfg.LockWait)
+
+	for {
+		switch {
+		case it.found:
+			return dest.SetBytes(it.value, it.expire)
+		case it.won:
+			return g.load(ctx, key, dest, true)
+		case !it.placeholder:
+			return g.load(ctx, key, dest, false)
+		case time.Now().After(deadline):
+			g.logDebug(key, "lock wait expired")
+			return g.getter.Get(ctx, key, dest)
+		}
+
+		if err := sleep(ctx, g.cfg.LockRetry); err != nil {
+			return err
+		}
+
+		var err error
+		if it, err = g.fetch(ctx, key, g.getFlags()...); err != nil {
+			return err
+		}
+	}
+}
+
+// revive:disable:flag-parameter
+
+// load calls the [cache.Getter] and stores the value. If we own the
+// placeholder it's removed on failure so others don't wait for us.
+func (g *Cache) load(ctx context.Context, key string, dest cache.Sink, owner bool) error {
+	// revive:enable:flag-parameter
+	g.logDebug(key, "getting...")
+	if err := g.getter.Get(ctx, key, dest); err != nil {
+		if owner {
+			_ = g.delete(context.WithoutCancel(ctx), key)
+		}
+		return err
+	}
+
+	if err := g.store(ctx, key, dest.Bytes(), dest.Expire()); err != nil {
+		// we have the value anyway
+		g.logError(key, err, "store")
+	}
+	return nil
+}
+
+// refresh reloads an entry in the background
+func (g *Cache) refresh(ctx context.Context, key string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), g.cfg.LockTTL)
+	defer cancel()
+
+	g.logDebug(key, "refreshing...")
+
+	var dest cache.ByteSink
+	err := g.getter.Get(ctx, key, &dest)
+	if err == nil {
+		err = g.store(ctx, key, dest.Bytes(), dest.Expire())
+	}
+
+	if err != nil {
+		g.logError(key, err, "refresh")
+	}
+}
+
+// Remove removes an entry from the [Cache]
+func (g *Cache) Remove(ctx context.Context, key string) {
+	if err := g.delete(ctx, key); err != nil {
+		g.logError(key, err, "remove")
+	}
+}
+
+// Invalidate marks an entry as stale instead of removing it. With
+// StaleWhileRevalidate set, readers keep receiving it until the first
+// of them refreshes it in the background. Otherwise the entry is removed.
+func (g *Cache) Invalidate(ctx context.Context, key string) error {
+	d := g.cfg.StaleWhileRevalidate
+	if d <= 0 {
+		return g.delete(ctx, key)
+	}
+	return g.delete(ctx, key, "I", intFlag('T', seconds(d)))
+}
+
+// Resize isn't supported by memcached namespaces
+func (*Cache) Resize(context.Context, int64) (int, error) {
+	return 0, cache.ErrNotSupported
+}
+
+// Purge isn't supported by memcached namespaces
+func (*Cache) Purge(context.Context) error {
+	return cache.ErrNotSupported
+}
+
+// Peek reads an entry into a [cache.Sink] without updating the statistics,
+// and without reaching out to the [cache.Getter] if missing.
+func (g *Cache) Peek(ctx context.Context, key string, dest cache.Sink) error {
+	it, err := g.fetch(ctx, key)
+	switch {
+	case err != nil:
+		return err
+	case !it.found:
+		return cache.ErrNotFound
+	default:
+		return dest.SetBytes(it.value, it.expire)
+	}
+}
+
+// Contains tells if the [Cache] has an entry for the key
+func (g *Cache) Contains(ctx context.Context, key string) bool {
+	_, ok := g.TTL(ctx, key)
+	return ok
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was found.
+// A zero duration on a found entry means it never expires.
+func (g *Cache) TTL(ctx context.Context, key string) (time.Duration, bool) {
+	res, err := g.c.Meta(ctx, "mg", itemKey(g.ns, key), nil, "t", "f")
+	if err != nil || res.status != statusHeader || res.ClientFlags() == 0 {
+		return 0, false
+	}
+	return res.TTL(), true
+}
+
+// SetLogger attaches a [slog.Logger] to the [Cache]
+func (g *Cache) SetLogger(log slog.Logger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.log = log
+}
+
+func (g *Cache) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.log != nil {
+		log, ok := g.log.WithLevel(level).WithEnabled()
+		if ok {
+			log = log.WithField("cache", g.name)
+			return log, true
+		}
+	}
+	return nil, false
+}
+
+func (g *Cache) logDebug(key, msg string) {
+	if log, ok := g.withLogger(slog.Debug); ok {
+		log.WithField("key", key).Print(msg)
+	}
+}
+
+func (g *Cache) logWarn(key, msg string) {
+	if log, ok := g.withLogger(slog.Warn); ok {
+		log.WithField("key", key).Print(msg)
+	}
+}
+
+func (g *Cache) logError(key string, err error, op string) {
+	if log, ok := g.withLogger(slog.Error); ok {
+		log.WithField("key", key).
+			WithField(slog.ErrorFieldName, err).
+			Printf("%s failed", op)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,215 @@
+package memcached
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("get and set", runTestCacheGetSet)
+	t.Run("expiry", runTestCacheExpiry)
+	t.Run("remove", runTestCacheRemove)
+	t.Run("chunked", runTestCacheChunked)
+	t.Run("hashed keys", runTestCacheHashedKeys)
+	t.Run("shared load", runTestCacheSharedLoad)
+}
+
+// countingGetter returns "value-" followed by the key after an
+// optional delay, and counts how many times it was called
+type countingGetter struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (g *countingGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.calls.Add(1)
+	time.Sleep(g.delay)
+	return dest.SetBytes([]byte("value-"+key), time.Time{})
+}
+
+func newTestStore(t *testing.T, m *fakeMemcached) *Store {
+	t.Helper()
+
+	s, err := New(&Config{
+		Servers:   []string{m.Addr()},
+		ItemSize:  2 * itemOverhead,
+		LockRetry: 5 * time.Millisecond,
+	})
+	core.AssertMustNoError(t, err, "New")
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func newTestCache(t *testing.T, s *Store, name string, getter cache.Getter[string]) *Cache {
+	t.Helper()
+
+	g := s.NewCache(name, 0, getter)
+	core.AssertMustNotNil(t, g, "NewCache")
+	return core.AssertMustTypeIs[*Cache](t, g, "NewCache")
+}
+
+func get(t *testing.T, g cache.Getter[string], key string) (*cache.ByteSink, error) {
+	t.Helper()
+
+	sink := new(cache.ByteSink)
+	err := g.Get(context.Background(), key, sink)
+	return sink, err
+}
+
+func runTestCacheGetSet(t *testing.T) {
+	m := newFakeMemcached(t)
+	getter := new(countingGetter)
+	g := newTestCache(t, newTestStore(t, m), "ns", getter)
+
+	sink, err := get(t, g, "k1")
+	core.AssertNoError(t, err, "first Get")
+	core.AssertEqual(t, "value-k1", string(sink.Bytes()), "first Get")
+	core.AssertTrue(t, m.Has("cache:ns:k1"), "stored")
+
+	sink, err = get(t, g, "k1")
+	core.AssertNoError(t, err, "second Get")
+	core.AssertEqual(t, "value-k1", string(sink.Bytes()), "second Get")
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+
+	err = g.Set(context.Background(), "k2", []byte("stored"), time.Time{}, cache.MainCache)
+	core.AssertNoError(t, err, "Set")
+
+	sink, err = get(t, g, "k2")
+	core.AssertNoError(t, err, "Get after Set")
+	core.AssertEqual(t, "stored", string(sink.Bytes()), "Get after Set")
+	core.AssertTrue(t, sink.Expire().IsZero(), "no expiration")
+
+	stats := g.Stats(cache.MainCache)
+	core.AssertEqual(t, int64(3), stats.Gets, "gets")
+	core.AssertEqual(t, int64(2), stats.Hits, "hits")
+}
+
+func runTestCacheExpiry(t *testing.T) {
+	m := newFakeMemcached(t)
+	g := newTestCache(t, newTestStore(t, m), "ns", new(countingGetter))
+	ctx := context.Background()
+
+	err := g.Set(ctx, "k1", []byte("v"), time.Now().Add(time.Minute), cache.MainCache)
+	core.AssertMustNoError(t, err, "Set")
+
+	ttl, ok := g.TTL(ctx, "k1")
+	core.AssertTrue(t, ok, "TTL found")
+	core.AssertTrue(t, ttl > 50*time.Second && ttl <= time.Minute, "TTL %v", ttl)
+
+	var peek cache.ByteSink
+	core.AssertNoError(t, g.Peek(ctx, "k1", &peek), "Peek")
+	core.AssertFalse(t, peek.Expire().IsZero(), "expiration read back")
+
+	err = g.Set(ctx, "k1", []byte("old"), time.Now().Add(-time.Second), cache.MainCache)
+	core.AssertNoError(t, err, "Set expired")
+	core.AssertFalse(t, m.Has("cache:ns:k1"), "expired removed")
+	core.AssertFalse(t, g.Contains(ctx, "k1"), "Contains after expiry")
+}
+
+func runTestCacheRemove(t *testing.T) {
+	m := newFakeMemcached(t)
+	g := newTestCache(t, newTestStore(t, m), "ns", new(countingGetter))
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, g.Set(ctx, "k1", []byte("v"), time.Time{}, cache.MainCache), "Set")
+	core.AssertTrue(t, g.Contains(ctx, "k1"), "Contains")
+
+	g.Remove(ctx, "k1")
+	core.AssertFalse(t, m.Has("cache:ns:k1"), "removed")
+
+	var peek cache.ByteSink
+	core.AssertErrorIs(t, g.Peek(ctx, "k1", &peek), cache.ErrNotFound, "Peek after Remove")
+}
+
+func runTestCacheChunked(t *testing.T) {
+	m := newFakeMemcached(t)
+	getter := new(countingGetter)
+	g := newTestCache(t, newTestStore(t, m), "ns", getter)
+	ctx := context.Background()
+
+	value := bytes.Repeat([]byte("0123456789"), 200)
+	core.AssertMustNoError(t, g.Set(ctx, "big", value, time.Time{}, cache.MainCache), "Set")
+
+	// manifest plus four chunks
+	core.AssertEqual(t, 5, len(m.Keys()), "items")
+
+	sink, err := get(t, g, "big")
+	core.AssertNoError(t, err, "Get")
+	core.AssertSliceEqual(t, value, sink.Bytes(), "Get")
+	core.AssertEqual(t, int32(0), getter.calls.Load(), "getter calls")
+}
+
+func runTestCacheHashedKeys(t *testing.T) {
+	m := newFakeMemcached(t)
+	g := newTestCache(t, newTestStore(t, m), "ns", new(countingGetter))
+	ctx := context.Background()
+
+	long := strings.Repeat("x", maxKeyLen)
+	keys := []string{long, "with space", "#hashed"}
+	for _, key := range keys {
+		core.AssertMustNoError(t, g.Set(ctx, key, []byte(key), time.Time{}, cache.MainCache), "Set %q", key)
+	}
+
+	// a user key equal to the hashed form of another
+	fake := strings.TrimPrefix(itemKey(g.ns, long), g.ns)
+	core.AssertMustNoError(t, g.Set(ctx, fake, []byte("fake"), time.Time{}, cache.MainCache), "Set fake")
+
+	for _, key := range keys {
+		sink, err := get(t, g, key)
+		core.AssertNoError(t, err, "Get %q", key)
+		core.AssertEqual(t, key, string(sink.Bytes()), "Get %q", key)
+	}
+
+	for _, k := range m.Keys() {
+		core.AssertTrue(t, len(k) <= maxKeyLen && validKey(k), "valid memcached key %q", k)
+	}
+	core.AssertFalse(t, m.Has("cache:ns:#hashed"), "'#' keys hashed")
+}
+
+func runTestCacheSharedLoad(t *testing.T) {
+	m := newFakeMemcached(t)
+	getter := &countingGetter{delay: 50 * time.Millisecond}
+
+	// two clients sharing the server
+	caches := []*Cache{
+		newTestCache(t, newTestStore(t, m), "ns", getter),
+		newTestCache(t, newTestStore(t, m), "ns", getter),
+	}
+
+	var wg sync.WaitGroup
+	for i := range 6 {
+		wg.Add(1)
+		go func(g *Cache) {
+			defer wg.Done()
+
+			sink, err := get(t, g, "k1")
+			core.AssertNoError(t, err, "Get")
+			core.AssertEqual(t, "value-k1", string(sink.Bytes()), "Get")
+		}(caches[i%2])
+	}
+	wg.Wait()
+
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+}
+
+func TestItemKey(t *testing.T) {
+	const ns = "cache:ns:"
+
+	core.AssertEqual(t, ns+"plain", itemKey(ns, "plain"), "plain key")
+
+	hashed := itemKey(ns, "#plain")
+	core.AssertTrue(t, strings.HasPrefix(hashed, ns+"#"), "'#' key hashed")
+	core.AssertNotEqual(t, hashed, itemKey(ns, strings.TrimPrefix(hashed, ns)), "hash of a hash")
+
+	// items and chunks never share a hash input
+	core.AssertNotEqual(t, hashedKey(ns, hashItem+"k"), hashedKey(ns, hashChunk+"k"), "domains")
+	core.AssertNotEqual(t, chunkKey(ns, "k", "t", 0), chunkKey(ns, "k", "t", 1), "chunks")
+}
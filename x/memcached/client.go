@@ -0,0 +1,152 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+// server is a pool of connections to one memcached server
+type server struct {
+	addr   string
+	dialer net.Dialer
+	idle   chan *conn
+}
+
+type conn struct {
+	nc net.Conn
+	r  *bufio.Reader
+	w  *bufio.Writer
+}
+
+func newServer(addr string, cfg *Config) *server {
+	return &server{
+		addr:   addr,
+		dialer: net.Dialer{Timeout: cfg.DialTimeout},
+		idle:   make(chan *conn, cfg.MaxIdle),
+	}
+}
+
+// network tells if the address is a Unix socket or TCP
+func (s *server) network() string {
+	if strings.Contains(s.addr, "/") {
+		return "unix"
+	}
+	return "tcp"
+}
+
+func (s *server) get(ctx context.Context) (*conn, error) {
+	select {
+	case c := <-s.idle:
+		return c, nil
+	default:
+		nc, err := s.dialer.DialContext(ctx, s.network(), s.addr)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &conn{
+			nc: nc,
+			r:  bufio.NewReader(nc),
+			w:  bufio.NewWriter(nc),
+		}
+		return c, nil
+	}
+}
+
+func (s *server) put(c *conn) {
+	_ = c.nc.SetDeadline(time.Time{})
+
+	select {
+	case s.idle <- c:
+	default:
+		_ = c.nc.Close()
+	}
+}
+
+// close closes all idle connections
+func (s *server) close() {
+	for {
+		select {
+		case c := <-s.idle:
+			_ = c.nc.Close()
+		default:
+			return
+		}
+	}
+}
+
+// do runs a command on a pooled connection, honouring the context
+// deadline. Connections are discarded on any error as they could be
+// left mid-response.
+func (s *server) do(ctx context.Context, fn func(*conn) error) error {
+	c, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dl, ok := ctx.Deadline(); ok {
+		_ = c.nc.SetDeadline(dl)
+	}
+
+	if err := fn(c); err != nil {
+		_ = c.nc.Close()
+		return err
+	}
+
+	s.put(c)
+	return nil
+}
+
+// client sends meta commands to the server owning each key
+type client struct {
+	servers []*server
+}
+
+func newClient(cfg *Config) *client {
+	c := &client{
+		servers: make([]*server, len(cfg.Servers)),
+	}
+	for i, addr := range cfg.Servers {
+		c.servers[i] = newServer(addr, cfg)
+	}
+	return c
+}
+
+// pick chooses the server for a key using rendezvous hashing
+func (c *client) pick(key string) *server {
+	var best uint64
+	var out *server
+
+	for _, s := range c.servers {
+		if w := weight(s.addr, key); out == nil || w > best {
+			best, out = w, s
+		}
+	}
+	return out
+}
+
+// Meta sends a meta command and returns the parsed response
+func (c *client) Meta(ctx context.Context, cmd, key string, data []byte,
+	flags ...string) (*response, error) {
+	//
+	var res *response
+
+	err := c.pick(key).do(ctx, func(conn *conn) error {
+		err := writeRequest(conn.w, cmd, key, data, flags...)
+		if err == nil {
+			res, err = readResponse(conn.r)
+		}
+		return err
+	})
+	return res, err
+}
+
+// Close closes all idle connections
+func (c *client) Close() {
+	for _, s := range c.servers {
+		s.close()
+	}
+}
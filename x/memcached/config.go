@@ -0,0 +1,119 @@
+package memcached
+
+import (
+	"time"
+
+	"darvaza.org/core"
+)
+
+const (
+	// DefaultPrefix is the prefix used for all keys if none is given
+	DefaultPrefix = "cache:"
+	// DefaultItemSize is the default maximum size of an item on the
+	// servers, as set by memcached's -I option
+	DefaultItemSize = 1 << 20
+	// DefaultDialTimeout is how long to wait for a connection
+	DefaultDialTimeout = 5 * time.Second
+	// DefaultMaxIdle is the number of idle connections kept per server
+	DefaultMaxIdle = 4
+	// DefaultLockTTL is how long a miss placeholder lives if the
+	// loader fails to replace it
+	DefaultLockTTL = 10 * time.Second
+	// DefaultLockRetry is the period between attempts to find the
+	// value while another client is loading it
+	DefaultLockRetry = 50 * time.Millisecond
+
+	// itemOverhead is reserved on each item for the key and
+	// memcached's own header
+	itemOverhead = 512
+)
+
+// Config tunes a [Store]
+type Config struct {
+	// Servers are the addresses of the memcached servers, as
+	// host:port or the path of a Unix socket. Keys are distributed
+	// among them using rendezvous hashing.
+	Servers []string
+	// Prefix is prepended to all keys. Defaults to [DefaultPrefix].
+	Prefix string
+	// ItemSize is the maximum size of an item on the servers. Larger
+	// values are split in chunks. Defaults to [DefaultItemSize].
+	ItemSize int
+	// DialTimeout limits how long to wait for a new connection.
+	// Defaults to [DefaultDialTimeout].
+	DialTimeout time.Duration
+	// MaxIdle is the number of idle connections kept per server.
+	// Defaults to [DefaultMaxIdle].
+	MaxIdle int
+	// StaleWhileRevalidate, if set, makes the first client reading an
+	// entry within this duration of its expiration, or marked stale
+	// by Invalidate, refresh it in the background while it and
+	// everyone else keep receiving the current value.
+	StaleWhileRevalidate time.Duration
+	// LockTTL is how long a miss placeholder lives if its loader
+	// fails to replace it. Defaults to [DefaultLockTTL].
+	LockTTL time.Duration
+	// LockRetry is the period between checks while another client is
+	// loading a key. Defaults to [DefaultLockRetry].
+	LockRetry time.Duration
+	// LockWait is how long to wait for another client to load a key
+	// before loading it ourselves. Defaults to LockTTL.
+	LockWait time.Duration
+}
+
+// SetDefaults fills the gaps and identifies errors.
+func (cfg *Config) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if cfg.ItemSize == 0 {
+		cfg.ItemSize = DefaultItemSize
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	if cfg.MaxIdle == 0 {
+		cfg.MaxIdle = DefaultMaxIdle
+	}
+
+	cfg.setLockDefaults()
+	return cfg.validate()
+}
+
+func (cfg *Config) setLockDefaults() {
+	if cfg.LockTTL == 0 {
+		cfg.LockTTL = DefaultLockTTL
+	}
+	if cfg.LockRetry == 0 {
+		cfg.LockRetry = DefaultLockRetry
+	}
+	if cfg.LockWait == 0 {
+		cfg.LockWait = cfg.LockTTL
+	}
+}
+
+func (cfg *Config) validate() error {
+	switch {
+	case len(cfg.Servers) == 0:
+		return core.Wrap(core.ErrInvalid, "no servers")
+	case !validKey(cfg.Prefix) || len(cfg.Prefix) > maxPrefixLen:
+		return core.Wrap(core.ErrInvalid, "invalid prefix")
+	case cfg.ItemSize < 2*itemOverhead:
+		return core.Wrap(core.ErrInvalid, "item size too small")
+	case cfg.LockTTL < time.Second, cfg.LockRetry < 0, cfg.LockWait < 0:
+		return core.Wrap(core.ErrInvalid, "invalid lock timing")
+	case cfg.StaleWhileRevalidate < 0:
+		return core.Wrap(core.ErrInvalid, "negative stale-while-revalidate")
+	default:
+		return nil
+	}
+}
+
+// chunkSize is the maximum value stored on a single item
+func (cfg *Config) chunkSize() int {
+	return cfg.ItemSize - itemOverhead
+}
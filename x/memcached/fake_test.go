@@ -0,0 +1,249 @@
+package memcached
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// fakeMemcached is an in-process memcached server supporting the
+// subset of the meta protocol used by [Cache]
+type fakeMemcached struct {
+	mu    sync.Mutex
+	ln    net.Listener
+	items map[string]*fakeItem
+}
+
+type fakeItem struct {
+	value  []byte
+	flags  int
+	expire time.Time
+	won    bool
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "Listen")
+
+	m := &fakeMemcached{
+		ln:    ln,
+		items: make(map[string]*fakeItem),
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go m.serve()
+	return m
+}
+
+// Addr returns the address to connect to the server
+func (m *fakeMemcached) Addr() string {
+	return m.ln.Addr().String()
+}
+
+// Keys returns the keys of the items stored, expired or not
+func (m *fakeMemcached) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.items))
+	for k := range m.items {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Has tells if there is an item for the given key, expired or not
+func (m *fakeMemcached) Has(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.items[key]
+	return ok
+}
+
+func (m *fakeMemcached) serve() {
+	for {
+		c, err := m.ln.Accept()
+		if err != nil {
+			return
+		}
+		go m.serveConn(c)
+	}
+}
+
+func (m *fakeMemcached) serveConn(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return
+		}
+
+		args := strings.Fields(line)
+		switch {
+		case len(args) < 2:
+			_, _ = w.WriteString("ERROR\r\n")
+		case args[0] == "mg":
+			m.doGet(w, args[1], args[2:])
+		case args[0] == "ms":
+			if !m.doSet(r, w, args[1:]) {
+				return
+			}
+		case args[0] == "md":
+			m.doDelete(w, args[1])
+		default:
+			_, _ = w.WriteString("ERROR\r\n")
+		}
+
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// get returns a live item, dropping it if expired
+func (m *fakeMemcached) get(key string) (*fakeItem, bool) {
+	it, ok := m.items[key]
+	if ok && !it.expire.IsZero() && !time.Now().Before(it.expire) {
+		delete(m.items, key)
+		return nil, false
+	}
+	return it, ok
+}
+
+func (m *fakeMemcached) doGet(w *bufio.Writer, key string, flags []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.get(key)
+	if !ok {
+		ttl, vivify := fakeFlag(flags, 'N')
+		if !vivify {
+			_, _ = w.WriteString("EN\r\n")
+			return
+		}
+
+		// placeholder won by this caller
+		it = &fakeItem{expire: time.Now().Add(ttl), won: true}
+		m.items[key] = it
+		flags = append(flags, "W")
+	} else if it.flags == 0 && it.won {
+		flags = append(flags, "Z")
+	}
+
+	out := make([]string, 0, len(flags))
+	for _, f := range flags {
+		switch f[0] {
+		case 't':
+			out = append(out, "t"+strconv.FormatInt(fakeTTL(it.expire), 10))
+		case 'f':
+			out = append(out, "f"+strconv.Itoa(it.flags))
+		case 'W', 'Z':
+			out = append(out, f)
+		}
+	}
+
+	if _, ok := fakeFlag(flags, 'v'); ok {
+		_, _ = w.WriteString("VA " + strconv.Itoa(len(it.value)))
+		writeFakeFlags(w, out)
+		_, _ = w.Write(it.value)
+		_, _ = w.Write(crlf)
+	} else {
+		_, _ = w.WriteString("HD")
+		writeFakeFlags(w, out)
+	}
+}
+
+func (m *fakeMemcached) doSet(r *bufio.Reader, w *bufio.Writer, args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 0 {
+		return false
+	}
+
+	b := make([]byte, n+2)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return false
+	}
+
+	it := &fakeItem{value: b[:n]}
+	if ttl, ok := fakeFlag(args[2:], 'T'); ok && ttl > 0 {
+		it.expire = time.Now().Add(ttl)
+	}
+	if s, ok := fakeToken(args[2:], 'F'); ok {
+		it.flags, _ = strconv.Atoi(s)
+	}
+
+	m.mu.Lock()
+	m.items[args[0]] = it
+	m.mu.Unlock()
+
+	_, _ = w.WriteString("HD\r\n")
+	return true
+}
+
+func (m *fakeMemcached) doDelete(w *bufio.Writer, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.get(key); !ok {
+		_, _ = w.WriteString("NF\r\n")
+		return
+	}
+
+	delete(m.items, key)
+	_, _ = w.WriteString("HD\r\n")
+}
+
+func writeFakeFlags(w *bufio.Writer, flags []string) {
+	for _, f := range flags {
+		_ = w.WriteByte(' ')
+		_, _ = w.WriteString(f)
+	}
+	_, _ = w.Write(crlf)
+}
+
+// fakeToken returns the token of a request flag
+func fakeToken(flags []string, c byte) (string, bool) {
+	for _, f := range flags {
+		if f != "" && f[0] == c {
+			return f[1:], true
+		}
+	}
+	return "", false
+}
+
+// fakeFlag returns the token of a request flag as seconds
+func fakeFlag(flags []string, c byte) (time.Duration, bool) {
+	s, ok := fakeToken(flags, c)
+	if !ok {
+		return 0, false
+	}
+
+	n, _ := strconv.Atoi(s)
+	return time.Duration(n) * time.Second, true
+}
+
+// fakeTTL returns the remaining seconds of an item, or -1 if it
+// doesn't expire
+func fakeTTL(expire time.Time) int64 {
+	if expire.IsZero() {
+		return -1
+	}
+	return int64((time.Until(expire) + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,160 @@
+package memcached
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// item is the outcome of reading an entry
+type item struct {
+	value  []byte
+	expire time.Time
+
+	// found indicates value holds an entry
+	found bool
+	// placeholder indicates the entry is missing and another
+	// client is loading it, unless won
+	placeholder bool
+	// won indicates we have been chosen to load or refresh the entry
+	won bool
+	// stale indicates the entry was marked stale by Invalidate
+	stale bool
+}
+
+func newToken() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// fetch reads an entry, assembling its chunks if needed
+func (g *Cache) fetch(ctx context.Context, key string, flags ...string) (*item, error) {
+	flags = append([]string{"v", "t", "f"}, flags...)
+	res, err := g.c.Meta(ctx, "mg", itemKey(g.ns, key), nil, flags...)
+	switch {
+	case err != nil:
+		return nil, err
+	case res.status == statusMiss:
+		return &item{}, nil
+	case res.status != statusValue:
+		return nil, ErrProtocol
+	}
+
+	it := &item{
+		expire: res.Expire(),
+		won:    res.Has('W'),
+		stale:  res.Has('X'),
+	}
+
+	switch res.ClientFlags() {
+	case itemPlain:
+		it.value, it.found = res.value, true
+	case itemChunked:
+		return g.fetchChunked(ctx, key, it, res.value)
+	default:
+		// vivified on miss
+		it.placeholder = true
+	}
+	return it, nil
+}
+
+// fetchChunked reads the chunks listed by a manifest. If any is missing
+// the entry is removed and reported as missing.
+func (g *Cache) fetchChunked(ctx context.Context, key string, it *item, manifest []byte) (*item, error) {
+	var token string
+	var count, size int
+
+	_, err := fmt.Sscanf(string(manifest), "%s %d %d", &token, &count, &size)
+	if err != nil || count < 1 || size < 0 {
+		return g.dropBroken(ctx, key, "bad manifest")
+	}
+
+	value := make([]byte, 0, size)
+	for i := 0; i < count; i++ {
+		res, err := g.c.Meta(ctx, "mg", chunkKey(g.ns, key, token, i), nil, "v")
+		switch {
+		case err != nil:
+			return nil, err
+		case res.status != statusValue:
+			return g.dropBroken(ctx, key, "missing chunk")
+		}
+		value = append(value, res.value...)
+	}
+
+	if len(value) != size {
+		return g.dropBroken(ctx, key, "size mismatch")
+	}
+
+	it.value, it.found = value, true
+	return it, nil
+}
+
+func (g *Cache) dropBroken(ctx context.Context, key, reason string) (*item, error) {
+	g.logWarn(key, reason)
+	if err := g.delete(ctx, key); err != nil {
+		return nil, err
+	}
+	return &item{}, nil
+}
+
+// store writes an entry, splitting values larger than an item in
+// chunks, written before their manifest
+func (g *Cache) store(ctx context.Context, key string, value []byte, expire time.Time) error {
+	ttl, ok := exptime(expire, time.Now())
+	if !ok {
+		return g.delete(ctx, key)
+	}
+
+	exp := intFlag('T', ttl)
+	size := g.cfg.chunkSize()
+	if len(value) <= size {
+		return g.set(ctx, itemKey(g.ns, key), value, exp, intFlag('F', itemPlain))
+	}
+
+	token := newToken()
+	count := (len(value) + size - 1) / size
+	for i := 0; i < count; i++ {
+		chunk := value[i*size : min((i+1)*size, len(value))]
+		err := g.set(ctx, chunkKey(g.ns, key, token, i), chunk, exp, intFlag('F', itemChunk))
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest := fmt.Sprintf("%s %d %d", token, count, len(value))
+	return g.set(ctx, itemKey(g.ns, key), []byte(manifest), exp, intFlag('F', itemChunked))
+}
+
+func (g *Cache) set(ctx context.Context, k string, value []byte, flags ...string) error {
+	if value == nil {
+		value = []byte{}
+	}
+
+	res, err := g.c.Meta(ctx, "ms", k, value, flags...)
+	switch {
+	case err != nil:
+		return err
+	case res.status == statusHeader:
+		return nil
+	case res.status == statusNotStore:
+		return ErrNotStored
+	default:
+		return ErrProtocol
+	}
+}
+
+// delete removes an entry. Chunks are left to expire.
+func (g *Cache) delete(ctx context.Context, key string, flags ...string) error {
+	res, err := g.c.Meta(ctx, "md", itemKey(g.ns, key), nil, flags...)
+	switch {
+	case err != nil:
+		return err
+	case res.status == statusHeader, res.status == statusNotFound:
+		return nil
+	default:
+		return ErrProtocol
+	}
+}
@@ -0,0 +1,64 @@
+package memcached
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxKeyLen is memcached's limit on key length
+	maxKeyLen = 250
+	// maxPrefixLen limits Prefix plus namespace name, leaving room
+	// for hashed keys
+	maxPrefixLen = 128
+)
+
+// validKey tells if a string can be used as-is as a memcached key,
+// ignoring its length
+func validKey(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// Domains of the hashed keys, so entries and chunks never collide
+const (
+	hashItem  = "k\x00"
+	hashChunk = "c\x00"
+)
+
+// itemKey returns the memcached key of an entry within a namespace.
+// Keys that are too long, contain whitespace or control characters,
+// or start with '#' like hashed ones, are replaced by their SHA-256.
+func itemKey(ns, key string) string {
+	if len(ns)+len(key) <= maxKeyLen && validKey(key) && !strings.HasPrefix(key, "#") {
+		return ns + key
+	}
+	return hashedKey(ns, hashItem+key)
+}
+
+func hashedKey(ns, s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return ns + "#" + base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// chunkKey returns the memcached key of a chunk of a large value.
+// The token makes chunks of different versions distinct.
+func chunkKey(ns, key, token string, i int) string {
+	return hashedKey(ns, hashChunk+key+"\x00"+token+"\x00"+strconv.Itoa(i))
+}
+
+// weight is the rendezvous hashing score of a server for a key
+func weight(addr, key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(addr))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
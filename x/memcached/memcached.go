@@ -0,0 +1,4 @@
+// Package memcached provides a cache.Store backed by a fleet of
+// memcached servers, using the meta text protocol, and a Server
+// exposing any cache.Store over the memcached text protocol
+package memcached
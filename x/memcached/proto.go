@@ -0,0 +1,213 @@
+package memcached
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// Response statuses of the meta protocol
+const (
+	statusValue    = "VA"
+	statusHeader   = "HD"
+	statusMiss     = "EN"
+	statusNotFound = "NF"
+	statusNotStore = "NS"
+	statusExists   = "EX"
+)
+
+// Client flags stored with our items, to tell them apart from
+// placeholders created by the vivify-on-miss flag
+const (
+	itemPlain   = 1
+	itemChunked = 2
+	itemChunk   = 3
+)
+
+// maxRelativeExptime is the longest expiration memcached accepts
+// as relative seconds, longer ones are Unix timestamps
+const maxRelativeExptime = 30 * 24 * time.Hour
+
+var (
+	// ErrProtocol indicates an unexpected or error response
+	// from a server
+	ErrProtocol = core.Wrap(core.ErrInvalid, "memcached protocol error")
+
+	// ErrNotStored indicates the server refused to store an item
+	ErrNotStored = core.Wrap(core.ErrInvalid, "not stored")
+
+	crlf = []byte("\r\n")
+)
+
+// response is a parsed meta protocol response
+type response struct {
+	status string
+	flags  []string
+	value  []byte
+}
+
+// Flag returns the token of a returned flag, and if it was present
+func (r *response) Flag(c byte) (string, bool) {
+	for _, f := range r.flags {
+		if f != "" && f[0] == c {
+			return f[1:], true
+		}
+	}
+	return "", false
+}
+
+// Has tells if a flag was returned
+func (r *response) Has(c byte) bool {
+	_, ok := r.Flag(c)
+	return ok
+}
+
+// ClientFlags returns the client flags of the item
+func (r *response) ClientFlags() int {
+	s, _ := r.Flag('f')
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// Expire returns the expiration date of the item based on the
+// remaining TTL, or zero if it doesn't expire
+func (r *response) Expire() time.Time {
+	s, ok := r.Flag('t')
+	if !ok {
+		return time.Time{}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(n) * time.Second)
+}
+
+// TTL returns the remaining TTL of the item, zero if it doesn't expire
+func (r *response) TTL() time.Duration {
+	if ex := r.Expire(); !ex.IsZero() {
+		return time.Until(ex)
+	}
+	return 0
+}
+
+// writeRequest writes a meta command, and its data block if given
+func writeRequest(w *bufio.Writer, cmd, key string, data []byte, flags ...string) error {
+	_, _ = w.WriteString(cmd)
+	_ = w.WriteByte(' ')
+	_, _ = w.WriteString(key)
+	if data != nil {
+		_ = w.WriteByte(' ')
+		_, _ = w.WriteString(strconv.Itoa(len(data)))
+	}
+	for _, f := range flags {
+		_ = w.WriteByte(' ')
+		_, _ = w.WriteString(f)
+	}
+	_, _ = w.Write(crlf)
+
+	if data != nil {
+		_, _ = w.Write(data)
+		_, _ = w.Write(crlf)
+	}
+	return w.Flush()
+}
+
+// readLine reads a CRLF terminated line
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	switch {
+	case err == bufio.ErrBufferFull:
+		return "", core.Wrap(ErrProtocol, "line too long")
+	case err != nil:
+		return "", err
+	case !bytes.HasSuffix(line, crlf):
+		return "", core.Wrap(ErrProtocol, "bad line ending")
+	default:
+		return string(line[:len(line)-2]), nil
+	}
+}
+
+// readResponse reads and parses a meta command response
+func readResponse(r *bufio.Reader) (*response, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, core.Wrap(ErrProtocol, "empty response")
+	}
+
+	switch fields[0] {
+	case statusValue:
+		return readValue(r, fields)
+	case statusHeader, statusMiss, statusNotFound, statusNotStore, statusExists:
+		return &response{status: fields[0], flags: fields[1:]}, nil
+	default:
+		// ERROR, CLIENT_ERROR and SERVER_ERROR
+		return nil, core.Wrap(ErrProtocol, line)
+	}
+}
+
+func readValue(r *bufio.Reader, fields []string) (*response, error) {
+	if len(fields) < 2 {
+		return nil, core.Wrap(ErrProtocol, "missing value size")
+	}
+
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n < 0 {
+		return nil, core.Wrap(ErrProtocol, "bad value size")
+	}
+
+	b := make([]byte, n+2)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasSuffix(b, crlf) {
+		return nil, core.Wrap(ErrProtocol, "bad data block")
+	}
+
+	return &response{
+		status: statusValue,
+		flags:  fields[2:],
+		value:  b[:n],
+	}, nil
+}
+
+// exptime converts an expiration date into memcached's exptime,
+// rounding up to whole seconds, and returns false if it has
+// already expired
+func exptime(expire, now time.Time) (int64, bool) {
+	if expire.IsZero() {
+		return 0, true
+	}
+
+	d := expire.Sub(now)
+	switch {
+	case d <= 0:
+		return 0, false
+	case d > maxRelativeExptime:
+		return expire.Add(time.Second - 1).Unix(), true
+	default:
+		return int64((d + time.Second - 1) / time.Second), true
+	}
+}
+
+// intFlag formats a flag with a numeric token
+func intFlag(c byte, n int64) string {
+	return string(c) + strconv.FormatInt(n, 10)
+}
+
+// seconds converts a duration into whole seconds, rounding up
+func seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,265 @@
+package memcached
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+const (
+	// DefaultSeparator separates the namespace from the key
+	// in server requests
+	DefaultSeparator = ":"
+	// DefaultMaxValueSize is the largest value accepted by the server
+	DefaultMaxValueSize = DefaultItemSize
+
+	serverVersion = "darvaza-cache"
+)
+
+// ServerConfig tunes a [Server]
+type ServerConfig struct {
+	// Separator splits request keys into namespace and key.
+	// Defaults to [DefaultSeparator].
+	Separator string
+	// MaxValueSize is the largest value accepted by set.
+	// Defaults to [DefaultMaxValueSize].
+	MaxValueSize int
+	// IdleTimeout closes connections without requests for this long.
+	// Zero means never.
+	IdleTimeout time.Duration
+	// ReadThrough makes get reach out to the [cache.Getter] of the
+	// namespace on a miss. Otherwise only the cached entries are
+	// returned if the namespace implements [cache.Inspector].
+	ReadThrough bool
+}
+
+// SetDefaults fills the gaps and identifies errors.
+func (cfg *ServerConfig) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
+	if cfg.Separator == "" {
+		cfg.Separator = DefaultSeparator
+	}
+	if cfg.MaxValueSize == 0 {
+		cfg.MaxValueSize = DefaultMaxValueSize
+	}
+
+	switch {
+	case !validKey(cfg.Separator):
+		return core.Wrap(core.ErrInvalid, "invalid separator")
+	case cfg.MaxValueSize < 0, cfg.IdleTimeout < 0:
+		return core.Wrap(core.ErrInvalid, "negative limit")
+	default:
+		return nil
+	}
+}
+
+// Server exposes a [cache.Store] over the memcached text protocol,
+// supporting get, gets, set, delete, touch, stats, version and quit.
+// Keys are "namespace:key", and client flags aren't stored.
+type Server struct {
+	store cache.Store[string]
+	cfg   ServerConfig
+	start time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	log       slog.Logger
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	seen      map[string]struct{}
+	wg        sync.WaitGroup
+
+	totalConns atomic.Int64
+	sets       atomic.Int64
+}
+
+// NewServer creates a [Server] exposing a [cache.Store]. A nil config
+// means all defaults.
+func NewServer(store cache.Store[string], cfg *ServerConfig) (*Server, error) {
+	if store == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing store")
+	}
+
+	if cfg == nil {
+		cfg = new(ServerConfig)
+	}
+
+	if err := cfg.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		store:     store,
+		cfg:       *cfg,
+		start:     time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+		seen:      make(map[string]struct{}),
+	}
+	return s, nil
+}
+
+// ListenAndServe listens on a TCP address or Unix socket, and serves
+// until the [Server] is closed. Stale Unix sockets are replaced, but
+// any other file in the way is an error.
+func (s *Server) ListenAndServe(network, addr string) error {
+	if network == "unix" {
+		if err := removeStaleSocket(addr); err != nil {
+			return err
+		}
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on a [net.Listener] until the [Server]
+// is closed. The listener is closed on return.
+func (s *Server) Serve(ln net.Listener) error {
+	if !track(s, ln, s.listeners) {
+		_ = ln.Close()
+		return net.ErrClosed
+	}
+	defer untrack(s, ln, s.listeners)
+	defer ln.Close()
+
+	for {
+		c, err := ln.Accept()
+		switch {
+		case errors.Is(err, net.ErrClosed):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		s.wg.Add(1)
+		go s.serveConn(c)
+	}
+}
+
+// removeStaleSocket removes a Unix socket left behind by a previous
+// run, refusing to remove anything that isn't a socket
+func removeStaleSocket(filename string) error {
+	fi, err := os.Lstat(filename)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	case fi.Mode()&os.ModeSocket == 0:
+		return core.Wrapf(core.ErrExists, "%s: not a socket", filename)
+	default:
+		return os.Remove(filename)
+	}
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer s.wg.Done()
+
+	if !track(s, c, s.conns) {
+		_ = c.Close()
+		return
+	}
+	defer untrack(s, c, s.conns)
+
+	s.totalConns.Add(1)
+	newSession(s, c).Run()
+}
+
+// track registers a listener or connection, unless closed
+func track[T comparable](s *Server, v T, m map[T]struct{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return false
+	}
+	m[v] = struct{}{}
+	return true
+}
+
+func untrack[T comparable](s *Server, v T, m map[T]struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(m, v)
+}
+
+// Close stops all listeners, closes all connections and waits for
+// their sessions to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.cancel()
+	for ln := range s.listeners {
+		_ = ln.Close()
+	}
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// getCache finds the namespace of a request, remembering it for stats
+func (s *Server) getCache(ns string) cache.Cache[string] {
+	g := s.store.GetCache(ns)
+	if g != nil {
+		s.mu.Lock()
+		s.seen[ns] = struct{}{}
+		s.mu.Unlock()
+	}
+	return g
+}
+
+// namespaces returns the names of the namespaces accessed so far
+func (s *Server) namespaces() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]string, 0, len(s.seen))
+	for ns := range s.seen {
+		out = append(out, ns)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SetLogger attaches a [slog.Logger] to the [Server]
+func (s *Server) SetLogger(log slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = log
+}
+
+func (s *Server) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log != nil {
+		return s.log.WithLevel(level).WithEnabled()
+	}
+	return nil, false
+}
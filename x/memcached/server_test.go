@@ -0,0 +1,196 @@
+package memcached
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestServer(t *testing.T) {
+	t.Run("protocol", runTestServerProtocol)
+	t.Run("stale socket", runTestServerStaleSocket)
+	t.Run("not a socket", runTestServerNotSocket)
+	t.Run("serve error", runTestServerServeError)
+}
+
+// newTestServer creates a [Server] exposing a namespace "ns" kept
+// on a fake memcached
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	store := newTestStore(t, newFakeMemcached(t))
+	newTestCache(t, store, "ns", new(countingGetter))
+
+	s, err := NewServer(store, nil)
+	core.AssertMustNoError(t, err, "NewServer")
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// testConn is a text protocol client
+type testConn struct {
+	t *testing.T
+	c net.Conn
+	r *bufio.Reader
+}
+
+func dialTestConn(t *testing.T, network, addr string) *testConn {
+	t.Helper()
+
+	c, err := net.DialTimeout(network, addr, time.Second)
+	core.AssertMustNoError(t, err, "Dial")
+	t.Cleanup(func() { _ = c.Close() })
+
+	return &testConn{t: t, c: c, r: bufio.NewReader(c)}
+}
+
+// Do sends a request and returns the lines of the response up to
+// and including the one matching last
+func (tc *testConn) Do(req string, last func(string) bool) []string {
+	tc.t.Helper()
+
+	_ = tc.c.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err := tc.c.Write([]byte(req))
+	core.AssertMustNoError(tc.t, err, "Write %q", req)
+
+	var out []string
+	for {
+		line, err := readLine(tc.r)
+		core.AssertMustNoError(tc.t, err, "Read %q", req)
+
+		out = append(out, line)
+		if last(line) {
+			return out
+		}
+	}
+}
+
+// One sends a request with a single line response
+func (tc *testConn) One(req string) string {
+	tc.t.Helper()
+	return tc.Do(req, func(string) bool { return true })[0]
+}
+
+// Get sends a retrieval request
+func (tc *testConn) Get(req string) []string {
+	tc.t.Helper()
+	return tc.Do(req, func(s string) bool { return s == respEnd })
+}
+
+func serveTestListener(s *Server, ln net.Listener) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ln) }()
+	return done
+}
+
+func runTestServerProtocol(t *testing.T) {
+	s := newTestServer(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "Listen")
+	done := serveTestListener(s, ln)
+
+	tc := dialTestConn(t, "tcp", ln.Addr().String())
+	core.AssertEqual(t, "VERSION "+serverVersion, tc.One("version\r\n"), "version")
+
+	core.AssertEqual(t, respStored, tc.One("set ns:k1 0 0 5\r\nhello\r\n"), "set")
+	core.AssertSliceEqual(t, []string{"VALUE ns:k1 0 5", "hello", respEnd},
+		tc.Get("get ns:k1 ns:k2\r\n"), "get")
+
+	res := tc.Get("gets ns:k1\r\n")
+	core.AssertEqual(t, 3, len(res), "gets")
+	core.AssertTrue(t, strings.HasPrefix(res[0], "VALUE ns:k1 0 5 "), "gets CAS")
+
+	core.AssertEqual(t, respTouched, tc.One("touch ns:k1 60\r\n"), "touch")
+	core.AssertEqual(t, respNotFound, tc.One("touch ns:k2 60\r\n"), "touch missing")
+
+	core.AssertEqual(t, respDeleted, tc.One("delete ns:k1\r\n"), "delete")
+	core.AssertEqual(t, respNotFound, tc.One("delete ns:k1\r\n"), "delete missing")
+	core.AssertSliceEqual(t, []string{respEnd}, tc.Get("get ns:k1\r\n"), "get after delete")
+
+	core.AssertEqual(t, "SERVER_ERROR unknown namespace",
+		tc.One("set other:k1 0 0 1\r\nx\r\n"), "unknown namespace")
+	core.AssertEqual(t, "CLIENT_ERROR "+string(errBadDataChunk),
+		tc.One("set ns:k1 0 0 1\r\nxyz\r\n"), "bad data chunk")
+	core.AssertEqual(t, respError, tc.One("bogus\r\n"), "unknown command")
+
+	core.AssertNoError(t, s.Close(), "Close")
+	core.AssertNoError(t, <-done, "Serve")
+}
+
+func runTestServerStaleSocket(t *testing.T) {
+	s := newTestServer(t)
+	filename := filepath.Join(t.TempDir(), "mc.sock")
+
+	// leave a socket behind
+	ln, err := net.Listen("unix", filename)
+	core.AssertMustNoError(t, err, "Listen")
+	core.AssertMustTypeIs[*net.UnixListener](t, ln, "Listen").SetUnlinkOnClose(false)
+	core.AssertMustNoError(t, ln.Close(), "Close")
+
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServe("unix", filename) }()
+
+	// the stale socket refuses connections until replaced
+	for i := 0; ; i++ {
+		if c, err := net.Dial("unix", filename); err == nil {
+			_ = c.Close()
+			break
+		}
+
+		core.AssertMustTrue(t, i < 100, "socket replaced")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tc := dialTestConn(t, "unix", filename)
+	core.AssertEqual(t, "VERSION "+serverVersion, tc.One("version\r\n"), "version")
+
+	core.AssertNoError(t, s.Close(), "Close")
+	core.AssertNoError(t, <-done, "ListenAndServe")
+}
+
+func runTestServerNotSocket(t *testing.T) {
+	s := newTestServer(t)
+	filename := filepath.Join(t.TempDir(), "data")
+	core.AssertMustNoError(t, os.WriteFile(filename, []byte("keep"), 0o600), "WriteFile")
+
+	err := s.ListenAndServe("unix", filename)
+	core.AssertErrorIs(t, err, core.ErrExists, "ListenAndServe")
+
+	b, err := os.ReadFile(filename)
+	core.AssertNoError(t, err, "ReadFile")
+	core.AssertEqual(t, "keep", string(b), "file kept")
+}
+
+// failingListener fails to accept, and tells if it was closed
+type failingListener struct {
+	net.Listener
+	closed bool
+}
+
+var errAccept = errors.New("accept failed")
+
+func (*failingListener) Accept() (net.Conn, error) { return nil, errAccept }
+
+func (ln *failingListener) Close() error {
+	ln.closed = true
+	return ln.Listener.Close()
+}
+
+func runTestServerServeError(t *testing.T) {
+	s := newTestServer(t)
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	core.AssertMustNoError(t, err, "Listen")
+
+	ln := &failingListener{Listener: inner}
+	core.AssertErrorIs(t, s.Serve(ln), errAccept, "Serve")
+	core.AssertTrue(t, ln.closed, "listener closed")
+}
@@ -0,0 +1,421 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+// Responses of the text protocol
+const (
+	respEnd      = "END"
+	respStored   = "STORED"
+	respDeleted  = "DELETED"
+	respTouched  = "TOUCHED"
+	respNotFound = "NOT_FOUND"
+	respError    = "ERROR"
+)
+
+// clientError is reported to the client as CLIENT_ERROR, keeping
+// the session open
+type clientError string
+
+func (e clientError) Error() string { return string(e) }
+
+const (
+	errBadFormat    clientError = "bad command line format"
+	errBadDataChunk clientError = "bad data chunk"
+	errKeyTooLong   clientError = "key too long"
+)
+
+// toucher is implemented by caches able to change the expiration
+// date of an entry in place
+type toucher interface {
+	Touch(ctx context.Context, key string, expire time.Time) bool
+}
+
+// session serves the requests of one connection
+type session struct {
+	s *Server
+	c net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+type command func(ss *session, args []string) error
+
+var commands = map[string]command{
+	"get":     (*session).doGet,
+	"gets":    (*session).doGets,
+	"set":     (*session).doSet,
+	"delete":  (*session).doDelete,
+	"touch":   (*session).doTouch,
+	"stats":   (*session).doStats,
+	"version": (*session).doVersion,
+	"quit":    (*session).doQuit,
+}
+
+func newSession(s *Server, c net.Conn) *session {
+	return &session{
+		s: s,
+		c: c,
+		r: bufio.NewReader(c),
+		w: bufio.NewWriter(c),
+	}
+}
+
+// Run serves requests until the client quits, the connection fails,
+// or a client error leaves the stream out of sync.
+func (ss *session) Run() {
+	for {
+		if d := ss.s.cfg.IdleTimeout; d > 0 {
+			_ = ss.c.SetReadDeadline(time.Now().Add(d))
+		}
+
+		err := ss.next()
+		if err == nil {
+			err = ss.w.Flush()
+		}
+
+		if err != nil {
+			ss.close(err)
+			return
+		}
+	}
+}
+
+func (ss *session) close(err error) {
+	if err != io.EOF && !os.IsTimeout(err) {
+		if log, ok := ss.s.withLogger(slog.Debug); ok {
+			log.WithField("remote", ss.c.RemoteAddr().String()).
+				WithField(slog.ErrorFieldName, err).
+				Print("session closed")
+		}
+	}
+	_ = ss.w.Flush()
+	_ = ss.c.Close()
+}
+
+// next reads and runs one command
+func (ss *session) next() error {
+	line, err := readLine(ss.r)
+	if err != nil {
+		return err
+	}
+
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return ss.reply(respError)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return ss.reply(respError)
+	}
+
+	var ce clientError
+	err = cmd(ss, args[1:])
+	if errors.As(err, &ce) {
+		return ss.reply("CLIENT_ERROR " + ce.Error())
+	}
+	return err
+}
+
+func (ss *session) reply(s string) error {
+	_, _ = ss.w.WriteString(s)
+	_, err := ss.w.Write(crlf)
+	return err
+}
+
+// context returns the context of a request
+func (ss *session) context() context.Context {
+	return ss.s.ctx
+}
+
+// split separates the namespace of a key, and finds its [cache.Cache]
+func (ss *session) split(key string) (cache.Cache[string], string, error) {
+	if len(key) > maxKeyLen {
+		return nil, "", errKeyTooLong
+	}
+
+	ns, k, ok := strings.Cut(key, ss.s.cfg.Separator)
+	if !ok {
+		return nil, "", nil
+	}
+	return ss.s.getCache(ns), k, nil
+}
+
+func (ss *session) doGet(args []string) error  { return ss.get(args, false) }
+func (ss *session) doGets(args []string) error { return ss.get(args, true) }
+
+// revive:disable:flag-parameter
+
+func (ss *session) get(keys []string, withCAS bool) error {
+	// revive:enable:flag-parameter
+	if len(keys) == 0 {
+		return errBadFormat
+	}
+
+	for _, key := range keys {
+		v, ex, ok, err := ss.read(key)
+		switch {
+		case err != nil:
+			return err
+		case ok:
+			ss.writeValue(key, v, ex, withCAS)
+		}
+	}
+	return ss.reply(respEnd)
+}
+
+func (ss *session) writeValue(key string, v []byte, ex time.Time, withCAS bool) {
+	if withCAS {
+		_, _ = fmt.Fprintf(ss.w, "VALUE %s 0 %d %d\r\n", key, len(v), casUnique(v, ex))
+	} else {
+		_, _ = fmt.Fprintf(ss.w, "VALUE %s 0 %d\r\n", key, len(v))
+	}
+	_, _ = ss.w.Write(v)
+	_, _ = ss.w.Write(crlf)
+}
+
+// read finds an entry, only reaching out to the [cache.Getter] if
+// ReadThrough is set or the [cache.Cache] can't be inspected
+func (ss *session) read(key string) ([]byte, time.Time, bool, error) {
+	g, k, err := ss.split(key)
+	if err != nil || g == nil {
+		return nil, time.Time{}, false, err
+	}
+
+	var dest cache.ByteSink
+	if in, ok := g.(cache.Inspector[string]); ok && !ss.s.cfg.ReadThrough {
+		err = in.Peek(ss.context(), k, &dest)
+	} else {
+		err = g.Get(ss.context(), k, &dest)
+	}
+
+	ex := dest.Expire()
+	if err != nil || (!ex.IsZero() && !time.Now().Before(ex)) {
+		return nil, time.Time{}, false, nil
+	}
+	return dest.Bytes(), ex, true, nil
+}
+
+// casUnique derives a CAS value from the entry, as the backends
+// don't keep one
+func casUnique(v []byte, ex time.Time) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(v)
+	_, _ = h.Write([]byte(ex.String()))
+	return h.Sum64() | 1
+}
+
+// doSet handles set <key> <flags> <exptime> <bytes> [noreply]
+func (ss *session) doSet(args []string) error {
+	if len(args) < 4 || len(args) > 5 {
+		return errBadFormat
+	}
+
+	n, err := strconv.Atoi(args[3])
+	if err != nil || n < 0 {
+		return errBadFormat
+	}
+
+	if n > ss.s.cfg.MaxValueSize {
+		return ss.discard(n)
+	}
+
+	data, err := ss.readData(n)
+	if err != nil {
+		return err
+	}
+
+	ss.s.sets.Add(1)
+	res := ss.set(args[0], args[1], args[2], data)
+	return ss.maybeReply(args[4:], res)
+}
+
+// readData reads a data block
+func (ss *session) readData(n int) ([]byte, error) {
+	b := make([]byte, n+2)
+	if _, err := io.ReadFull(ss.r, b); err != nil {
+		return nil, err
+	}
+
+	if string(b[n:]) != "\r\n" {
+		if b[n+1] != '\n' {
+			// resync at the end of the line
+			err := ss.skipLine()
+			return nil, core.CoalesceError(err, errBadDataChunk)
+		}
+		return nil, errBadDataChunk
+	}
+	return b[:n], nil
+}
+
+func (ss *session) skipLine() error {
+	for {
+		_, err := ss.r.ReadSlice('\n')
+		if err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+}
+
+// discard skips a data block too large to be stored
+func (ss *session) discard(n int) error {
+	if _, err := ss.r.Discard(n + 2); err != nil {
+		return err
+	}
+	return ss.reply("SERVER_ERROR object too large for cache")
+}
+
+func (ss *session) set(key, flags, exp string, data []byte) string {
+	g, k, err := ss.split(key)
+	switch {
+	case err != nil:
+		return "CLIENT_ERROR " + err.Error()
+	case g == nil:
+		return "SERVER_ERROR unknown namespace"
+	}
+
+	ex, expired, err := parseExptime(exp, flags)
+	switch {
+	case err != nil:
+		return "CLIENT_ERROR " + err.Error()
+	case expired:
+		g.Remove(ss.context(), k)
+		return respStored
+	}
+
+	if err := g.Set(ss.context(), k, data, ex, cache.MainCache); err != nil {
+		return "SERVER_ERROR " + err.Error()
+	}
+	return respStored
+}
+
+// maybeReply sends a reply unless noreply was requested
+func (ss *session) maybeReply(args []string, res string) error {
+	switch {
+	case len(args) == 0:
+		return ss.reply(res)
+	case args[0] == "noreply":
+		return nil
+	default:
+		return errBadFormat
+	}
+}
+
+// doDelete handles delete <key> [noreply]
+func (ss *session) doDelete(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errBadFormat
+	}
+
+	g, k, err := ss.split(args[0])
+	switch {
+	case err != nil:
+		return err
+	case g == nil:
+		return ss.maybeReply(args[1:], respNotFound)
+	}
+
+	res := respDeleted
+	if in, ok := g.(cache.Inspector[string]); ok && !in.Contains(ss.context(), k) {
+		res = respNotFound
+	}
+
+	g.Remove(ss.context(), k)
+	return ss.maybeReply(args[1:], res)
+}
+
+// doTouch handles touch <key> <exptime> [noreply]
+func (ss *session) doTouch(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errBadFormat
+	}
+
+	ex, expired, err := parseExptime(args[1], "0")
+	if err != nil {
+		return err
+	}
+
+	g, k, err := ss.split(args[0])
+	switch {
+	case err != nil:
+		return err
+	case g == nil:
+		return ss.maybeReply(args[2:], respNotFound)
+	case expired:
+		g.Remove(ss.context(), k)
+		return ss.maybeReply(args[2:], respTouched)
+	}
+
+	res := respNotFound
+	if ss.touch(g, k, ex) {
+		res = respTouched
+	}
+	return ss.maybeReply(args[2:], res)
+}
+
+// touch changes the expiration date of an entry, in place if supported
+// or by storing it again
+func (ss *session) touch(g cache.Cache[string], key string, ex time.Time) bool {
+	ctx := ss.context()
+	if t, ok := g.(toucher); ok {
+		return t.Touch(ctx, key, ex)
+	}
+
+	in, ok := g.(cache.Inspector[string])
+	if !ok {
+		return false
+	}
+
+	var dest cache.ByteSink
+	if err := in.Peek(ctx, key, &dest); err != nil {
+		return false
+	}
+	return g.Set(ctx, key, dest.Bytes(), ex, cache.MainCache) == nil
+}
+
+// parseExptime converts memcached's exptime into an expiration date,
+// and tells if it has already expired. The flags are only validated.
+func parseExptime(exp, flags string) (time.Time, bool, error) {
+	if _, err := strconv.ParseUint(flags, 10, 32); err != nil {
+		return time.Time{}, false, errBadFormat
+	}
+
+	n, err := strconv.ParseInt(exp, 10, 64)
+	switch {
+	case err != nil:
+		return time.Time{}, false, errBadFormat
+	case n == 0:
+		return time.Time{}, false, nil
+	case n < 0:
+		return time.Time{}, true, nil
+	case n <= int64(maxRelativeExptime/time.Second):
+		return time.Now().Add(time.Duration(n) * time.Second), false, nil
+	default:
+		ex := time.Unix(n, 0)
+		return ex, !time.Now().Before(ex), nil
+	}
+}
+
+func (ss *session) doVersion([]string) error {
+	return ss.reply("VERSION " + serverVersion)
+}
+
+func (*session) doQuit([]string) error {
+	return io.EOF
+}
@@ -0,0 +1,80 @@
+package memcached
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"darvaza.org/cache"
+)
+
+// doStats handles stats [namespace]. Without arguments it reports the
+// server and the sum of the namespaces accessed so far, otherwise
+// the given namespace.
+func (ss *session) doStats(args []string) error {
+	switch len(args) {
+	case 0:
+		ss.writeServerStats()
+		ss.writeCacheStats(ss.s.sumStats())
+	case 1:
+		g := ss.s.store.GetCache(args[0])
+		if g == nil {
+			return ss.reply("SERVER_ERROR unknown namespace")
+		}
+		ss.writeCacheStats(g.Stats(cache.MainCache))
+	default:
+		return errBadFormat
+	}
+	return ss.reply(respEnd)
+}
+
+func (ss *session) writeServerStats() {
+	s := ss.s
+	now := time.Now()
+
+	ss.stat("pid", os.Getpid())
+	ss.stat("uptime", int64(now.Sub(s.start)/time.Second))
+	ss.stat("time", now.Unix())
+	ss.stat("version", serverVersion)
+	ss.stat("curr_connections", s.currConns())
+	ss.stat("total_connections", s.totalConns.Load())
+	ss.stat("cmd_set", s.sets.Load())
+}
+
+// writeCacheStats maps [cache.Stats] to memcached's names
+func (ss *session) writeCacheStats(st cache.Stats) {
+	ss.stat("cmd_get", st.Gets)
+	ss.stat("get_hits", st.Hits)
+	ss.stat("get_misses", st.Gets-st.Hits)
+	ss.stat("curr_items", st.Items)
+	ss.stat("bytes", st.Bytes)
+	ss.stat("evictions", st.Evictions)
+}
+
+func (ss *session) stat(name string, value any) {
+	_, _ = fmt.Fprintf(ss.w, "STAT %s %v\r\n", name, value)
+}
+
+// sumStats adds the statistics of all namespaces accessed so far
+func (s *Server) sumStats() cache.Stats {
+	var out cache.Stats
+
+	for _, ns := range s.namespaces() {
+		if g := s.store.GetCache(ns); g != nil {
+			st := g.Stats(cache.MainCache)
+			out.Bytes += st.Bytes
+			out.Items += st.Items
+			out.Gets += st.Gets
+			out.Hits += st.Hits
+			out.Evictions += st.Evictions
+		}
+	}
+	return out
+}
+
+func (s *Server) currConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
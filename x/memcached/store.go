@@ -0,0 +1,133 @@
+package memcached
+
+import (
+	"strings"
+	"sync"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Store[string] = (*Store)(nil)
+)
+
+// Store manages [cache.Cache] namespaces on a fleet of memcached
+// servers, as key prefixes
+type Store struct {
+	mu  sync.Mutex
+	c   *client
+	cfg Config
+	log slog.Logger
+	m   map[string]*Cache
+}
+
+// New creates a new [Store] using the given config. Connections are
+// established on demand.
+func New(cfg *Config) (*Store, error) {
+	if cfg == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing config")
+	}
+
+	c := *cfg
+	c.Servers = append([]string{}, cfg.Servers...)
+	if err := c.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		c:   newClient(&c),
+		cfg: c,
+		m:   make(map[string]*Cache),
+	}
+	return s, nil
+}
+
+// Close closes all idle connections
+func (s *Store) Close() error {
+	s.c.Close()
+	return nil
+}
+
+// DeregisterCache disconnects a [Cache] from the [Store]. Its entries
+// are left on the servers.
+func (s *Store) DeregisterCache(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.m, name)
+}
+
+// GetCache finds a [Cache] by name in the [Store]
+func (s *Store) GetCache(name string) cache.Cache[string] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.m[name]
+	if ok {
+		return g
+	}
+	return nil
+}
+
+// NewCache creates a new [Cache] namespace attached to the [Store].
+// Names can't contain ':', '#' or whitespace, and the size is ignored
+// as it's up to the servers' eviction policy.
+func (s *Store) NewCache(name string, _ int64, getter cache.Getter[string]) cache.Cache[string] {
+	if !s.validName(name) || getter == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.m[name]; ok {
+		core.Panicf("%s: %s", name, "cache already registered")
+	}
+
+	g := &Cache{
+		name:   name,
+		ns:     s.cfg.Prefix + name + ":",
+		c:      s.c,
+		getter: getter,
+		cfg:    &s.cfg,
+		log:    s.log,
+	}
+	s.m[name] = g
+
+	if log, ok := s.withDebug(); ok {
+		log.Printf("cache:%q created", name)
+	}
+	return g
+}
+
+func (s *Store) validName(name string) bool {
+	switch {
+	case name == "", len(s.cfg.Prefix)+len(name) > maxPrefixLen:
+		return false
+	case strings.ContainsAny(name, ":#"):
+		return false
+	default:
+		return validKey(name)
+	}
+}
+
+// SetLogger attaches a [slog.Logger] to the store and any new Cache created through it
+func (s *Store) SetLogger(log slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = log
+}
+
+func (s *Store) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	if s.log != nil {
+		return s.log.WithLevel(level).WithEnabled()
+	}
+	return nil, false
+}
+
+func (s *Store) withDebug() (slog.Logger, bool) {
+	return s.withLogger(slog.Debug)
+}
@@ -0,0 +1,154 @@
+package otelcache
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+var (
+	_ cache.Cache[string]   = (*Cache[string])(nil)
+	_ cache.Resizer         = (*Cache[string])(nil)
+	_ cache.ExtendedStatser = (*Cache[string])(nil)
+)
+
+// Cache is a [cache.Cache] instrumented with OpenTelemetry. Get calls
+// create a span telling if they were served by a local cache, waited
+// for a load in progress, loaded the value, or asked another peer,
+// with child spans for the loads and peer requests of caches like
+// those of memcache and groupcache/cluster. Its [cache.Stats] are
+// reported as metrics until closed.
+type Cache[K comparable] struct {
+	c      cache.Cache[K]
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+	in     *instruments
+	reg    metric.Registration
+}
+
+// Wrap instruments a [cache.Cache] using the given [Config]
+func Wrap[K comparable](c cache.Cache[K], cfg *Config) (*Cache[K], error) {
+	if c == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing cache")
+	}
+
+	cfg, err := prepare(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	m := cfg.meter()
+	in, err := newInstruments(m)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := cfg.attributes(c.Name())
+	reg, err := in.register(m, c, cfg.Types, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache[K]{
+		c:      c,
+		tracer: cfg.tracer(),
+		attrs:  attrs,
+		in:     in,
+		reg:    reg,
+	}, nil
+}
+
+// Unwrap returns the instrumented [cache.Cache]
+func (c *Cache[K]) Unwrap() cache.Cache[K] {
+	return c.c
+}
+
+// Close stops reporting the metrics of the [Cache]
+func (c *Cache[K]) Close() error {
+	return c.reg.Unregister()
+}
+
+// Name returns the name of the cache
+func (c *Cache[K]) Name() string {
+	return c.c.Name()
+}
+
+// Stats returns stats about the cache
+func (c *Cache[K]) Stats(t cache.Type) cache.Stats {
+	return c.c.Stats(t)
+}
+
+// ExtendedStats returns extended stats about the cache, if supported,
+// or only its basic ones otherwise
+func (c *Cache[K]) ExtendedStats(t cache.Type) cache.ExtendedStats {
+	if es, ok := c.c.(cache.ExtendedStatser); ok {
+		return es.ExtendedStats(t)
+	}
+	return cache.ExtendedStats{Stats: c.c.Stats(t)}
+}
+
+// Get reads an entry into a [cache.Sink] within a span
+func (c *Cache[K]) Get(ctx context.Context, key K, dest cache.Sink) error {
+	ctx, span := c.tracer.Start(ctx, "cache.Get",
+		trace.WithAttributes(c.attrs...))
+
+	obs := &getObserver{
+		tracer:       c.tracer,
+		attrs:        c.attrs,
+		loadDuration: c.in.loadDuration,
+	}
+
+	err := c.c.Get(cache.WithGetObserver(ctx, obs), key, dest)
+
+	if result := obs.Result(); result != "" {
+		span.SetAttributes(
+			HitKey.Bool(err == nil && result == ResultHit),
+			ResultKey.String(result))
+	}
+	endSpan(span, err)
+	return err
+}
+
+// Set adds an entry to the cache within a span
+func (c *Cache[K]) Set(ctx context.Context, key K, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	ctx, span := c.tracer.Start(ctx, "cache.Set",
+		trace.WithAttributes(c.attrs...),
+		trace.WithAttributes(TypeKey.String(cacheType.String())))
+
+	err := c.c.Set(ctx, key, value, expire, cacheType)
+	endSpan(span, err)
+	return err
+}
+
+// Remove removes an entry from the cache within a span
+func (c *Cache[K]) Remove(ctx context.Context, key K) {
+	ctx, span := c.tracer.Start(ctx, "cache.Remove",
+		trace.WithAttributes(c.attrs...))
+	defer span.End()
+
+	c.c.Remove(ctx, key)
+}
+
+// Resize changes the capacity of the cache, if supported
+func (c *Cache[K]) Resize(ctx context.Context, cacheBytes int64) (int, error) {
+	if r, ok := c.c.(cache.Resizer); ok {
+		return r.Resize(ctx, cacheBytes)
+	}
+	return 0, cache.ErrNotSupported
+}
+
+// Purge removes all entries of the cache, if supported
+func (c *Cache[K]) Purge(ctx context.Context) error {
+	if r, ok := c.c.(cache.Resizer); ok {
+		return r.Purge(ctx)
+	}
+	return cache.ErrNotSupported
+}
@@ -0,0 +1,300 @@
+package otelcache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/core"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("load and hit", runTestCacheLoadHit)
+	t.Run("wait", runTestCacheWait)
+	t.Run("peer", runTestCachePeer)
+	t.Run("unobserved", runTestCacheUnobserved)
+	t.Run("metrics", runTestCacheMetrics)
+}
+
+// testEnv holds the providers of a test and their readers
+type testEnv struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+	cfg    *Config
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		_ = mp.Shutdown(context.Background())
+	})
+
+	return &testEnv{
+		spans:  spans,
+		reader: reader,
+		cfg: &Config{
+			TracerProvider: tp,
+			MeterProvider:  mp,
+		},
+	}
+}
+
+// Spans returns the ended spans by name
+func (env *testEnv) Spans(name string) []sdktrace.ReadOnlySpan {
+	var out []sdktrace.ReadOnlySpan
+	for _, s := range env.spans.Ended() {
+		if s.Name() == name {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Metrics collects the metrics, by name
+func (env *testEnv) Metrics(t *testing.T) map[string]metricdata.Aggregation {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	core.AssertMustNoError(t, env.reader.Collect(context.Background(), &rm), "Collect")
+
+	out := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			out[m.Name] = m.Data
+		}
+	}
+	return out
+}
+
+// countingGetter returns "value-" followed by the key after an
+// optional delay, and counts how many times it was called
+type countingGetter struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (g *countingGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.calls.Add(1)
+	time.Sleep(g.delay)
+	return dest.SetBytes([]byte("value-"+key), time.Time{})
+}
+
+func newTestCache(t *testing.T, env *testEnv, getter cache.Getter[string]) *Cache[string] {
+	t.Helper()
+
+	c, err := Wrap(memcache.NewCache[string]("test", 1<<20, getter), env.cfg)
+	core.AssertMustNoError(t, err, "Wrap")
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func get(t *testing.T, g cache.Getter[string], key string) string {
+	t.Helper()
+
+	var sink cache.ByteSink
+	core.AssertNoError(t, g.Get(context.Background(), key, &sink), "Get %q", key)
+	return string(sink.Bytes())
+}
+
+// attr returns the value of an attribute of a span
+func attr(s sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func assertResult(t *testing.T, s sdktrace.ReadOnlySpan, result string, hit bool) {
+	t.Helper()
+
+	v, ok := attr(s, ResultKey)
+	core.AssertTrue(t, ok, "%s set", ResultKey)
+	core.AssertEqual(t, result, v.AsString(), "%s", ResultKey)
+
+	v, ok = attr(s, HitKey)
+	core.AssertTrue(t, ok, "%s set", HitKey)
+	core.AssertEqual(t, hit, v.AsBool(), "%s", HitKey)
+}
+
+func runTestCacheLoadHit(t *testing.T) {
+	env := newTestEnv(t)
+	c := newTestCache(t, env, new(countingGetter))
+
+	core.AssertEqual(t, "value-k1", get(t, c, "k1"), "load")
+	core.AssertEqual(t, "value-k1", get(t, c, "k1"), "hit")
+
+	gets := env.Spans("cache.Get")
+	core.AssertMustEqual(t, 2, len(gets), "cache.Get spans")
+	assertResult(t, gets[0], ResultLoad, false)
+	assertResult(t, gets[1], ResultHit, true)
+
+	loads := env.Spans("cache.load")
+	core.AssertMustEqual(t, 1, len(loads), "cache.load spans")
+	core.AssertEqual(t, gets[0].SpanContext().SpanID(), loads[0].Parent().SpanID(), "load parent")
+
+	v, ok := attr(gets[0], NameKey)
+	core.AssertTrue(t, ok, "%s set", NameKey)
+	core.AssertEqual(t, "test", v.AsString(), "%s", NameKey)
+}
+
+func runTestCacheWait(t *testing.T) {
+	env := newTestEnv(t)
+	getter := &countingGetter{delay: 100 * time.Millisecond}
+	c := newTestCache(t, env, getter)
+
+	var wg sync.WaitGroup
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// let the first one start loading
+			time.Sleep(time.Duration(i) * 20 * time.Millisecond)
+			core.AssertEqual(t, "value-k1", get(t, c, "k1"), "Get")
+		}()
+	}
+	wg.Wait()
+
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+
+	results := make(map[string]int)
+	for _, s := range env.Spans("cache.Get") {
+		v, _ := attr(s, ResultKey)
+		results[v.AsString()]++
+	}
+	core.AssertEqual(t, 1, results[ResultLoad], "loads")
+	core.AssertEqual(t, 1, results[ResultWait], "waits")
+}
+
+// peerCache pretends to get every value from another peer
+type peerCache struct {
+	cache.Cache[string]
+	err error
+}
+
+func (c *peerCache) Get(ctx context.Context, key string, dest cache.Sink) error {
+	_, done := cache.ObservePeer(ctx, "peer1")
+	done(c.err)
+	if c.err != nil {
+		return c.err
+	}
+	return dest.SetBytes([]byte("peer-"+key), time.Time{})
+}
+
+func runTestCachePeer(t *testing.T) {
+	env := newTestEnv(t)
+	inner := &peerCache{Cache: memcache.NewCache[string]("test", 1<<20, new(countingGetter))}
+
+	c, err := Wrap[string](inner, env.cfg)
+	core.AssertMustNoError(t, err, "Wrap")
+	defer c.Close()
+
+	core.AssertEqual(t, "peer-k1", get(t, c, "k1"), "Get")
+
+	inner.err = core.ErrInvalid
+	var sink cache.ByteSink
+	core.AssertErrorIs(t, c.Get(context.Background(), "k2", &sink), core.ErrInvalid, "failed Get")
+
+	gets := env.Spans("cache.Get")
+	core.AssertMustEqual(t, 2, len(gets), "cache.Get spans")
+	assertResult(t, gets[0], ResultPeer, false)
+
+	peers := env.Spans("cache.peer")
+	core.AssertMustEqual(t, 2, len(peers), "cache.peer spans")
+	core.AssertEqual(t, trace.SpanKindClient, peers[0].SpanKind(), "span kind")
+	core.AssertEqual(t, gets[0].SpanContext().SpanID(), peers[0].Parent().SpanID(), "peer parent")
+
+	v, ok := attr(peers[0], PeerKey)
+	core.AssertTrue(t, ok, "%s set", PeerKey)
+	core.AssertEqual(t, "peer1", v.AsString(), "%s", PeerKey)
+
+	core.AssertEqual(t, "Unset", peers[0].Status().Code.String(), "peer status")
+	core.AssertEqual(t, "Error", peers[1].Status().Code.String(), "failed peer status")
+	core.AssertEqual(t, "Error", gets[1].Status().Code.String(), "failed Get status")
+}
+
+// silentCache doesn't report how values are found
+type silentCache struct {
+	cache.Cache[string]
+}
+
+func (*silentCache) Get(_ context.Context, key string, dest cache.Sink) error {
+	return dest.SetBytes([]byte("silent-"+key), time.Time{})
+}
+
+func runTestCacheUnobserved(t *testing.T) {
+	env := newTestEnv(t)
+	inner := &silentCache{Cache: memcache.NewCache[string]("test", 1<<20, new(countingGetter))}
+
+	c, err := Wrap[string](inner, env.cfg)
+	core.AssertMustNoError(t, err, "Wrap")
+	defer c.Close()
+
+	core.AssertEqual(t, "silent-k1", get(t, c, "k1"), "Get")
+
+	gets := env.Spans("cache.Get")
+	core.AssertMustEqual(t, 1, len(gets), "cache.Get spans")
+
+	_, ok := attr(gets[0], HitKey)
+	core.AssertFalse(t, ok, "%s set", HitKey)
+	_, ok = attr(gets[0], ResultKey)
+	core.AssertFalse(t, ok, "%s set", ResultKey)
+}
+
+func runTestCacheMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	c := newTestCache(t, env, new(countingGetter))
+
+	get(t, c, "k1")
+	get(t, c, "k1")
+	get(t, c, "k2")
+
+	metrics := env.Metrics(t)
+
+	gets := core.AssertMustTypeIs[metricdata.Sum[int64]](t, metrics["cache.gets"], "cache.gets")
+	core.AssertMustEqual(t, 1, len(gets.DataPoints), "cache.gets points")
+	core.AssertEqual(t, int64(3), gets.DataPoints[0].Value, "cache.gets")
+
+	typ, ok := gets.DataPoints[0].Attributes.Value(TypeKey)
+	core.AssertTrue(t, ok, "%s set", TypeKey)
+	core.AssertEqual(t, "main", typ.AsString(), "%s", TypeKey)
+
+	hits := core.AssertMustTypeIs[metricdata.Sum[int64]](t, metrics["cache.hits"], "cache.hits")
+	core.AssertEqual(t, int64(1), hits.DataPoints[0].Value, "cache.hits")
+
+	items := core.AssertMustTypeIs[metricdata.Gauge[int64]](t, metrics["cache.items"], "cache.items")
+	core.AssertEqual(t, int64(2), items.DataPoints[0].Value, "cache.items")
+
+	loads := core.AssertMustTypeIs[metricdata.Sum[int64]](t, metrics["cache.loads"], "cache.loads")
+	core.AssertEqual(t, int64(2), loads.DataPoints[0].Value, "cache.loads")
+
+	d := core.AssertMustTypeIs[metricdata.Histogram[float64]](t,
+		metrics["cache.load.duration"], "cache.load.duration")
+	core.AssertMustEqual(t, 1, len(d.DataPoints), "cache.load.duration points")
+	core.AssertEqual(t, uint64(2), d.DataPoints[0].Count, "cache.load.duration count")
+
+	// no longer reported once closed
+	core.AssertMustNoError(t, c.Close(), "Close")
+	_, ok = env.Metrics(t)["cache.gets"]
+	core.AssertFalse(t, ok, "cache.gets after Close")
+}
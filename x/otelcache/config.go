@@ -0,0 +1,99 @@
+package otelcache
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+// ScopeName is the name of the instrumentation scope of the
+// tracers and meters used by this package
+const ScopeName = "darvaza.org/cache/x/otelcache"
+
+// Attribute keys used on spans and metrics
+const (
+	// NameKey is the name of the cache
+	NameKey = attribute.Key("cache.name")
+	// TypeKey is the type of cache, main or hot
+	TypeKey = attribute.Key("cache.type")
+	// HitKey tells if a Get was served by a local cache, only set
+	// if the cache reported how it was served
+	HitKey = attribute.Key("cache.hit")
+	// ResultKey tells how a Get was served: hit, wait, load or peer
+	ResultKey = attribute.Key("cache.result")
+	// PeerKey is the name of the peer asked for a value
+	PeerKey = attribute.Key("cache.peer")
+)
+
+// Config describes the instrumentation
+type Config struct {
+	// TracerProvider creates the tracers, the global one if nil
+	TracerProvider trace.TracerProvider
+	// MeterProvider creates the meters, the global one if nil
+	MeterProvider metric.MeterProvider
+	// Attributes are added to all spans and metrics
+	Attributes []attribute.KeyValue
+
+	// Types are the types of cache reported by the metrics, only
+	// [cache.MainCache] if empty, as most backends don't tell types
+	// apart and report the same values for all
+	Types []cache.Type
+}
+
+// SetDefaults fills the gaps in the [Config] and validates it
+func (cfg *Config) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
+	if cfg.TracerProvider == nil {
+		cfg.TracerProvider = otel.GetTracerProvider()
+	}
+
+	if cfg.MeterProvider == nil {
+		cfg.MeterProvider = otel.GetMeterProvider()
+	}
+
+	if len(cfg.Types) == 0 {
+		cfg.Types = []cache.Type{cache.MainCache}
+	}
+
+	for _, t := range cfg.Types {
+		if t.String() == "" {
+			return core.Wrap(core.ErrInvalid, "invalid cache type")
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) tracer() trace.Tracer {
+	return cfg.TracerProvider.Tracer(ScopeName)
+}
+
+func (cfg *Config) meter() metric.Meter {
+	return cfg.MeterProvider.Meter(ScopeName)
+}
+
+// attributes returns the [Config] attributes for a named cache
+func (cfg *Config) attributes(name string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(cfg.Attributes)+1)
+	attrs = append(attrs, cfg.Attributes...)
+	return append(attrs, NameKey.String(name))
+}
+
+// prepare copies a [Config] and fills its gaps
+func prepare(cfg *Config) (*Config, error) {
+	var c Config
+	if cfg != nil {
+		c = *cfg
+	}
+
+	if err := c.SetDefaults(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,57 @@
+package otelcache
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+var (
+	_ cache.Getter[string] = (*Getter[string])(nil)
+)
+
+// Getter is a [cache.Getter] instrumented with OpenTelemetry,
+// creating a span for every call
+type Getter[K comparable] struct {
+	g      cache.Getter[K]
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+// WrapGetter instruments a [cache.Getter], identified by name,
+// using the given [Config]
+func WrapGetter[K comparable](name string, g cache.Getter[K], cfg *Config) (*Getter[K], error) {
+	if g == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing getter")
+	}
+
+	cfg, err := prepare(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Getter[K]{
+		g:      g,
+		tracer: cfg.tracer(),
+		attrs:  cfg.attributes(name),
+	}, nil
+}
+
+// Unwrap returns the instrumented [cache.Getter]
+func (g *Getter[K]) Unwrap() cache.Getter[K] {
+	return g.g
+}
+
+// Get acquires the value of a key within a span
+func (g *Getter[K]) Get(ctx context.Context, key K, dest cache.Sink) error {
+	ctx, span := g.tracer.Start(ctx, "cache.Getter.Get",
+		trace.WithAttributes(g.attrs...))
+
+	err := g.g.Get(ctx, key, dest)
+	endSpan(span, err)
+	return err
+}
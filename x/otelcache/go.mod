@@ -0,0 +1,33 @@
+module darvaza.org/cache/x/otelcache
+
+go 1.24.0
+
+require (
+	darvaza.org/cache v0.5.0
+	darvaza.org/cache/x/memcache v0.2.0
+	darvaza.org/core v0.19.1
+	go.opentelemetry.io/otel v1.40.0
+	go.opentelemetry.io/otel/metric v1.40.0
+	go.opentelemetry.io/otel/sdk v1.40.0
+	go.opentelemetry.io/otel/sdk/metric v1.40.0
+	go.opentelemetry.io/otel/trace v1.40.0
+)
+
+require (
+	darvaza.org/cache/x/simplelru v0.3.0 // indirect
+	darvaza.org/slog v0.9.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	golang.org/x/net v0.50.0 // indirect
+	golang.org/x/sys v0.41.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
+)
+
+replace (
+	darvaza.org/cache => ../..
+	darvaza.org/cache/x/memcache => ../memcache
+	darvaza.org/cache/x/simplelru => ../simplelru
+)
@@ -0,0 +1,137 @@
+package otelcache
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+// instruments mirror the [cache.Stats] and [cache.ExtendedStats]
+// of a cache
+type instruments struct {
+	bytes     metric.Int64ObservableGauge
+	items     metric.Int64ObservableGauge
+	gets      metric.Int64ObservableCounter
+	hits      metric.Int64ObservableCounter
+	evictions metric.Int64ObservableCounter
+
+	loads      metric.Int64ObservableCounter
+	loadErrors metric.Int64ObservableCounter
+	dedupWaits metric.Int64ObservableCounter
+	peerHits   metric.Int64ObservableCounter
+	peerErrors metric.Int64ObservableCounter
+
+	loadDuration metric.Float64Histogram
+}
+
+// revive:disable:function-length
+
+func newInstruments(m metric.Meter) (*instruments, error) {
+	// revive:enable:function-length
+	var in instruments
+	var errs core.CompoundError
+
+	gauge := func(name, unit, desc string) metric.Int64ObservableGauge {
+		g, err := m.Int64ObservableGauge(name,
+			metric.WithUnit(unit), metric.WithDescription(desc))
+		errs.AppendError(err)
+		return g
+	}
+	counter := func(name, unit, desc string) metric.Int64ObservableCounter {
+		c, err := m.Int64ObservableCounter(name,
+			metric.WithUnit(unit), metric.WithDescription(desc))
+		errs.AppendError(err)
+		return c
+	}
+
+	in.bytes = gauge("cache.bytes", "By", "Bytes used by the cache entries.")
+	in.items = gauge("cache.items", "{item}", "Number of entries in the cache.")
+	in.gets = counter("cache.gets", "{get}", "Number of cache lookups.")
+	in.hits = counter("cache.hits", "{hit}", "Number of cache lookups that found an entry.")
+	in.evictions = counter("cache.evictions", "{eviction}", "Number of entries removed from the cache.")
+
+	in.loads = counter("cache.loads", "{load}", "Number of calls to the getter.")
+	in.loadErrors = counter("cache.load.errors", "{error}", "Number of calls to the getter that failed.")
+	in.dedupWaits = counter("cache.dedup.waits", "{wait}", "Number of lookups that waited for a load in progress.")
+	in.peerHits = counter("cache.peer.hits", "{hit}", "Number of values obtained from other peers.")
+	in.peerErrors = counter("cache.peer.errors", "{error}", "Number of failed requests to other peers.")
+
+	h, err := m.Float64Histogram("cache.load.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of the calls to the getter."),
+		metric.WithExplicitBucketBoundaries(latencyBoundaries()...))
+	errs.AppendError(err)
+	in.loadDuration = h
+
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+func latencyBoundaries() []float64 {
+	bounds := cache.LatencyBounds()
+	out := make([]float64, len(bounds))
+	for i, d := range bounds {
+		out[i] = d.Seconds()
+	}
+	return out
+}
+
+func (in *instruments) observables() []metric.Observable {
+	return []metric.Observable{
+		in.bytes, in.items, in.gets, in.hits, in.evictions,
+		in.loads, in.loadErrors, in.dedupWaits, in.peerHits, in.peerErrors,
+	}
+}
+
+// statser is the part of a cache.Cache used by the metrics
+type statser interface {
+	Stats(cache.Type) cache.Stats
+}
+
+// register reports the statistics of a cache on every collection
+func (in *instruments) register(m metric.Meter, c statser,
+	types []cache.Type, attrs []attribute.KeyValue) (metric.Registration, error) {
+	//
+	return m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		in.observe(o, c, types, attrs)
+		return nil
+	}, in.observables()...)
+}
+
+func (in *instruments) observe(o metric.Observer, c statser,
+	types []cache.Type, attrs []attribute.KeyValue) {
+	//
+	for _, t := range types {
+		s := c.Stats(t)
+		opt := metric.WithAttributes(slices.Concat(attrs,
+			[]attribute.KeyValue{TypeKey.String(t.String())})...)
+
+		o.ObserveInt64(in.bytes, s.Bytes, opt)
+		o.ObserveInt64(in.items, s.Items, opt)
+		o.ObserveInt64(in.gets, s.Gets, opt)
+		o.ObserveInt64(in.hits, s.Hits, opt)
+		o.ObserveInt64(in.evictions, s.Evictions, opt)
+	}
+
+	es, ok := c.(cache.ExtendedStatser)
+	if !ok || len(types) == 0 {
+		return
+	}
+
+	// load counters are shared by all types
+	s := es.ExtendedStats(types[0])
+	opt := metric.WithAttributes(attrs...)
+
+	o.ObserveInt64(in.loads, s.Loads, opt)
+	o.ObserveInt64(in.loadErrors, s.LoadErrors, opt)
+	o.ObserveInt64(in.dedupWaits, s.DedupWaits, opt)
+	o.ObserveInt64(in.peerHits, s.PeerHits, opt)
+	o.ObserveInt64(in.peerErrors, s.PeerErrors, opt)
+}
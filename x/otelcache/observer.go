@@ -0,0 +1,106 @@
+package otelcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"darvaza.org/cache"
+)
+
+var (
+	_ cache.GetObserver = (*getObserver)(nil)
+)
+
+// Results of a Get, as reported by [ResultKey]
+const (
+	ResultHit  = "hit"
+	ResultWait = "wait"
+	ResultLoad = "load"
+	ResultPeer = "peer"
+)
+
+// getObserver follows a Get call, creating child spans for the
+// loads and peer requests, and remembering how it was served
+type getObserver struct {
+	tracer       trace.Tracer
+	attrs        []attribute.KeyValue
+	loadDuration metric.Float64Histogram
+
+	mu     sync.Mutex
+	result string
+}
+
+func (o *getObserver) setResult(result string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.result = result
+}
+
+// Result returns how the Get was served, if known
+func (o *getObserver) Result() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.result
+}
+
+func (o *getObserver) OnHit(context.Context) {
+	o.setResult(ResultHit)
+}
+
+func (o *getObserver) OnWait(ctx context.Context) {
+	o.setResult(ResultWait)
+	trace.SpanFromContext(ctx).AddEvent("waiting for load")
+}
+
+func (o *getObserver) OnLoad(ctx context.Context) (context.Context, func(error)) {
+	o.setResult(ResultLoad)
+
+	start := time.Now()
+	ctx, span := o.tracer.Start(ctx, "cache.load",
+		trace.WithAttributes(o.attrs...))
+
+	return o.detach(ctx), func(err error) {
+		o.loadDuration.Record(ctx,
+			time.Since(start).Seconds(),
+			metric.WithAttributes(o.attrs...))
+		endSpan(span, err)
+	}
+}
+
+func (o *getObserver) OnPeer(ctx context.Context, peer string) (context.Context, func(error)) {
+	o.setResult(ResultPeer)
+
+	ctx, span := o.tracer.Start(ctx, "cache.peer",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(o.attrs...),
+		trace.WithAttributes(PeerKey.String(peer)))
+
+	return o.detach(ctx), func(err error) {
+		endSpan(span, err)
+	}
+}
+
+// detach removes the observer from the context passed to getters
+// and peers, so nested caches don't report to it
+func (*getObserver) detach(ctx context.Context) context.Context {
+	return cache.WithGetObserver(ctx, nil)
+}
+
+// endSpan records the error, if any, and ends the span. Missing
+// entries aren't considered failures.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, cache.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,3 @@
+// Package otelcache instruments cache.Cache and cache.Getter with
+// OpenTelemetry traces and metrics
+package otelcache
@@ -0,0 +1,138 @@
+package promstats
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+var (
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+// labels of all metrics
+var labelNames = []string{"namespace", "type"}
+
+// statser is the part of a cache.Cache used by the [Collector]
+type statser interface {
+	Stats(cache.Type) cache.Stats
+}
+
+// Collector is a [prometheus.Collector] reporting the [cache.Stats]
+// of the caches of a [cache.Store], labelled by namespace and type.
+type Collector struct {
+	types  []cache.Type
+	names  func() []string
+	lookup func(string) (statser, bool)
+
+	bytes     *prometheus.Desc
+	items     *prometheus.Desc
+	gets      *prometheus.Desc
+	hits      *prometheus.Desc
+	evictions *prometheus.Desc
+	hitRatio  *prometheus.Desc
+}
+
+// New creates a [Collector] for the caches of a [cache.Store]
+func New[K comparable](store cache.Store[K], cfg *Config) (*Collector, error) {
+	var c Config
+
+	if store == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing store")
+	} else if cfg != nil {
+		c = *cfg
+	}
+
+	if err := c.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	names, err := namesFunc(store, c.Names)
+	if err != nil {
+		return nil, err
+	}
+
+	col := &Collector{
+		types: c.Types,
+		names: names,
+		lookup: func(name string) (statser, bool) {
+			g := store.GetCache(name)
+			return g, g != nil
+		},
+	}
+	col.initDescs(c.Namespace, c.ConstLabels)
+	return col, nil
+}
+
+func namesFunc[K comparable](store cache.Store[K], names []string) (func() []string, error) {
+	if len(names) > 0 {
+		names = append([]string(nil), names...)
+		return func() []string { return names }, nil
+	}
+
+	if l, ok := store.(cache.Lister); ok {
+		return l.CacheNames, nil
+	}
+	return nil, core.Wrap(core.ErrInvalid, "store can't list its caches")
+}
+
+func (col *Collector) initDescs(ns string, constLabels prometheus.Labels) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(ns, "", name),
+			help, labelNames, constLabels)
+	}
+
+	col.bytes = desc("bytes", "Bytes used by the cache entries.")
+	col.items = desc("items", "Number of entries in the cache.")
+	col.gets = desc("gets_total", "Number of cache lookups.")
+	col.hits = desc("hits_total", "Number of cache lookups that found an entry.")
+	col.evictions = desc("evictions_total", "Number of entries removed from the cache.")
+	col.hitRatio = desc("hit_ratio", "Ratio of lookups that found an entry.")
+}
+
+// Describe sends the descriptions of the metrics
+func (col *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		col.bytes, col.items, col.gets, col.hits, col.evictions, col.hitRatio,
+	} {
+		ch <- d
+	}
+}
+
+// Collect sends the current statistics of all caches
+func (col *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, name := range col.names() {
+		g, ok := col.lookup(name)
+		if !ok {
+			continue
+		}
+
+		for _, t := range col.types {
+			col.collectStats(ch, g.Stats(t), name, t.String())
+		}
+	}
+}
+
+func (col *Collector) collectStats(ch chan<- prometheus.Metric, s cache.Stats, labels ...string) {
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, labels...)
+	}
+	counter := func(d *prometheus.Desc, v int64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, float64(v), labels...)
+	}
+
+	gauge(col.bytes, float64(s.Bytes))
+	gauge(col.items, float64(s.Items))
+	counter(col.gets, s.Gets)
+	counter(col.hits, s.Hits)
+	counter(col.evictions, s.Evictions)
+	gauge(col.hitRatio, hitRatio(s))
+}
+
+func hitRatio(s cache.Stats) float64 {
+	if s.Gets <= 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Gets)
+}
@@ -0,0 +1,51 @@
+package promstats
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+// DefaultNamespace is the prefix of the metric names when
+// none is specified
+const DefaultNamespace = "cache"
+
+// Config describes a [Collector]
+type Config struct {
+	// Namespace is the prefix of the metric names,
+	// [DefaultNamespace] if empty
+	Namespace string
+	// ConstLabels are added to all metrics
+	ConstLabels prometheus.Labels
+
+	// Names are the caches to export. If empty, the store needs
+	// to implement [cache.Lister] and all its caches are exported.
+	Names []string
+	// Types are the types of cache to export, only [cache.MainCache]
+	// if empty, as most backends don't tell types apart and report
+	// the same values for all
+	Types []cache.Type
+}
+
+// SetDefaults fills the gaps in the [Config] and validates it
+func (cfg *Config) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultNamespace
+	}
+
+	if len(cfg.Types) == 0 {
+		cfg.Types = []cache.Type{cache.MainCache}
+	}
+
+	for _, t := range cfg.Types {
+		if t.String() == "" {
+			return core.Wrap(core.ErrInvalid, "invalid cache type")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package promstats
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/core"
+)
+
+func TestHandler(t *testing.T) {
+	t.Run("default types", runTestHandlerDefaultTypes)
+	t.Run("all types", runTestHandlerAllTypes)
+}
+
+func newTestStore(t *testing.T) *memcache.Store[string] {
+	t.Helper()
+
+	getter := cache.GetterFunc[string](func(_ context.Context, key string, dest cache.Sink) error {
+		return dest.SetBytes([]byte("value-"+key), time.Time{})
+	})
+
+	store := memcache.New[string]()
+	g := store.NewCache("ns", 1<<20, getter)
+	core.AssertMustNotNil(t, g, "NewCache")
+
+	// one miss and one hit
+	for range 2 {
+		var sink cache.ByteSink
+		core.AssertMustNoError(t, g.Get(context.Background(), "k1", &sink), "Get")
+	}
+	return store
+}
+
+// scrape serves the collector and returns the exposed metrics
+func scrape(t *testing.T, col *Collector) string {
+	t.Helper()
+
+	h, err := Handler(col)
+	core.AssertMustNoError(t, err, "Handler")
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	core.AssertMustNoError(t, err, "GET")
+	defer res.Body.Close()
+
+	core.AssertEqual(t, http.StatusOK, res.StatusCode, "status")
+
+	b, err := io.ReadAll(res.Body)
+	core.AssertMustNoError(t, err, "read body")
+	return string(b)
+}
+
+func runTestHandlerDefaultTypes(t *testing.T) {
+	col, err := New[string](newTestStore(t), nil)
+	core.AssertMustNoError(t, err, "New")
+
+	body := scrape(t, col)
+	for _, series := range []string{
+		`cache_gets_total{namespace="ns",type="main"} 2`,
+		`cache_hits_total{namespace="ns",type="main"} 1`,
+		`cache_items{namespace="ns",type="main"} 1`,
+		`cache_hit_ratio{namespace="ns",type="main"} 0.5`,
+	} {
+		core.AssertContains(t, body, series, "series")
+	}
+	core.AssertNotContain(t, body, `type="hot"`, "hot cache")
+}
+
+func runTestHandlerAllTypes(t *testing.T) {
+	col, err := New[string](newTestStore(t), &Config{
+		Namespace: "test",
+		Names:     []string{"ns", "missing"},
+		Types:     []cache.Type{cache.MainCache, cache.HotCache},
+	})
+	core.AssertMustNoError(t, err, "New")
+
+	body := scrape(t, col)
+	core.AssertContains(t, body, `test_hit_ratio{namespace="ns",type="main"} 0.5`, "main")
+	core.AssertContains(t, body, `test_gets_total{namespace="ns",type="hot"} 2`, "hot")
+	core.AssertNotContain(t, body, `namespace="missing"`, "missing cache")
+	core.AssertNotContain(t, body, "cache_", "namespace prefix")
+}
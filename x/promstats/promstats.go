@@ -0,0 +1,3 @@
+// Package promstats exports the statistics of the caches of
+// a cache.Store as Prometheus metrics
+package promstats
@@ -0,0 +1,340 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Cache[string]     = (*Cache)(nil)
+	_ cache.Resizer           = (*Cache)(nil)
+	_ cache.Inspector[string] = (*Cache)(nil)
+)
+
+// Cache is a [cache.Cache] namespace on a Redis server. Concurrent loads
+// of the same key, on this or other clients, are coordinated using a
+// distributed lock.
+type Cache struct {
+	name   string
+	client goredis.UniversalClient
+	getter cache.Getter[string]
+	cfg    *Config
+	keys   keys
+
+	mu    sync.Mutex
+	log   slog.Logger
+	stats cache.Stats
+}
+
+// Name returns the name of the [Cache] namespace
+func (g *Cache) Name() string {
+	return g.name
+}
+
+// Stats returns the statistics kept by this client about the [Cache].
+// Only Gets and Hits are tracked, and it doesn't distinguish among types.
+func (g *Cache) Stats(_ cache.Type) cache.Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.stats
+}
+
+// Set stores an entry using SET PX, or removes it if already expired
+func (g *Cache) Set(ctx context.Context, key string, value []byte,
+	expire time.Time, _ cache.Type) error {
+	//
+	k := g.keys.data + key
+	if expire.IsZero() {
+		return g.client.Set(ctx, k, value, 0).Err()
+	}
+
+	ttl := time.Until(expire).Milliseconds()
+	if ttl < 1 {
+		return g.client.Del(ctx, k).Err()
+	}
+	return g.client.Do(ctx, "SET", k, value, "PX", ttl).Err()
+}
+
+// Get reads an entry into a [cache.Sink], using PTTL for its expiration
+// date read atomically with the value, and reaches out to the
+// [cache.Getter] on a miss. If another client is already loading the
+// key, it waits for it to finish.
+func (g *Cache) Get(ctx context.Context, key string, dest cache.Sink) error {
+	if dest == nil {
+		return core.ErrInvalid
+	}
+
+	v, ex, ok, err := g.read(ctx, key)
+	g.count(ok)
+
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		return dest.SetBytes(v, ex)
+	default:
+		return g.load(ctx, key, dest)
+	}
+}
+
+// readScript returns the value and remaining TTL of a key atomically,
+// or nil if missing
+var readScript = goredis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if not v then
+	return false
+end
+return {v, redis.call("PTTL", KEYS[1])}
+`)
+
+// read fetches the value and remaining TTL of a key
+func (g *Cache) read(ctx context.Context, key string) ([]byte, time.Time, bool, error) {
+	res, err := readScript.Run(ctx, g.client, []string{g.keys.data + key}).Slice()
+	switch {
+	case errors.Is(err, goredis.Nil):
+		return nil, time.Time{}, false, nil
+	case err != nil:
+		return nil, time.Time{}, false, err
+	}
+
+	v, ex, ok := decodeRead(res)
+	return v, ex, ok, nil
+}
+
+// decodeRead parses the reply of readScript. Keys expired
+// meanwhile (PTTL -2) are a miss.
+func decodeRead(res []any) ([]byte, time.Time, bool) {
+	if len(res) != 2 {
+		return nil, time.Time{}, false
+	}
+
+	v, ok1 := res[0].(string)
+	pttl, ok2 := res[1].(int64)
+	switch {
+	case !ok1 || !ok2 || pttl == -2:
+		return nil, time.Time{}, false
+	case pttl > 0:
+		return []byte(v), time.Now().Add(time.Duration(pttl) * time.Millisecond), true
+	default:
+		return []byte(v), time.Time{}, true
+	}
+}
+
+func (g *Cache) count(hit bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.stats.Gets++
+	if hit {
+		g.stats.Hits++
+	}
+}
+
+// load acquires the distributed lock of a key and calls the [cache.Getter],
+// or waits for whoever holds the lock to store the value. If the value
+// doesn't appear within LockWait, it's loaded without the lock.
+func (g *Cache) load(ctx context.Context, key string, dest cache.Sink) error {
+	token := newToken()
+	deadline := time.Now().Add(g.cfg.LockWait)
+
+	for {
+		locked, err := g.tryLock(ctx, key, token)
+		switch {
+		case err != nil:
+			return err
+		case locked:
+			defer g.unlock(ctx, key, token)
+			return g.loadLocked(ctx, key, dest)
+		}
+
+		done, err := g.wait(ctx, key, dest)
+		switch {
+		case done || err != nil:
+			return err
+		case time.Now().After(deadline):
+			g.logDebug(key, "lock wait expired")
+			return g.getter.Get(ctx, key, dest)
+		}
+	}
+}
+
+// wait waits for someone else to store the value, and returns true
+// if it was found
+func (g *Cache) wait(ctx context.Context, key string, dest cache.Sink) (bool, error) {
+	if err := sleep(ctx, g.cfg.LockRetry); err != nil {
+		return false, err
+	}
+
+	v, ex, ok, err := g.read(ctx, key)
+	switch {
+	case err != nil:
+		return false, err
+	case ok:
+		g.logDebug(key, "thank you!")
+		return true, dest.SetBytes(v, ex)
+	default:
+		return false, nil
+	}
+}
+
+// loadLocked calls the [cache.Getter] and stores the value while
+// holding the lock
+func (g *Cache) loadLocked(ctx context.Context, key string, dest cache.Sink) error {
+	// in case it was stored between our miss and the lock
+	if v, ex, ok, err := g.read(ctx, key); err == nil && ok {
+		return dest.SetBytes(v, ex)
+	}
+
+	g.logDebug(key, "getting...")
+	if err := g.getter.Get(ctx, key, dest); err != nil {
+		return err
+	}
+
+	err := g.Set(ctx, key, dest.Bytes(), dest.Expire(), cache.MainCache)
+	if err != nil {
+		// we have the value anyway
+		g.logError(key, err, "store")
+	}
+	return nil
+}
+
+// Remove removes an entry from the [Cache]
+func (g *Cache) Remove(ctx context.Context, key string) {
+	if err := g.client.Del(ctx, g.keys.data+key).Err(); err != nil {
+		g.logError(key, err, "remove")
+	}
+}
+
+// Resize isn't supported by Redis namespaces
+func (*Cache) Resize(context.Context, int64) (int, error) {
+	return 0, cache.ErrNotSupported
+}
+
+// purgeBatch is the number of keys removed on each round trip by Purge
+const purgeBatch = 256
+
+// Purge removes all entries of the [Cache] namespace using SCAN and
+// pipelined UNLINKs, on every master if the client is a cluster.
+func (g *Cache) Purge(ctx context.Context) error {
+	if cc, ok := g.client.(*goredis.ClusterClient); ok {
+		return cc.ForEachMaster(ctx, func(ctx context.Context, c *goredis.Client) error {
+			return g.purge(ctx, c)
+		})
+	}
+	return g.purge(ctx, g.client)
+}
+
+func (g *Cache) purge(ctx context.Context, c goredis.Cmdable) error {
+	iter := c.Scan(ctx, 0, g.keys.match, purgeBatch).Iterator()
+
+	batch := make([]string, 0, purgeBatch)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := unlinkEach(ctx, c, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return unlinkEach(ctx, c, batch)
+}
+
+// unlinkEach removes keys using one UNLINK per key in a pipeline,
+// as keys on different cluster slots can't share a command
+func unlinkEach(ctx context.Context, c goredis.Cmdable, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := c.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		for _, k := range keys {
+			p.Unlink(ctx, k)
+		}
+		return nil
+	})
+	return err
+}
+
+// Peek reads an entry into a [cache.Sink] without updating the statistics,
+// and without reaching out to the [cache.Getter] if missing.
+func (g *Cache) Peek(ctx context.Context, key string, dest cache.Sink) error {
+	v, ex, ok, err := g.read(ctx, key)
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		return cache.ErrNotFound
+	default:
+		return dest.SetBytes(v, ex)
+	}
+}
+
+// Contains tells if the [Cache] has an entry for the key
+func (g *Cache) Contains(ctx context.Context, key string) bool {
+	n, err := g.client.Exists(ctx, g.keys.data+key).Result()
+	return err == nil && n > 0
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was found.
+// A zero duration on a found entry means it never expires.
+func (g *Cache) TTL(ctx context.Context, key string) (time.Duration, bool) {
+	d, err := g.client.PTTL(ctx, g.keys.data+key).Result()
+	switch {
+	case err != nil, d == -2:
+		return 0, false
+	case d < 0:
+		return 0, true
+	default:
+		return d, true
+	}
+}
+
+// SetLogger attaches a [slog.Logger] to the [Cache]
+func (g *Cache) SetLogger(log slog.Logger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.log = log
+}
+
+func (g *Cache) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.log != nil {
+		log, ok := g.log.WithLevel(level).WithEnabled()
+		if ok {
+			log = log.WithField("cache", g.name)
+			return log, true
+		}
+	}
+	return nil, false
+}
+
+func (g *Cache) logDebug(key, msg string) {
+	if log, ok := g.withLogger(slog.Debug); ok {
+		log.WithField("key", key).Print(msg)
+	}
+}
+
+func (g *Cache) logError(key string, err error, op string) {
+	if log, ok := g.withLogger(slog.Error); ok {
+		log.WithField("key", key).
+			WithField(slog.ErrorFieldName, err).
+			Printf("%s failed", op)
+	}
+}
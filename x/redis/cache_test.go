@@ -0,0 +1,233 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("get and set", runTestCacheGetSet)
+	t.Run("expiry", runTestCacheExpiry)
+	t.Run("remove", runTestCacheRemove)
+	t.Run("purge", runTestCachePurge)
+	t.Run("purge glob names", runTestCachePurgeGlob)
+	t.Run("shared load", runTestCacheSharedLoad)
+}
+
+// countingGetter returns "value-" followed by the key after an
+// optional delay, and counts how many times it was called
+type countingGetter struct {
+	calls  atomic.Int32
+	delay  time.Duration
+	expire time.Time
+}
+
+func (g *countingGetter) Get(_ context.Context, key string, dest cache.Sink) error {
+	g.calls.Add(1)
+	time.Sleep(g.delay)
+	return dest.SetBytes([]byte("value-"+key), g.expire)
+}
+
+func newTestServer(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	mr := miniredis.NewMiniRedis()
+	core.AssertMustNoError(t, mr.Start(), "miniredis")
+	t.Cleanup(mr.Close)
+	return mr
+}
+
+func newTestStore(t *testing.T, mr *miniredis.Miniredis) *Store {
+	t.Helper()
+
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	s, err := New(client, &Config{
+		LockRetry: 5 * time.Millisecond,
+	})
+	core.AssertMustNoError(t, err, "New")
+	return s
+}
+
+func newTestCache(t *testing.T, s *Store, name string, getter cache.Getter[string]) *Cache {
+	t.Helper()
+
+	g := s.NewCache(name, 0, getter)
+	core.AssertMustNotNil(t, g, "NewCache")
+	return core.AssertMustTypeIs[*Cache](t, g, "NewCache")
+}
+
+func get(t *testing.T, g cache.Getter[string], key string) (*cache.ByteSink, error) {
+	t.Helper()
+
+	sink := new(cache.ByteSink)
+	err := g.Get(context.Background(), key, sink)
+	return sink, err
+}
+
+func runTestCacheGetSet(t *testing.T) {
+	mr := newTestServer(t)
+	getter := new(countingGetter)
+	g := newTestCache(t, newTestStore(t, mr), "ns", getter)
+
+	sink, err := get(t, g, "k1")
+	core.AssertNoError(t, err, "first Get")
+	core.AssertEqual(t, "value-k1", string(sink.Bytes()), "first Get")
+
+	stored, err := mr.Get("cache:ns:k1")
+	core.AssertNoError(t, err, "stored")
+	core.AssertEqual(t, "value-k1", stored, "stored")
+
+	sink, err = get(t, g, "k1")
+	core.AssertNoError(t, err, "second Get")
+	core.AssertEqual(t, "value-k1", string(sink.Bytes()), "second Get")
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+
+	err = g.Set(context.Background(), "k2", []byte("stored"), time.Time{}, cache.MainCache)
+	core.AssertNoError(t, err, "Set")
+
+	sink, err = get(t, g, "k2")
+	core.AssertNoError(t, err, "Get after Set")
+	core.AssertEqual(t, "stored", string(sink.Bytes()), "Get after Set")
+	core.AssertTrue(t, sink.Expire().IsZero(), "no expiration")
+
+	stats := g.Stats(cache.MainCache)
+	core.AssertEqual(t, int64(3), stats.Gets, "gets")
+	core.AssertEqual(t, int64(2), stats.Hits, "hits")
+}
+
+func runTestCacheExpiry(t *testing.T) {
+	mr := newTestServer(t)
+	getter := &countingGetter{expire: time.Now().Add(time.Minute)}
+	g := newTestCache(t, newTestStore(t, mr), "ns", getter)
+	ctx := context.Background()
+
+	_, err := get(t, g, "k1")
+	core.AssertMustNoError(t, err, "load")
+	core.AssertTrue(t, mr.TTL("cache:ns:k1") > 0, "TTL stored")
+
+	sink, err := get(t, g, "k1")
+	core.AssertNoError(t, err, "hit")
+	remaining := time.Until(sink.Expire())
+	core.AssertTrue(t, remaining > 50*time.Second && remaining <= time.Minute,
+		"expiration read back %v", remaining)
+
+	ttl, ok := g.TTL(ctx, "k1")
+	core.AssertTrue(t, ok, "TTL found")
+	core.AssertTrue(t, ttl > 0, "TTL positive")
+
+	mr.FastForward(2 * time.Minute)
+	core.AssertFalse(t, g.Contains(ctx, "k1"), "Contains after expiry")
+
+	var peek cache.ByteSink
+	core.AssertErrorIs(t, g.Peek(ctx, "k1", &peek), cache.ErrNotFound, "Peek after expiry")
+
+	_, err = get(t, g, "k1")
+	core.AssertNoError(t, err, "reload")
+	core.AssertEqual(t, int32(2), getter.calls.Load(), "getter calls")
+
+	err = g.Set(ctx, "k2", []byte("old"), time.Now().Add(-time.Second), cache.MainCache)
+	core.AssertNoError(t, err, "Set expired")
+	core.AssertFalse(t, mr.Exists("cache:ns:k2"), "expired not stored")
+}
+
+func runTestCacheRemove(t *testing.T) {
+	mr := newTestServer(t)
+	g := newTestCache(t, newTestStore(t, mr), "ns", new(countingGetter))
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, g.Set(ctx, "k1", []byte("v"), time.Time{}, cache.MainCache), "Set")
+	g.Remove(ctx, "k1")
+	core.AssertFalse(t, mr.Exists("cache:ns:k1"), "removed")
+}
+
+func runTestCachePurge(t *testing.T) {
+	mr := newTestServer(t)
+	s := newTestStore(t, mr)
+	g1 := newTestCache(t, s, "ns1", new(countingGetter))
+	g2 := newTestCache(t, s, "ns2", new(countingGetter))
+	ctx := context.Background()
+
+	// more than one batch
+	for i := range purgeBatch + 10 {
+		key := cache.MustJoinKey("k", i)
+		core.AssertMustNoError(t, g1.Set(ctx, key, []byte("v"), time.Time{}, cache.MainCache), "Set ns1")
+	}
+	core.AssertMustNoError(t, g2.Set(ctx, "kept", []byte("v"), time.Time{}, cache.MainCache), "Set ns2")
+
+	core.AssertNoError(t, g1.Purge(ctx), "Purge")
+	core.AssertSliceEqual(t, []string{"cache:ns2:kept"}, mr.Keys(), "keys left")
+}
+
+func runTestCachePurgeGlob(t *testing.T) {
+	mr := newTestServer(t)
+	s := newTestStore(t, mr)
+	ctx := context.Background()
+
+	// unescaped, "a*" and "[ab]" would also match the other namespace
+	for _, names := range [][2]string{{"a*", "ab"}, {"[ab]", "a"}, {`a\`, `a\b`}} {
+		g1 := newTestCache(t, s, names[0], new(countingGetter))
+		g2 := newTestCache(t, s, names[1], new(countingGetter))
+
+		core.AssertMustNoError(t, g1.Set(ctx, "gone", []byte("v"), time.Time{}, cache.MainCache), "Set %q", names[0])
+		core.AssertMustNoError(t, g2.Set(ctx, "kept", []byte("v"), time.Time{}, cache.MainCache), "Set %q", names[1])
+
+		core.AssertNoError(t, g1.Purge(ctx), "Purge %q", names[0])
+		core.AssertSliceEqual(t, []string{"cache:" + names[1] + ":kept"}, mr.Keys(), "keys left")
+		mr.FlushAll()
+	}
+}
+
+func runTestCacheSharedLoad(t *testing.T) {
+	mr := newTestServer(t)
+	getter := &countingGetter{delay: 50 * time.Millisecond}
+
+	// two clients sharing the server
+	caches := []*Cache{
+		newTestCache(t, newTestStore(t, mr), "ns", getter),
+		newTestCache(t, newTestStore(t, mr), "ns", getter),
+	}
+
+	var wg sync.WaitGroup
+	for i := range 6 {
+		wg.Add(1)
+		go func(g *Cache) {
+			defer wg.Done()
+
+			sink, err := get(t, g, "k1")
+			core.AssertNoError(t, err, "Get")
+			core.AssertEqual(t, "value-k1", string(sink.Bytes()), "Get")
+		}(caches[i%2])
+	}
+	wg.Wait()
+
+	core.AssertEqual(t, int32(1), getter.calls.Load(), "getter calls")
+	core.AssertFalse(t, mr.Exists("cache::lock:ns:k1"), "lock released")
+}
+
+func TestDecodeRead(t *testing.T) {
+	_, _, ok := decodeRead([]any{"v", int64(-2)})
+	core.AssertFalse(t, ok, "expired meanwhile")
+
+	v, ex, ok := decodeRead([]any{"v", int64(-1)})
+	core.AssertTrue(t, ok, "persistent")
+	core.AssertEqual(t, "v", string(v), "persistent value")
+	core.AssertTrue(t, ex.IsZero(), "persistent expiration")
+
+	_, ex, ok = decodeRead([]any{"v", int64(1000)})
+	core.AssertTrue(t, ok, "expiring")
+	core.AssertTrue(t, time.Until(ex) > 0, "expiring expiration")
+
+	_, _, ok = decodeRead([]any{"v"})
+	core.AssertFalse(t, ok, "malformed")
+}
@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"strings"
+	"time"
+
+	"darvaza.org/core"
+)
+
+const (
+	// DefaultPrefix is the prefix used for all keys if none is given
+	DefaultPrefix = "cache:"
+	// DefaultLockTTL is how long the loader lock is held if not released
+	DefaultLockTTL = 10 * time.Second
+	// DefaultLockRetry is the period between attempts to find the value
+	// or acquire the lock while another client is loading
+	DefaultLockRetry = 50 * time.Millisecond
+)
+
+// Config tunes a [Store]
+type Config struct {
+	// Prefix is prepended to all keys. Defaults to [DefaultPrefix].
+	Prefix string
+	// LockTTL is how long the distributed loader lock lives if its
+	// holder fails to release it. Defaults to [DefaultLockTTL].
+	LockTTL time.Duration
+	// LockRetry is the period between checks while another client is
+	// loading a key. Defaults to [DefaultLockRetry].
+	LockRetry time.Duration
+	// LockWait is how long to wait for another client to load a key
+	// before loading it ourselves. Defaults to LockTTL.
+	LockWait time.Duration
+}
+
+// SetDefaults fills the gaps and identifies errors.
+func (cfg *Config) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if cfg.LockTTL == 0 {
+		cfg.LockTTL = DefaultLockTTL
+	}
+	if cfg.LockRetry == 0 {
+		cfg.LockRetry = DefaultLockRetry
+	}
+	if cfg.LockWait == 0 {
+		cfg.LockWait = cfg.LockTTL
+	}
+
+	switch {
+	case cfg.LockTTL < time.Millisecond, cfg.LockRetry < 0, cfg.LockWait < 0:
+		return core.Wrap(core.ErrInvalid, "invalid lock timing")
+	default:
+		return nil
+	}
+}
+
+// keys builds the names used on the server for a namespace.
+// Namespace names can't contain ':', so data keys
+// (prefix + name + ':' + key) and lock keys
+// (prefix + ':lock:' + name + ':' + key) never collide.
+type keys struct {
+	data  string
+	lock  string
+	match string
+}
+
+func newKeys(prefix, name string) keys {
+	data := prefix + name + ":"
+	return keys{
+		data:  data,
+		lock:  prefix + ":lock:" + name + ":",
+		match: globEscape(data) + "*",
+	}
+}
+
+// globEscape quotes the characters SCAN MATCH patterns give
+// a special meaning to
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func validName(name string) bool {
+	return name != "" && !strings.Contains(name, ":")
+}
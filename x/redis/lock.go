@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// unlockScript releases a lock only if it's still ours
+var unlockScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func newToken() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// tryLock attempts to acquire the distributed loader lock of a key
+func (g *Cache) tryLock(ctx context.Context, key, token string) (bool, error) {
+	return g.client.SetNX(ctx, g.keys.lock+key, token, g.cfg.LockTTL).Result()
+}
+
+// unlock releases the distributed loader lock of a key, even
+// if the context was cancelled
+func (g *Cache) unlock(ctx context.Context, key, token string) {
+	ctx = context.WithoutCancel(ctx)
+	err := unlockScript.Run(ctx, g.client, []string{g.keys.lock + key}, token).Err()
+	if err != nil {
+		g.logError(key, err, "unlock")
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,3 @@
+// Package redis provides a cache.Store backed by a Redis or Valkey
+// server, with namespaces as key prefixes
+package redis
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"sync"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Store[string] = (*Store)(nil)
+)
+
+// Store manages [cache.Cache] namespaces on a Redis or Valkey server,
+// standalone or cluster, as key prefixes
+type Store struct {
+	mu     sync.Mutex
+	client goredis.UniversalClient
+	cfg    Config
+	log    slog.Logger
+	m      map[string]*Cache
+}
+
+// New creates a new [Store] using the given client. A nil config means
+// all defaults.
+func New(client goredis.UniversalClient, cfg *Config) (*Store, error) {
+	if client == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing client")
+	}
+
+	if cfg == nil {
+		cfg = new(Config)
+	}
+
+	if err := cfg.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		client: client,
+		cfg:    *cfg,
+		m:      make(map[string]*Cache),
+	}
+	return s, nil
+}
+
+// DeregisterCache disconnects a [Cache] from the [Store]. Its entries
+// are left on the server.
+func (s *Store) DeregisterCache(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.m, name)
+}
+
+// GetCache finds a [Cache] by name in the [Store]
+func (s *Store) GetCache(name string) cache.Cache[string] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.m[name]
+	if ok {
+		return g
+	}
+	return nil
+}
+
+// NewCache creates a new [Cache] namespace attached to the [Store].
+// Names can't contain ':', and the size is ignored as it's up to the
+// server's eviction policy.
+func (s *Store) NewCache(name string, _ int64, getter cache.Getter[string]) cache.Cache[string] {
+	if !validName(name) || getter == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.m[name]; ok {
+		core.Panicf("%s: %s", name, "cache already registered")
+	}
+
+	g := &Cache{
+		name:   name,
+		client: s.client,
+		getter: getter,
+		cfg:    &s.cfg,
+		keys:   newKeys(s.cfg.Prefix, name),
+		log:    s.log,
+	}
+	s.m[name] = g
+
+	if log, ok := s.withDebug(); ok {
+		log.Printf("cache:%q created", name)
+	}
+	return g
+}
+
+// SetLogger attaches a [slog.Logger] to the store and any new Cache created through it
+func (s *Store) SetLogger(log slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = log
+}
+
+func (s *Store) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	if s.log != nil {
+		return s.log.WithLevel(level).WithEnabled()
+	}
+	return nil, false
+}
+
+func (s *Store) withDebug() (slog.Logger, bool) {
+	return s.withLogger(slog.Debug)
+}
@@ -0,0 +1,45 @@
+package simplelru
+
+import (
+	"container/list"
+	"iter"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// All returns an iterator over all non-expired entries, from the least
+// recently used to the most recently used. Like [LRU.ForEach], expired
+// entries found along the way are evicted.
+func (m *LRU[K, T]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		m.ForEach(func(key K, value T, _ int, _ time.Time) bool {
+			return !yield(key, value)
+		})
+	}
+}
+
+// Keys returns an iterator over the keys of all non-expired entries,
+// from the least recently used to the most recently used.
+func (m *LRU[K, T]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.ForEach(func(key K, _ T, _ int, _ time.Time) bool {
+			return !yield(key)
+		})
+	}
+}
+
+// Backward returns an iterator over all non-expired entries, from the
+// most recently used to the least recently used. Expired entries found
+// along the way are evicted.
+func (m *LRU[K, T]) Backward() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		fn := func(key K, value T, _ int, _ time.Time) bool {
+			return !yield(key, value)
+		}
+
+		core.ListForEachBackwardElement(m.eviction, func(le *list.Element) bool {
+			return m.forEachIter(le, fn)
+		})
+	}
+}
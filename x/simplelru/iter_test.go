@@ -0,0 +1,110 @@
+package simplelru
+
+import (
+	"iter"
+	"slices"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestIterators(t *testing.T) {
+	t.Run("all", runTestIteratorsAll)
+	t.Run("keys", runTestIteratorsKeys)
+	t.Run("backward", runTestIteratorsBackward)
+	t.Run("peek each", runTestIteratorsPeekEach)
+}
+
+// newTestLRU returns an [LRU] with k1, k2 and k3 from the least to
+// the most recently used, and an expired entry between k1 and k2,
+// counting the evictions
+func newTestLRU(t *testing.T) (*LRU[string, int], *int) {
+	t.Helper()
+
+	evictions := new(int)
+	m := NewLRU[string, int](100, nil, func(string, int, int) { *evictions++ })
+	m.Add("k1", 1, 1, time.Time{})
+	m.Add("old", 0, 1, time.Now().Add(-time.Second))
+	m.Add("k2", 2, 1, time.Time{})
+	m.Add("k3", 3, 1, time.Now().Add(time.Hour))
+	return m, evictions
+}
+
+func collect[K comparable, T any](seq iter.Seq2[K, T]) ([]K, []T) {
+	var keys []K
+	var values []T
+	for k, v := range seq {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	return keys, values
+}
+
+func runTestIteratorsAll(t *testing.T) {
+	m, evictions := newTestLRU(t)
+
+	keys, values := collect(m.All())
+	core.AssertSliceEqual(t, []string{"k1", "k2", "k3"}, keys, "keys")
+	core.AssertSliceEqual(t, []int{1, 2, 3}, values, "values")
+	core.AssertEqual(t, 1, *evictions, "expired evicted")
+	core.AssertEqual(t, 3, m.Len(), "Len")
+
+	keys = keys[:0]
+	for k := range m.All() {
+		keys = append(keys, k)
+		break
+	}
+	core.AssertSliceEqual(t, []string{"k1"}, keys, "early stop")
+}
+
+func runTestIteratorsKeys(t *testing.T) {
+	m, evictions := newTestLRU(t)
+
+	m.Get("k1")
+	keys := slices.Collect(m.Keys())
+	core.AssertSliceEqual(t, []string{"k2", "k3", "k1"}, keys, "keys")
+	core.AssertEqual(t, 1, *evictions, "expired evicted")
+}
+
+func runTestIteratorsBackward(t *testing.T) {
+	m, evictions := newTestLRU(t)
+
+	keys, values := collect(m.Backward())
+	core.AssertSliceEqual(t, []string{"k3", "k2", "k1"}, keys, "keys")
+	core.AssertSliceEqual(t, []int{3, 2, 1}, values, "values")
+	core.AssertEqual(t, 1, *evictions, "expired evicted")
+
+	keys = keys[:0]
+	for k := range m.Backward() {
+		keys = append(keys, k)
+		break
+	}
+	core.AssertSliceEqual(t, []string{"k3"}, keys, "early stop")
+}
+
+func runTestIteratorsPeekEach(t *testing.T) {
+	m, evictions := newTestLRU(t)
+
+	var keys []string
+	var expires []time.Time
+	m.PeekEach(func(key string, _ int, size int, expire time.Time) bool {
+		core.AssertEqual(t, 1, size, "%s size", key)
+		keys = append(keys, key)
+		expires = append(expires, expire)
+		return false
+	})
+
+	core.AssertSliceEqual(t, []string{"k1", "k2", "k3"}, keys, "keys")
+	core.AssertTrue(t, expires[0].IsZero(), "k1 expire")
+	core.AssertFalse(t, expires[2].IsZero(), "k3 expire")
+	core.AssertEqual(t, 0, *evictions, "nothing evicted")
+	core.AssertEqual(t, 4, m.Len(), "Len")
+
+	keys = keys[:0]
+	m.PeekEach(func(key string, _ int, _ int, _ time.Time) bool {
+		keys = append(keys, key)
+		return true
+	})
+	core.AssertSliceEqual(t, []string{"k1"}, keys, "early stop")
+}
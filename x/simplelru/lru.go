@@ -19,6 +19,7 @@ type LRU[K comparable, T any] struct {
 	eviction *list.List
 	onAdd    func(K, T, int, time.Time)
 	onEvict  func(K, T, int)
+	onReason func(K, T, int, EvictReason)
 }
 
 // NewLRU creates a new LRU with maximum size and eviction callback
@@ -36,6 +37,13 @@ func NewLRU[K comparable, T any](size int,
 	return lru
 }
 
+// SetEvictReasonCallback sets a function to be called, in addition to
+// the eviction callback given to [NewLRU], when an entry is removed
+// and telling why
+func (m *LRU[K, T]) SetEvictReasonCallback(fn func(K, T, int, EvictReason)) {
+	m.onReason = fn
+}
+
 // Len returns the number of entries in the cache
 func (m *LRU[K, T]) Len() int {
 	return m.count
@@ -46,6 +54,11 @@ func (m *LRU[K, T]) Size() int {
 	return m.size
 }
 
+// MaxSize returns the maximum size of the cache
+func (m *LRU[K, T]) MaxSize() int {
+	return m.maxSize
+}
+
 // Available tells how much space is available without evictions
 func (m *LRU[K, T]) Available() int {
 	return m.maxSize - m.size
@@ -89,6 +102,7 @@ func (m *LRU[K, T]) Add(key K, value T, size int, expire time.Time) bool {
 		value:  value,
 		size:   size,
 		expire: ex,
+		added:  time.Now(),
 	}
 
 	if le, p, ok := m.getEntry(key); ok {
@@ -122,7 +136,7 @@ func (m *LRU[K, T]) Add(key K, value T, size int, expire time.Time) bool {
 // Evict removes an entry if present
 func (m *LRU[K, T]) Evict(key K) {
 	if le, ok := m.items[key]; ok {
-		m.evictElement(le)
+		m.evictElement(le, Removed)
 	}
 }
 
@@ -141,11 +155,78 @@ func (m *LRU[K, T]) Get(key K) (T, time.Time, bool) {
 
 			return p.value, e, true
 		}
-		m.evictElement(le)
+		m.evictElement(le, Expired)
 	}
 	return zero, time.Time{}, false
 }
 
+// Peek is like Get but it doesn't update the recency of the entry,
+// nor evicts it if expired
+func (m *LRU[K, T]) Peek(key K) (T, time.Time, bool) {
+	var zero T
+	if _, p, ok := m.getEntry(key); ok && !p.Expired() {
+		var e time.Time
+
+		if ex := p.expire; ex != nil {
+			e = *ex
+		}
+
+		return p.value, e, true
+	}
+	return zero, time.Time{}, false
+}
+
+// Contains tells if a non-expired entry exists for the key, without
+// updating its recency
+func (m *LRU[K, T]) Contains(key K) bool {
+	_, _, ok := m.Peek(key)
+	return ok
+}
+
+// TTL returns the remaining time-to-live of an entry, and if it was
+// found. A zero duration on a found entry means it never expires.
+// The recency of the entry isn't updated.
+func (m *LRU[K, T]) TTL(key K) (time.Duration, bool) {
+	_, e, ok := m.Peek(key)
+	if !ok {
+		return 0, false
+	} else if e.IsZero() {
+		return 0, true
+	}
+
+	if ttl := time.Until(e); ttl > 0 {
+		return ttl, true
+	}
+	return 0, false
+}
+
+// Touch sets a new expiration date to a non-expired entry without
+// changing its value nor its recency, and returns true if it was found.
+// A zero expire means it never expires.
+func (m *LRU[K, T]) Touch(key K, expire time.Time) bool {
+	_, p, ok := m.getEntry(key)
+	if !ok || p.Expired() {
+		return false
+	}
+
+	if expire.IsZero() {
+		p.expire = nil
+	} else {
+		p.expire = &expire
+	}
+	return true
+}
+
+// Age tells how long ago a non-expired entry was added or last updated,
+// and if it was found
+func (m *LRU[K, T]) Age(key K) (time.Duration, bool) {
+	_, p, ok := m.getEntry(key)
+	if !ok || p.Expired() {
+		return 0, false
+	}
+	return time.Since(p.added), true
+}
+
 // prune removes entries if space is needed. It tries
 // the oldest expired first, and then just the oldest.
 func (m *LRU[K, T]) prune() bool {
@@ -186,23 +267,46 @@ func (m *LRU[K, T]) pruneLoop(onlyExpired bool) bool {
 
 func (m *LRU[K, T]) pruneEvict(le *list.Element, onlyExpired bool) bool {
 	var evict bool
+	reason := Pruned
 	p, ok := m.getListEntry(le)
 	switch {
 	case !ok:
 		evict = true
-	case !onlyExpired:
-		evict = true
+	case p.Expired():
+		evict, reason = true, Expired
 	default:
-		evict = p.Expired()
+		evict = !onlyExpired
 	}
 
 	if evict {
-		m.evictElement(le)
+		m.evictElement(le, reason)
 	}
 
 	return evict
 }
 
+// Resize changes the maximum size of the cache, evicting expired
+// entries first and then the oldest if needed, and returns
+// the number of evicted entries
+func (m *LRU[K, T]) Resize(size int) int {
+	count := m.count
+
+	m.maxSize = size
+	m.prune()
+
+	return count - m.count
+}
+
+// Purge removes all entries from the cache, calling the eviction
+// callbacks with the [Purged] reason
+func (m *LRU[K, T]) Purge() {
+	core.ListForEachElement(m.eviction,
+		func(le *list.Element) bool {
+			m.evictElement(le, Purged)
+			return false
+		})
+}
+
 // EvictExpired scans the whole cache and evicts all expired entries
 func (m *LRU[K, T]) EvictExpired() bool {
 	var evicted bool
@@ -211,7 +315,7 @@ func (m *LRU[K, T]) EvictExpired() bool {
 			p, ok := m.getListEntry(le)
 			if !ok || p.Expired() {
 				evicted = true
-				m.evictElement(le)
+				m.evictElement(le, Expired)
 			}
 
 			return false
@@ -219,7 +323,7 @@ func (m *LRU[K, T]) EvictExpired() bool {
 	return evicted
 }
 
-func (m *LRU[K, T]) evictElement(le *list.Element) {
+func (m *LRU[K, T]) evictElement(le *list.Element, reason EvictReason) {
 	p, ok := m.getListEntry(le)
 
 	// remove from eviction list
@@ -240,6 +344,9 @@ func (m *LRU[K, T]) evictElement(le *list.Element) {
 	if fn := m.onEvict; fn != nil {
 		fn(p.key, p.value, p.size)
 	}
+	if fn := m.onReason; fn != nil {
+		fn(p.key, p.value, p.size, reason)
+	}
 }
 
 // ForEach allows you to iterate over all non-expired entries in the Cache.
@@ -253,12 +360,33 @@ func (m *LRU[K, T]) ForEach(fn func(K, T, int, time.Time) bool) {
 	}
 }
 
+// PeekEach is like ForEach but it doesn't evict the expired entries
+// it finds, which are skipped, so it can be used to inspect the cache
+// without modifying it.
+func (m *LRU[K, T]) PeekEach(fn func(K, T, int, time.Time) bool) {
+	if fn != nil {
+		core.ListForEachElement(m.eviction, func(le *list.Element) bool {
+			var ex time.Time
+
+			p, ok := m.getListEntry(le)
+			if !ok || p.Expired() {
+				return false
+			}
+
+			if p.expire != nil {
+				ex = *p.expire
+			}
+			return fn(p.key, p.value, p.size, ex)
+		})
+	}
+}
+
 func (m *LRU[K, T]) forEachIter(le *list.Element, fn func(K, T, int, time.Time) bool) bool {
 	var ex time.Time
 
 	p, ok := m.getListEntry(le)
 	if !ok || p.Expired() {
-		m.evictElement(le)
+		m.evictElement(le, Expired)
 		return false
 	}
 
@@ -274,6 +402,7 @@ type entry[K comparable, T any] struct {
 	value  T
 	size   int
 	expire *time.Time
+	added  time.Time
 }
 
 func (e *entry[K, T]) Expired() bool {
@@ -0,0 +1,93 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+)
+
+func TestLRUInspect(t *testing.T) {
+	t.Run("peek", runTestLRUPeek)
+	t.Run("contains", runTestLRUContains)
+	t.Run("ttl", runTestLRUTTL)
+}
+
+func runTestLRUPeek(t *testing.T) {
+	m := NewLRU[string, int](2, nil, nil)
+	m.Add("k1", 1, 1, time.Time{})
+	m.Add("k2", 2, 1, time.Time{})
+
+	v, ex, ok := m.Peek("k1")
+	core.AssertTrue(t, ok, "Peek k1")
+	core.AssertEqual(t, 1, v, "Peek k1")
+	core.AssertTrue(t, ex.IsZero(), "Peek k1 expire")
+
+	// k1 wasn't promoted, so it goes first
+	m.Add("k3", 3, 1, time.Time{})
+	core.AssertFalse(t, m.Contains("k1"), "k1 evicted")
+	core.AssertTrue(t, m.Contains("k2"), "k2 kept")
+
+	// expired entries are neither returned nor evicted
+	m.Add("old", 0, 1, time.Now().Add(-time.Second))
+	_, _, ok = m.Peek("old")
+	core.AssertFalse(t, ok, "Peek expired")
+	core.AssertEqual(t, 2, m.Len(), "Len")
+}
+
+func runTestLRUContains(t *testing.T) {
+	m := NewLRU[string, int](2, nil, nil)
+	m.Add("k1", 1, 1, time.Time{})
+	m.Add("k2", 2, 1, time.Time{})
+
+	core.AssertTrue(t, m.Contains("k1"), "Contains k1")
+	core.AssertFalse(t, m.Contains("k3"), "Contains k3")
+
+	m.Add("k3", 3, 1, time.Time{})
+	core.AssertFalse(t, m.Contains("k1"), "k1 not promoted")
+}
+
+func runTestLRUTTL(t *testing.T) {
+	m := NewLRU[string, int](10, nil, nil)
+	m.Add("forever", 1, 1, time.Time{})
+	m.Add("hour", 2, 1, time.Now().Add(time.Hour))
+	m.Add("old", 3, 1, time.Now().Add(-time.Second))
+
+	ttl, ok := m.TTL("forever")
+	core.AssertTrue(t, ok, "TTL forever")
+	core.AssertEqual(t, time.Duration(0), ttl, "TTL forever")
+
+	ttl, ok = m.TTL("hour")
+	core.AssertTrue(t, ok, "TTL hour")
+	core.AssertTrue(t, ttl > 59*time.Minute && ttl <= time.Hour, "TTL hour")
+
+	_, ok = m.TTL("old")
+	core.AssertFalse(t, ok, "TTL expired")
+	_, ok = m.TTL("missing")
+	core.AssertFalse(t, ok, "TTL missing")
+}
+
+func TestLRUTouch(t *testing.T) {
+	m := NewLRU[string, int](2, nil, nil)
+	m.Add("k1", 1, 1, time.Now().Add(time.Minute))
+	m.Add("k2", 2, 1, time.Time{})
+
+	expire := time.Now().Add(time.Hour)
+	core.AssertTrue(t, m.Touch("k1", expire), "Touch k1")
+	_, ex, ok := m.Peek("k1")
+	core.AssertTrue(t, ok, "Peek k1")
+	core.AssertTrue(t, expire.Equal(ex), "expire extended")
+
+	core.AssertTrue(t, m.Touch("k1", time.Time{}), "Touch k1 forever")
+	ttl, ok := m.TTL("k1")
+	core.AssertTrue(t, ok, "TTL k1")
+	core.AssertEqual(t, time.Duration(0), ttl, "never expires")
+
+	// recency isn't changed
+	m.Add("k3", 3, 1, time.Time{})
+	core.AssertFalse(t, m.Contains("k1"), "k1 evicted")
+
+	core.AssertFalse(t, m.Touch("k1", expire), "Touch missing")
+	m.Add("old", 0, 1, time.Now().Add(-time.Second))
+	core.AssertFalse(t, m.Touch("old", expire), "Touch expired")
+}
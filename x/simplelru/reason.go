@@ -0,0 +1,30 @@
+package simplelru
+
+// EvictReason tells why an entry was removed from the [LRU]
+type EvictReason int
+
+const (
+	// Removed indicates the entry was explicitly evicted
+	Removed EvictReason = iota + 1
+	// Expired indicates the entry was past its expiration date
+	Expired
+	// Pruned indicates the entry was evicted to make space
+	Pruned
+	// Purged indicates the whole [LRU] was emptied
+	Purged
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case Removed:
+		return "removed"
+	case Expired:
+		return "expired"
+	case Pruned:
+		return "pruned"
+	case Purged:
+		return "purged"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,256 @@
+package writeback
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Cache[string] = (*WriteBehind[string])(nil)
+)
+
+var (
+	// ErrClosed indicates the [WriteBehind] no longer accepts writes
+	ErrClosed = core.Wrap(core.ErrInvalid, "write-behind closed")
+)
+
+// WriteBehind is a [cache.Cache] decorator that updates the cache
+// immediately and writes to a backing store in the background, through
+// a bounded queue where successive writes to the same key are coalesced.
+// Failed writes are retried with exponential backoff unless superseded.
+type WriteBehind[K comparable] struct {
+	cache.Cache[K]
+
+	setter  cache.Setter[K]
+	deleter cache.Deleter[K]
+	cfg     Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	log      slog.Logger
+	pending  map[K]*op[K]
+	order    []K
+	inflight bool
+	closed   bool
+	stats    QueueStats
+}
+
+// QueueStats reports the state of a [WriteBehind] queue
+type QueueStats struct {
+	// Pending is the number of keys waiting to be written
+	Pending int
+	// Written counts successful writes to the backing store
+	Written int64
+	// Coalesced counts writes replaced by a later one before
+	// reaching the backing store
+	Coalesced int64
+	// Retries counts failed writes that were retried
+	Retries int64
+	// Failures counts writes dropped after exhausting their retries,
+	// or when closing
+	Failures int64
+}
+
+// op is a pending write
+type op[K comparable] struct {
+	key       K
+	value     []byte
+	expire    time.Time
+	cacheType cache.Type
+	remove    bool
+}
+
+// NewWriteBehind creates a [WriteBehind] decorator and starts its
+// background writer. The deleter is optional, without it Remove only
+// affects the cache. A nil config means all defaults.
+func NewWriteBehind[K comparable](c cache.Cache[K], setter cache.Setter[K],
+	deleter cache.Deleter[K], cfg *Config) (*WriteBehind[K], error) {
+	//
+	if c == nil || setter == nil {
+		return nil, core.Wrap(core.ErrInvalid, "missing parameters")
+	}
+
+	if cfg == nil {
+		cfg = new(Config)
+	}
+
+	if err := cfg.SetDefaults(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wb := &WriteBehind[K]{
+		Cache:   c,
+		setter:  setter,
+		deleter: deleter,
+		cfg:     *cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		pending: make(map[K]*op[K]),
+	}
+	wb.cond = sync.NewCond(&wb.mu)
+
+	go wb.run()
+	return wb, nil
+}
+
+// Set updates the cache and queues the write to the backing store.
+// If the queue is full it waits for room or the context to be cancelled.
+func (wb *WriteBehind[K]) Set(ctx context.Context, key K, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	o := &op[K]{
+		key:       key,
+		value:     append([]byte(nil), value...),
+		expire:    expire,
+		cacheType: cacheType,
+	}
+
+	if err := wb.enqueue(ctx, o); err != nil {
+		return err
+	}
+	return wb.Cache.Set(ctx, key, value, expire, cacheType)
+}
+
+// Remove evicts the entry from the cache and, if there is a deleter,
+// queues its removal from the backing store. Otherwise any pending
+// write of the key is kept, as the backing store can't be told to
+// forget it.
+func (wb *WriteBehind[K]) Remove(ctx context.Context, key K) {
+	defer wb.Cache.Remove(ctx, key)
+
+	if wb.deleter == nil {
+		return
+	}
+
+	if err := wb.enqueue(ctx, &op[K]{key: key, remove: true}); err != nil {
+		if log, ok := wb.withLogger(slog.Error); ok {
+			log.WithField("key", key).
+				WithField(slog.ErrorFieldName, err).
+				Print("failed to queue delete")
+		}
+	}
+}
+
+// enqueue adds or replaces the pending write of a key
+func (wb *WriteBehind[K]) enqueue(ctx context.Context, o *op[K]) error {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	if _, ok := wb.pending[o.key]; ok && !wb.closed {
+		wb.pending[o.key] = o
+		wb.stats.Coalesced++
+		return nil
+	}
+
+	if err := wb.waitRoom(ctx); err != nil {
+		return err
+	}
+
+	wb.pending[o.key] = o
+	wb.order = append(wb.order, o.key)
+	wb.cond.Broadcast()
+	return nil
+}
+
+// waitRoom waits until there is room in the queue, the context is
+// cancelled or the [WriteBehind] is closed. Must hold the lock.
+func (wb *WriteBehind[K]) waitRoom(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, wb.wakeup)
+	defer stop()
+
+	for {
+		switch {
+		case wb.closed:
+			return ErrClosed
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case len(wb.order) < wb.cfg.QueueSize:
+			return nil
+		}
+		wb.cond.Wait()
+	}
+}
+
+func (wb *WriteBehind[K]) wakeup() {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	wb.cond.Broadcast()
+}
+
+// QueueStats returns the state of the queue
+func (wb *WriteBehind[K]) QueueStats() QueueStats {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	out := wb.stats
+	out.Pending = len(wb.pending)
+	return out
+}
+
+// Flush waits until all the writes queued so far have been attempted,
+// or the context is cancelled.
+func (wb *WriteBehind[K]) Flush(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, wb.wakeup)
+	defer stop()
+
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	for len(wb.pending) > 0 || wb.inflight {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		wb.cond.Wait()
+	}
+	return nil
+}
+
+// Close stops accepting writes and flushes the queue. If the context
+// is cancelled first, the remaining writes are dropped and counted as
+// failures.
+func (wb *WriteBehind[K]) Close(ctx context.Context) error {
+	wb.mu.Lock()
+	wb.closed = true
+	wb.cond.Broadcast()
+	wb.mu.Unlock()
+
+	err := wb.Flush(ctx)
+	wb.cancel()
+	wb.wakeup()
+	<-wb.done
+	return err
+}
+
+// SetLogger attaches a [slog.Logger] to the [WriteBehind]
+func (wb *WriteBehind[K]) SetLogger(log slog.Logger) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	wb.log = log
+}
+
+func (wb *WriteBehind[K]) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	if wb.log != nil {
+		log, ok := wb.log.WithLevel(level).WithEnabled()
+		if ok {
+			log = log.WithField("cache", wb.Name())
+			return log, true
+		}
+	}
+	return nil, false
+}
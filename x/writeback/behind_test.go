@@ -0,0 +1,161 @@
+package writeback
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/cache/x/memcache"
+	"darvaza.org/core"
+)
+
+func TestWriteBehind(t *testing.T) {
+	t.Run("full queue", runTestWriteBehindFull)
+	t.Run("remove", runTestWriteBehindRemove)
+	t.Run("remove without deleter", runTestWriteBehindRemoveNoDeleter)
+}
+
+// blockingSetter records the keys written, holding the first write
+// until released
+type blockingSetter struct {
+	mu      sync.Mutex
+	keys    []string
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingSetter() *blockingSetter {
+	return &blockingSetter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (s *blockingSetter) Set(_ context.Context, key string, _ []byte,
+	_ time.Time, _ cache.Type) error {
+	//
+	s.mu.Lock()
+	s.keys = append(s.keys, key)
+	first := len(s.keys) == 1
+	s.mu.Unlock()
+
+	if first {
+		close(s.started)
+		<-s.release
+	}
+	return nil
+}
+
+func (s *blockingSetter) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.keys...)
+}
+
+// recordingDeleter records the keys deleted
+type recordingDeleter struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (d *recordingDeleter) Delete(_ context.Context, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.keys = append(d.keys, key)
+	return nil
+}
+
+func (d *recordingDeleter) Keys() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string(nil), d.keys...)
+}
+
+func newTestCache() *memcache.Cache[string] {
+	return memcache.NewCache[string]("test", 1<<20, cache.GetterFunc[string](
+		func(context.Context, string, cache.Sink) error { return cache.ErrNotFound }))
+}
+
+// newTestWriteBehind returns a [WriteBehind] with room for two pending
+// writes, with k0 being written and held until the setter is released
+func newTestWriteBehind(t *testing.T, setter *blockingSetter,
+	deleter cache.Deleter[string]) (*WriteBehind[string], *memcache.Cache[string]) {
+	//
+	t.Helper()
+
+	c := newTestCache()
+	wb, err := NewWriteBehind[string](c, setter, deleter, &Config{QueueSize: 2})
+	core.AssertMustNoError(t, err, "NewWriteBehind")
+	t.Cleanup(func() { _ = wb.Close(context.Background()) })
+
+	core.AssertMustNoError(t, wbSet(context.Background(), wb, "k0"), "Set k0")
+	<-setter.started
+	return wb, c
+}
+
+func wbSet(ctx context.Context, wb *WriteBehind[string], key string) error {
+	return wb.Set(ctx, key, []byte(key), time.Time{}, cache.MainCache)
+}
+
+func runTestWriteBehindFull(t *testing.T) {
+	setter := newBlockingSetter()
+	wb, _ := newTestWriteBehind(t, setter, nil)
+	ctx := context.Background()
+
+	// fill the queue while k0 is being written
+	core.AssertMustNoError(t, wbSet(ctx, wb, "k1"), "Set k1")
+	core.AssertMustNoError(t, wbSet(ctx, wb, "k2"), "Set k2")
+	core.AssertMustNoError(t, wbSet(ctx, wb, "k1"), "Set k1 again")
+	core.AssertEqual(t, int64(1), wb.QueueStats().Coalesced, "coalesced")
+
+	tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	core.AssertErrorIs(t, wbSet(tctx, wb, "k3"), context.DeadlineExceeded, "Set on a full queue")
+
+	close(setter.release)
+	core.AssertMustNoError(t, wb.Flush(ctx), "Flush")
+	core.AssertSliceEqual(t, []string{"k0", "k1", "k2"}, setter.Keys(), "written")
+	core.AssertEqual(t, int64(3), wb.QueueStats().Written, "Written")
+}
+
+func runTestWriteBehindRemove(t *testing.T) {
+	setter := newBlockingSetter()
+	deleter := new(recordingDeleter)
+	wb, c := newTestWriteBehind(t, setter, deleter)
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, wbSet(ctx, wb, "k1"), "Set k1")
+	core.AssertTrue(t, c.Contains(ctx, "k1"), "cached")
+
+	// the delete replaces the pending write
+	wb.Remove(ctx, "k1")
+	core.AssertFalse(t, c.Contains(ctx, "k1"), "evicted")
+	core.AssertEqual(t, 1, wb.QueueStats().Pending, "pending after Remove")
+
+	close(setter.release)
+	core.AssertMustNoError(t, wb.Flush(ctx), "Flush")
+	core.AssertSliceEqual(t, []string{"k0"}, setter.Keys(), "written")
+	core.AssertSliceEqual(t, []string{"k1"}, deleter.Keys(), "deleted")
+}
+
+func runTestWriteBehindRemoveNoDeleter(t *testing.T) {
+	setter := newBlockingSetter()
+	wb, c := newTestWriteBehind(t, setter, nil)
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, wbSet(ctx, wb, "k1"), "Set k1")
+
+	// the pending write isn't lost
+	wb.Remove(ctx, "k1")
+	core.AssertFalse(t, c.Contains(ctx, "k1"), "evicted")
+	core.AssertEqual(t, 1, wb.QueueStats().Pending, "pending after Remove")
+
+	close(setter.release)
+	core.AssertMustNoError(t, wb.Flush(ctx), "Flush")
+	core.AssertSliceEqual(t, []string{"k0", "k1"}, setter.Keys(), "written")
+}
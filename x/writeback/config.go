@@ -0,0 +1,77 @@
+package writeback
+
+import (
+	"time"
+
+	"darvaza.org/core"
+)
+
+const (
+	// DefaultQueueSize is the default number of keys pending to be
+	// written
+	DefaultQueueSize = 1024
+	// DefaultMaxRetries is the default number of retries of a failed
+	// write before giving up
+	DefaultMaxRetries = 5
+	// DefaultMinBackoff is the default delay before the first retry
+	DefaultMinBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is the default limit of the delay between
+	// retries
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// Config tunes a [WriteBehind]
+type Config struct {
+	// QueueSize is the maximum number of keys pending to be written.
+	// Writes to a key already pending replace it without using more
+	// room. Defaults to [DefaultQueueSize].
+	QueueSize int
+	// MaxRetries is how many times a failed write is retried before
+	// it's dropped and counted as a failure, negative for no retries.
+	// Defaults to [DefaultMaxRetries].
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled on each
+	// attempt. Defaults to [DefaultMinBackoff].
+	MinBackoff time.Duration
+	// MaxBackoff limits the delay between retries.
+	// Defaults to [DefaultMaxBackoff].
+	MaxBackoff time.Duration
+}
+
+// SetDefaults fills the gaps and identifies errors.
+func (cfg *Config) SetDefaults() error {
+	if cfg == nil {
+		return core.ErrNilReceiver
+	}
+
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.MinBackoff == 0 {
+		cfg.MinBackoff = DefaultMinBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+
+	switch {
+	case cfg.QueueSize < 0:
+		return core.Wrap(core.ErrInvalid, "negative queue size")
+	case cfg.MinBackoff < 0, cfg.MaxBackoff < cfg.MinBackoff:
+		return core.Wrap(core.ErrInvalid, "invalid backoff")
+	default:
+		return nil
+	}
+}
+
+// backoff returns the delay before the given retry
+func (cfg *Config) backoff(attempt int) time.Duration {
+	d := cfg.MinBackoff
+	for i := 1; i < attempt && d < cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, cfg.MaxBackoff)
+}
@@ -0,0 +1,92 @@
+package writeback
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+	"darvaza.org/slog"
+)
+
+var (
+	_ cache.Cache[string] = (*WriteThrough[string])(nil)
+)
+
+// WriteThrough is a [cache.Cache] decorator that writes to a backing
+// store before updating the cache, so the cache never holds data the
+// store rejected.
+type WriteThrough[K comparable] struct {
+	cache.Cache[K]
+
+	setter  cache.Setter[K]
+	deleter cache.Deleter[K]
+
+	mu  sync.Mutex
+	log slog.Logger
+}
+
+// NewWriteThrough creates a [WriteThrough] decorator. The deleter is
+// optional, without it Remove only affects the cache.
+func NewWriteThrough[K comparable](c cache.Cache[K], setter cache.Setter[K],
+	deleter cache.Deleter[K]) *WriteThrough[K] {
+	//
+	if c == nil || setter == nil {
+		core.Panic("missing parameters")
+	}
+
+	return &WriteThrough[K]{
+		Cache:   c,
+		setter:  setter,
+		deleter: deleter,
+	}
+}
+
+// Set writes the entry to the backing store, and then to the cache
+func (wt *WriteThrough[K]) Set(ctx context.Context, key K, value []byte,
+	expire time.Time, cacheType cache.Type) error {
+	//
+	if err := wt.setter.Set(ctx, key, value, expire, cacheType); err != nil {
+		return err
+	}
+	return wt.Cache.Set(ctx, key, value, expire, cacheType)
+}
+
+// Remove deletes the entry from the backing store, and evicts it
+// from the cache even if that failed
+func (wt *WriteThrough[K]) Remove(ctx context.Context, key K) {
+	if wt.deleter != nil {
+		if err := wt.deleter.Delete(ctx, key); err != nil {
+			if log, ok := wt.withLogger(slog.Error); ok {
+				log.WithField("key", key).
+					WithField(slog.ErrorFieldName, err).
+					Print("delete failed")
+			}
+		}
+	}
+
+	wt.Cache.Remove(ctx, key)
+}
+
+// SetLogger attaches a [slog.Logger] to the [WriteThrough]
+func (wt *WriteThrough[K]) SetLogger(log slog.Logger) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+
+	wt.log = log
+}
+
+func (wt *WriteThrough[K]) withLogger(level slog.LogLevel) (slog.Logger, bool) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+
+	if wt.log != nil {
+		log, ok := wt.log.WithLevel(level).WithEnabled()
+		if ok {
+			log = log.WithField("cache", wt.Name())
+			return log, true
+		}
+	}
+	return nil, false
+}
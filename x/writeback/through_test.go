@@ -0,0 +1,91 @@
+package writeback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"darvaza.org/cache"
+	"darvaza.org/core"
+)
+
+func TestWriteThrough(t *testing.T) {
+	t.Run("set", runTestWriteThroughSet)
+	t.Run("set failed", runTestWriteThroughSetFailed)
+	t.Run("remove", runTestWriteThroughRemove)
+	t.Run("remove failed", runTestWriteThroughRemoveFailed)
+	t.Run("remove without deleter", runTestWriteThroughRemoveNoDeleter)
+}
+
+// failingSetter rejects every write
+var failingSetter = cache.SetterFunc[string](func(context.Context, string, []byte,
+	time.Time, cache.Type) error {
+	//
+	return core.ErrInvalid
+})
+
+func runTestWriteThroughSet(t *testing.T) {
+	setter := newBlockingSetter()
+	close(setter.release)
+
+	c := newTestCache()
+	wt := NewWriteThrough[string](c, setter, nil)
+	ctx := context.Background()
+
+	err := wt.Set(ctx, "k1", []byte("v1"), time.Time{}, cache.MainCache)
+	core.AssertNoError(t, err, "Set")
+	core.AssertSliceEqual(t, []string{"k1"}, setter.Keys(), "written")
+
+	var sink cache.ByteSink
+	core.AssertNoError(t, wt.Get(ctx, "k1", &sink), "Get")
+	core.AssertEqual(t, "v1", string(sink.Bytes()), "cached")
+}
+
+func runTestWriteThroughSetFailed(t *testing.T) {
+	c := newTestCache()
+	wt := NewWriteThrough[string](c, failingSetter, nil)
+	ctx := context.Background()
+
+	err := wt.Set(ctx, "k1", []byte("v1"), time.Time{}, cache.MainCache)
+	core.AssertErrorIs(t, err, core.ErrInvalid, "Set")
+	core.AssertFalse(t, c.Contains(ctx, "k1"), "not cached")
+}
+
+func runTestWriteThroughRemove(t *testing.T) {
+	c := newTestCache()
+	deleter := new(recordingDeleter)
+	wt := NewWriteThrough[string](c, newBlockingSetter(), deleter)
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, c.Set(ctx, "k1", []byte("v1"), time.Time{}, cache.MainCache), "Set")
+
+	wt.Remove(ctx, "k1")
+	core.AssertSliceEqual(t, []string{"k1"}, deleter.Keys(), "deleted")
+	core.AssertFalse(t, c.Contains(ctx, "k1"), "evicted")
+}
+
+func runTestWriteThroughRemoveFailed(t *testing.T) {
+	c := newTestCache()
+	deleter := cache.DeleterFunc[string](func(context.Context, string) error {
+		return core.ErrInvalid
+	})
+	wt := NewWriteThrough[string](c, newBlockingSetter(), deleter)
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, c.Set(ctx, "k1", []byte("v1"), time.Time{}, cache.MainCache), "Set")
+
+	// evicted anyway
+	wt.Remove(ctx, "k1")
+	core.AssertFalse(t, c.Contains(ctx, "k1"), "evicted")
+}
+
+func runTestWriteThroughRemoveNoDeleter(t *testing.T) {
+	c := newTestCache()
+	wt := NewWriteThrough[string](c, newBlockingSetter(), nil)
+	ctx := context.Background()
+
+	core.AssertMustNoError(t, c.Set(ctx, "k1", []byte("v1"), time.Time{}, cache.MainCache), "Set")
+
+	wt.Remove(ctx, "k1")
+	core.AssertFalse(t, c.Contains(ctx, "k1"), "evicted")
+}
@@ -0,0 +1,134 @@
+package writeback
+
+import (
+	"context"
+	"time"
+
+	"darvaza.org/slog"
+)
+
+// run writes the queued operations in order until closed
+func (wb *WriteBehind[K]) run() {
+	defer close(wb.done)
+
+	for {
+		o, ok := wb.next()
+		if !ok {
+			return
+		}
+
+		wb.write(o)
+	}
+}
+
+// next waits for the oldest pending write, and returns false once
+// stopped
+func (wb *WriteBehind[K]) next() (*op[K], bool) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	wb.inflight = false
+	wb.cond.Broadcast()
+
+	for {
+		if wb.ctx.Err() != nil {
+			wb.dropAll()
+			return nil, false
+		}
+
+		for len(wb.order) > 0 {
+			key := wb.order[0]
+			wb.order = wb.order[1:]
+
+			if o, ok := wb.pending[key]; ok {
+				delete(wb.pending, key)
+				wb.inflight = true
+				return o, true
+			}
+		}
+
+		wb.cond.Wait()
+	}
+}
+
+// dropAll discards the queue when stopped. Must hold the lock.
+func (wb *WriteBehind[K]) dropAll() {
+	wb.stats.Failures += int64(len(wb.pending))
+	clear(wb.pending)
+	wb.order = nil
+	wb.cond.Broadcast()
+}
+
+// write applies an operation on the backing store, retrying with
+// backoff until it succeeds, it's superseded by a newer write of the
+// same key, it runs out of retries, or the [WriteBehind] is stopped.
+func (wb *WriteBehind[K]) write(o *op[K]) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && wb.superseded(o.key) {
+			wb.count(func(st *QueueStats) { st.Coalesced++ })
+			return
+		}
+
+		err := wb.apply(o)
+		if err == nil {
+			wb.count(func(st *QueueStats) { st.Written++ })
+			return
+		}
+
+		if attempt >= wb.cfg.MaxRetries || !sleep(wb.ctx, wb.cfg.backoff(attempt+1)) {
+			wb.count(func(st *QueueStats) { st.Failures++ })
+			wb.logFailure(o, err)
+			return
+		}
+
+		wb.count(func(st *QueueStats) { st.Retries++ })
+	}
+}
+
+func (wb *WriteBehind[K]) apply(o *op[K]) error {
+	if o.remove {
+		return wb.deleter.Delete(wb.ctx, o.key)
+	}
+	return wb.setter.Set(wb.ctx, o.key, o.value, o.expire, o.cacheType)
+}
+
+// superseded tells if a newer write of the key is pending
+func (wb *WriteBehind[K]) superseded(key K) bool {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	_, ok := wb.pending[key]
+	return ok
+}
+
+func (wb *WriteBehind[K]) count(fn func(*QueueStats)) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	fn(&wb.stats)
+}
+
+func (wb *WriteBehind[K]) logFailure(o *op[K], err error) {
+	if log, ok := wb.withLogger(slog.Error); ok {
+		op := "set"
+		if o.remove {
+			op = "delete"
+		}
+
+		log.WithField("key", o.key).
+			WithField(slog.ErrorFieldName, err).
+			Printf("%s failed", op)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,4 @@
+// Package writeback provides cache.Cache decorators forwarding
+// writes to a persistent backing store, synchronously or in the
+// background
+package writeback